@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"igc-tool/internal/airspace"
+	"igc-tool/internal/cli"
+	"igc-tool/internal/config"
+	"igc-tool/internal/flags"
+	"igc-tool/internal/parser"
+	"igc-tool/internal/source"
+	"igc-tool/internal/units"
+	"igc-tool/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// NewAirspaceCmd creates and returns the airspace command
+func NewAirspaceCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
+	var airspaceCmd = &cobra.Command{
+		Use:   "airspace [IGC file or URL]",
+		Short: "Check a flight for airspace infringements",
+		Long: `Parse an IGC file and report every stretch of fixes inside one of the airspaces
+given with --airspaces, a GeoJSON FeatureCollection of Polygon or MultiPolygon
+features. Each feature is named by its "name" property and limited vertically by
+its "floor" and "ceiling" properties in meters above sea level; a missing floor is
+the ground and a missing ceiling is unlimited. Fixes are checked with their GPS
+altitude.
+
+For each infringement the entry and exit times are printed with the maximum
+penetration: horizontally, the deepest distance inside the boundary in the
+--distance-unit, and vertically, the deepest distance inside the floor or ceiling.
+
+The file may also be an http:// or https:// URL.
+
+Exit Codes:
+  0  the flight infringes no airspace
+  1  an error stopped the command
+  3  the flight infringes at least one airspace`,
+		Example: `  igc-tool airspace --airspaces airspaces.geojson flight.igc`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			filename := args[0]
+			airspaceFlags := flagConfig.GetAirspaceFromFlags(cmd)
+			commonFlags := flagConfig.GetCommonFromConfig(cmd, cfg)
+
+			airspaces, err := airspace.LoadAirspaces(airspaceFlags.File)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading airspaces: %v\n", err)
+				os.Exit(1)
+			}
+
+			flight, err := parser.ParseIGC(source.ForRef(filename), filename, parser.Options{Logger: cfg.Logger})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			infringements := airspace.CheckFlight(flight, airspaces)
+			if len(infringements) == 0 {
+				fmt.Printf("No airspace infringements (%d airspaces checked)\n", len(airspaces))
+				return
+			}
+
+			distanceSymbol := units.DistanceSymbol(airspaceFlags.DistanceUnit)
+			heightSymbol := units.AltitudeSymbol(units.HeightUnit(commonFlags.AltitudeUnit))
+			for _, infringement := range infringements {
+				fmt.Printf("%s: %s - %s, max penetration %.2f %s horizontal, %.0f %s vertical\n",
+					infringement.Airspace,
+					utils.FormatTime(infringement.Entry, commonFlags.TimeFormat),
+					utils.FormatTime(infringement.Exit, commonFlags.TimeFormat),
+					units.Distance(infringement.HorizontalPenetration, airspaceFlags.DistanceUnit), distanceSymbol,
+					units.Height(infringement.VerticalPenetration, commonFlags.AltitudeUnit),
+					heightSymbol)
+			}
+			fmt.Fprintf(os.Stderr, "%d infringements found\n", len(infringements))
+			os.Exit(cli.ExitInfringement)
+		},
+	}
+
+	// Set up flags
+	flagConfig.AddAirspaceFlags(airspaceCmd)
+	flagConfig.AddCommonFlags(airspaceCmd)
+
+	return airspaceCmd
+}
@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"igc-tool/internal/config"
 	"igc-tool/internal/flags"
+	"igc-tool/internal/utils"
 
 	"github.com/spf13/cobra"
 )
@@ -14,27 +17,121 @@ func NewConfigCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Comma
 	var configCmd = &cobra.Command{
 		Use:   "config",
 		Short: "Show current configuration",
-		Long:  `Display the current configuration values from config files, environment variables, and defaults.`,
+		Long: `Display the current configuration values from config files, environment variables,
+and defaults. The output is TOML, ready to paste into a config file, or JSON with
+--output json. The config file in use is shown as a TOML comment, and on stderr for JSON.
+JSON is indented on a terminal and compact when piped, unless --indent or --compact is
+given.
+
+Examples:
+  igc-tool config
+  igc-tool config --output json`,
+		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("Current configuration:")
+			configFlags := flagConfig.GetConfigFromFlags(cmd)
+			jsonFlags := flagConfig.GetJSONFromFlags(cmd, utils.IsTerminal(os.Stdout))
+
+			data, err := cfg.Marshal(configFlags.Output, jsonFlags.Indent)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
 			configFile := cfg.ConfigFile
 			if configFile == "" {
-				configFile = "No config file found (using defaults)"
+				configFile = "none found (using defaults)"
 			}
-			fmt.Printf("Config file used: %s\n\n", configFile)
-
-			commonFlags := flagConfig.GetCommonFromConfig(cmd, cfg)
-			logbookFlags := flagConfig.GetLogbookFromConfig(cmd, cfg)
-
-			fmt.Printf("altitude-unit: %s\n", commonFlags.AltitudeUnit)
-			fmt.Printf("time-format: %s\n", commonFlags.TimeFormat)
-			fmt.Printf("speed-unit: %s\n", logbookFlags.SpeedUnit)
-			fmt.Printf("climb-unit: %s\n", logbookFlags.ClimbUnit)
-			fmt.Printf("logbook-format: %s\n", logbookFlags.Format)
-			fmt.Printf("sites-database-location: %s\n", logbookFlags.Sites)
-			fmt.Printf("speed-window: %g\n", logbookFlags.SpeedWindow)
+			if configFlags.Output == config.OutputTOML {
+				fmt.Printf("# Config file: %s\n", configFile)
+			} else {
+				fmt.Fprintf(os.Stderr, "Config file: %s\n", configFile)
+			}
+			os.Stdout.Write(data)
 		},
 	}
 
+	flagConfig.AddConfigFlags(configCmd)
+	flagConfig.AddJSONFlags(configCmd)
+
+	configCmd.AddCommand(newConfigInitCmd(flagConfig))
+	configCmd.AddCommand(newConfigSetCmd(cfg))
+
 	return configCmd
 }
+
+// newConfigInitCmd creates the config init subcommand
+func newConfigInitCmd(flagConfig *flags.FlagConfig) *cobra.Command {
+	var initCmd = &cobra.Command{
+		Use:   "init",
+		Short: "Write a config file with all defaults",
+		Long: `Write a commented ` + config.FileName + ` listing every setting with its default value,
+ready to edit. The file goes to ~/.config/igc-tool unless --dir is given, and an
+existing file is only replaced with --force.
+
+Examples:
+  igc-tool config init
+  igc-tool config init --dir .
+  igc-tool config init --force`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			initFlags := flagConfig.GetConfigInitFromFlags(cmd)
+
+			dir := initFlags.Dir
+			if dir == "" {
+				var err error
+				if dir, err = config.DefaultDir(); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			path, err := config.WriteDefaultFile(dir, initFlags.Force)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Config file written to %s\n", path)
+		},
+	}
+
+	flagConfig.AddConfigInitFlags(initCmd)
+
+	return initCmd
+}
+
+// newConfigSetCmd creates the config set subcommand
+func newConfigSetCmd(cfg *config.Config) *cobra.Command {
+	var setCmd = &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Store a single value in the config file",
+		Long: `Validate a value and store it in the config file in use, or in
+~/.config/igc-tool/` + config.FileName + ` when there is none, creating it if missing.
+Units and formats are checked against the values the commands accept.
+Other settings in the file are kept but its comments are not.
+
+Examples:
+  igc-tool config set altitude-unit ft
+  igc-tool config set speed-window 3
+  igc-tool config set sites-database-location ~/sites.geojson`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := cfg.ConfigFile
+			if path == "" {
+				dir, err := config.DefaultDir()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				path = filepath.Join(dir, config.FileName)
+			}
+
+			if err := config.SetValue(path, args[0], args[1]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s set to %s in %s\n", args[0], args[1], path)
+		},
+	}
+
+	return setCmd
+}
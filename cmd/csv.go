@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"igc-tool/internal/cli"
+	"igc-tool/internal/config"
+	"igc-tool/internal/flags"
+	"igc-tool/internal/parser"
+	"igc-tool/internal/renderer"
+	"igc-tool/internal/source"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCSVCmd creates and returns the csv command
+func NewCSVCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
+	var csvCmd = &cobra.Command{
+		Use:   "csv [IGC file or URL]",
+		Short: "Export the raw fixes of a flight as CSV",
+		Long: `Parse an IGC file and write one CSV row per fix with the columns time, lat, lon,
+alt_gps and alt_baro, for spreadsheets or pandas. Altitudes follow --altitude-unit
+and times follow --time-format. With --speeds, the instantaneous ground speed,
+vertical speed and heading since the previous fix are added.
+
+The file may also be an http:// or https:// URL.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			filename := args[0]
+			csvFlags := flagConfig.GetCSVFromFlags(cmd)
+			commonFlags := flagConfig.GetCommonFromConfig(cmd, cfg)
+
+			flight, err := parser.ParseIGC(source.ForRef(filename), filename, parser.Options{Logger: cfg.Logger})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			var buf bytes.Buffer
+			err = renderer.WriteFixesCSV(&buf, flight, renderer.FixesCSVOptions{
+				AltitudeUnit:  commonFlags.AltitudeUnit,
+				TimeFormat:    commonFlags.TimeFormat,
+				IncludeSpeeds: csvFlags.Speeds,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := cli.WriteOutput(csvFlags.Output, "CSV", buf.Bytes()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	// Set up flags
+	flagConfig.AddCSVFlags(csvCmd)
+	flagConfig.AddCommonFlags(csvCmd)
+
+	return csvCmd
+}
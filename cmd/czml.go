@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"igc-tool/internal/cli"
+	"igc-tool/internal/config"
+	"igc-tool/internal/flags"
+	flightpkg "igc-tool/internal/flight"
+	"igc-tool/internal/parser"
+	"igc-tool/internal/renderer"
+	"igc-tool/internal/source"
+	"igc-tool/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCZMLCmd creates and returns the czml command
+func NewCZMLCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
+	var czmlCmd = &cobra.Command{
+		Use:   "czml [IGC file or URL]",
+		Short: "Convert IGC flight track to CZML for animated 3D playback",
+		Long: `Parse an IGC file and convert the flight track to a CZML document for Cesium and
+other 3D globe viewers, with the glider animated along its time-sampled positions.
+The file may also be an http:// or https:// URL.
+
+Coordinates are assumed to use the WGS84 datum. Files declaring another datum in
+their HFDTM header are rejected unless --force is given, since the track would be
+offset against the globe imagery.
+
+--smooth-altitude averages the altitudes over a moving window (in seconds) for a
+cleaner 3D track. It is purely cosmetic and does not alter the source file.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			filename := args[0]
+			renderFlags := flagConfig.GetRenderFromFlags(cmd)
+			jsonFlags := flagConfig.GetJSONFromFlags(cmd, renderFlags.Output == "" && utils.IsTerminal(os.Stdout))
+			anonymizeFlags := flagConfig.GetAnonymizeFromFlags(cmd)
+
+			if err := flightpkg.ValidateAnonymizeLevel(anonymizeFlags.Level); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			flight, err := parser.ParseIGC(source.ForRef(filename), filename, parser.Options{Logger: cfg.Logger})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if !flight.HasWGS84Datum() && !renderFlags.Force {
+				fmt.Fprintf(os.Stderr, "Error: %s declares GPS datum %q, not WGS84 (use --force to render anyway)\n", filename, flight.GPSDatum)
+				os.Exit(1)
+			}
+			cli.WarnIfNotWGS84(flight, filename)
+			cli.WarnIfClockProblems(flight, filename)
+			flight = flight.Anonymize(anonymizeFlags.Level)
+
+			czmlData, err := renderer.RenderToCZML(flight.SmoothAltitude(renderFlags.SmoothAltitude))
+			if err == nil {
+				czmlData, err = utils.IndentJSON(czmlData, jsonFlags.Indent)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error rendering CZML: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := cli.WriteOutput(renderFlags.Output, "CZML", czmlData); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	// Set up flags
+	flagConfig.AddCZMLFlags(czmlCmd)
+	flagConfig.AddJSONFlags(czmlCmd)
+	flagConfig.AddAnonymizeFlags(czmlCmd)
+
+	return czmlCmd
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"os"
 
+	"igc-tool/internal/cli"
 	"igc-tool/internal/config"
 	"igc-tool/internal/flags"
+	flightpkg "igc-tool/internal/flight"
 	"igc-tool/internal/geojson"
 	"igc-tool/internal/parser"
+	"igc-tool/internal/source"
+	"igc-tool/internal/utils"
 
 	"github.com/spf13/cobra"
 )
@@ -15,41 +19,115 @@ import (
 // NewGeoJSONCmd creates and returns the geojson command
 func NewGeoJSONCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
 	var geojsonCmd = &cobra.Command{
-		Use:   "geojson [IGC file]",
+		Use:   "geojson [IGC files or URLs...]",
 		Short: "Convert IGC flight track to GeoJSON",
-		Long:  `Parse an IGC file and convert the flight track to a GeoJSON LineString feature.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Parse an IGC file and convert the flight track to a GeoJSON LineString feature.
+
+Given several files flown together, the tracks are merged into a FeatureCollection
+for a time-synchronized gaggle view: each feature carries a "coordTimes" property
+with the UTC time of every coordinate, a "flight_index" and a "color". A warning is
+printed for flights that do not overlap in time with any other.
+
+Files may also be http:// or https:// URLs, fetched with a 30 second timeout and a
+10 MiB size limit.
+
+Coordinates are assumed to use the WGS84 datum. Files declaring another datum in
+their HFDTM header are rejected unless --force is given, since the track would be
+offset against standard web maps.
+
+--smooth-altitude averages the altitudes over a moving window (in seconds) for a
+cleaner 3D track. It is purely cosmetic and, unlike outlier rejection, also
+flattens genuine short climbs; metadata statistics use the original altitudes.
+
+--thermals adds a Point feature for each detected thermal at its centroid, with
+"gain", "climb_rate", "start_time", "end_time" and "duration_seconds" properties,
+colored and sized by climb rate with the simplestyle "marker-color" and
+"marker-size" properties, turning a single track into a FeatureCollection.
+
+--markers likewise adds takeoff and landing Point features, with "feature_type",
+"time" and "altitude" properties, for web maps that expect a FeatureCollection.
+Without --thermals or --markers a single track is output as a single Feature.
+
+--coord-times adds a "coordTimes" property holding the RFC3339 UTC time of each
+track coordinate, in the same order, so time-aware players can replay the track.
+Gaggles always include it.`,
+		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			filename := args[0]
 			renderFlags := flagConfig.GetRenderFromFlags(cmd)
+			geojsonFlags := flagConfig.GetGeoJSONFromFlags(cmd)
+			jsonFlags := flagConfig.GetJSONFromFlags(cmd, renderFlags.Output == "" && utils.IsTerminal(os.Stdout))
+			anonymizeFlags := flagConfig.GetAnonymizeFromFlags(cmd)
 
-			flight, err := parser.ParseIGCFile(filename)
-			if err != nil {
+			if err := flightpkg.ValidateAnonymizeLevel(anonymizeFlags.Level); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 
-			geojsonData, err := geojson.RenderToGeoJSON(flight, renderFlags.Pretty, renderFlags.IncludeMetadata)
+			var flights []*flightpkg.Flight
+			for _, filename := range args {
+				flight, err := parser.ParseIGC(source.ForRef(filename), filename, parser.Options{Logger: cfg.Logger})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+
+				if !flight.HasWGS84Datum() && !renderFlags.Force {
+					fmt.Fprintf(os.Stderr, "Error: %s declares GPS datum %q, not WGS84 (use --force to render anyway)\n", filename, flight.GPSDatum)
+					os.Exit(1)
+				}
+				cli.WarnIfNotWGS84(flight, filename)
+				cli.WarnIfClockProblems(flight, filename)
+				flights = append(flights, flight.Anonymize(anonymizeFlags.Level))
+			}
+
+			layers := geojson.LayerOptions{
+				Thermals:   geojsonFlags.Thermals,
+				Markers:    geojsonFlags.Markers,
+				CoordTimes: geojsonFlags.CoordTimes,
+			}
+
+			var geojsonData []byte
+			var err error
+			if len(flights) == 1 {
+				geojsonData, err = geojson.RenderToGeoJSON(flights[0], jsonFlags.Indent, renderFlags.IncludeMetadata, geojsonFlags.SpeedWindow, renderFlags.SmoothAltitude, layers)
+			} else {
+				warnIfNotOverlapping(flights, args)
+				geojsonData, err = geojson.RenderGaggleToGeoJSON(flights, jsonFlags.Indent, renderFlags.IncludeMetadata, geojsonFlags.SpeedWindow, renderFlags.SmoothAltitude, layers)
+			}
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error rendering GeoJSON: %v\n", err)
 				os.Exit(1)
 			}
 
-			if renderFlags.Output != "" {
-				err := os.WriteFile(renderFlags.Output, geojsonData, 0644)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error writing to file %s: %v\n", renderFlags.Output, err)
-					os.Exit(1)
-				}
-				fmt.Fprintf(os.Stderr, "GeoJSON written to %s\n", renderFlags.Output)
-			} else {
-				fmt.Print(string(geojsonData))
+			if err := cli.WriteOutput(renderFlags.Output, "GeoJSON", geojsonData); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
 			}
 		},
 	}
 
 	// Set up flags
 	flagConfig.AddRenderFlags(geojsonCmd)
+	flagConfig.AddGeoJSONFlags(geojsonCmd)
+	flagConfig.AddJSONFlags(geojsonCmd)
+	flagConfig.AddAnonymizeFlags(geojsonCmd)
 
 	return geojsonCmd
 }
+
+// warnIfNotOverlapping prints a warning for each flight that was not in the air at the
+// same time as any other flight, since there is nothing to compare it with
+func warnIfNotOverlapping(flights []*flightpkg.Flight, filenames []string) {
+	for i, f := range flights {
+		overlapping := false
+		for j, other := range flights {
+			if i != j && f.OverlapsInTime(other) {
+				overlapping = true
+				break
+			}
+		}
+		if !overlapping {
+			fmt.Fprintf(os.Stderr, "Warning: %s does not overlap in time with any other flight\n", filenames[i])
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"igc-tool/internal/cli"
+	"igc-tool/internal/config"
+	"igc-tool/internal/flags"
+	"igc-tool/internal/inspect"
+	"igc-tool/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+// NewInspectCmd creates and returns the inspect command
+func NewInspectCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
+	var inspectCmd = &cobra.Command{
+		Use:   "inspect [IGC files or directories...]",
+		Short: "List distinct header values across flights",
+		Long: fmt.Sprintf(`List every distinct value of a header field across IGC files, with the number of
+flights using it, sorted by frequency. Only the file headers are read, so large
+archives are scanned quickly. Useful to spot inconsistencies such as "John Doe"
+and "J. Doe" before building a logbook.
+
+Supported fields: %s
+
+Examples:
+  igc-tool inspect --field pilot -r flights/
+  igc-tool inspect --field glider-type *.igc`, strings.Join(inspect.Fields, ", ")),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			inspectFlags := flagConfig.GetInspectFromFlags(cmd)
+
+			if err := inspect.ValidateField(inspectFlags.Field); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			igcFiles, err := cli.FindIGCFiles(args, inspectFlags.Recursive)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding IGC files: %v\n", err)
+				os.Exit(1)
+			}
+
+			if len(igcFiles) == 0 {
+				fmt.Fprintf(os.Stderr, "No IGC files found\n")
+				os.Exit(1)
+			}
+
+			var values []string
+			for _, filename := range igcFiles {
+				flight, err := parser.ParseIGCHeaders(filename)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", filename, err)
+					continue
+				}
+
+				value, _ := inspect.HeaderValue(flight, inspectFlags.Field)
+				values = append(values, value)
+			}
+
+			for _, valueCount := range inspect.CountValues(values) {
+				value := valueCount.Value
+				if value == "" {
+					value = "(empty)"
+				}
+				fmt.Printf("%6d  %s\n", valueCount.Count, value)
+			}
+		},
+	}
+
+	// Set up flags
+	flagConfig.AddInspectFlags(inspectCmd)
+
+	return inspectCmd
+}
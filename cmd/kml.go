@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"igc-tool/internal/cli"
+	"igc-tool/internal/config"
+	"igc-tool/internal/flags"
+	flightpkg "igc-tool/internal/flight"
+	"igc-tool/internal/parser"
+	"igc-tool/internal/renderer"
+	"igc-tool/internal/source"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKMLCmd creates and returns the kml command
+func NewKMLCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
+	var kmlCmd = &cobra.Command{
+		Use:   "kml [IGC file or URL]",
+		Short: "Convert IGC flight track to KML for Google Earth",
+		Long: `Parse an IGC file and convert the flight track to a KML document for Google Earth.
+The track is a LineString with absolute altitudes so it floats at the recorded GPS
+altitude. The file may also be an http:// or https:// URL.
+
+--track adds a time-stamped gx:Track for animated playback, and --color-by-climb
+splits the line into segments colored by vertical speed: blue for strong sink, cyan
+for sink, green for level flight, yellow for climb and red for strong climb.
+
+Coordinates are assumed to use the WGS84 datum. Files declaring another datum in
+their HFDTM header are rejected unless --force is given, since the track would be
+offset against the imagery.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			filename := args[0]
+			renderFlags := flagConfig.GetRenderFromFlags(cmd)
+			kmlFlags := flagConfig.GetKMLFromFlags(cmd)
+			anonymizeFlags := flagConfig.GetAnonymizeFromFlags(cmd)
+
+			if err := flightpkg.ValidateAnonymizeLevel(anonymizeFlags.Level); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			flight, err := parser.ParseIGC(source.ForRef(filename), filename, parser.Options{Logger: cfg.Logger})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if !flight.HasWGS84Datum() && !renderFlags.Force {
+				fmt.Fprintf(os.Stderr, "Error: %s declares GPS datum %q, not WGS84 (use --force to render anyway)\n", filename, flight.GPSDatum)
+				os.Exit(1)
+			}
+			cli.WarnIfNotWGS84(flight, filename)
+			cli.WarnIfClockProblems(flight, filename)
+			flight = flight.Anonymize(anonymizeFlags.Level)
+
+			opts := renderer.KMLOptions{
+				Track:        kmlFlags.Track,
+				ColorByClimb: kmlFlags.ColorByClimb,
+			}
+			if renderFlags.Pretty {
+				opts.Indent = "  "
+			}
+			if renderFlags.IncludeMetadata {
+				opts.Description = kmlDescription(flight)
+			}
+
+			kmlData, err := renderer.RenderToKML(flight.SmoothAltitude(renderFlags.SmoothAltitude), opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error rendering KML: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := cli.WriteOutput(renderFlags.Output, "KML", append(kmlData, '\n')); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	// Set up flags
+	flagConfig.AddRenderFlags(kmlCmd)
+	flagConfig.AddKMLFlags(kmlCmd)
+	flagConfig.AddAnonymizeFlags(kmlCmd)
+
+	return kmlCmd
+}
+
+// kmlDescription returns the flight metadata shown in the KML document description,
+// which Google Earth renders as HTML
+func kmlDescription(flight *flightpkg.Flight) string {
+	var lines []string
+	if !flight.Date.IsZero() {
+		lines = append(lines, "Date: "+flight.Date.Format("2006-01-02"))
+	}
+	if flight.Pilot != "" {
+		lines = append(lines, "Pilot: "+flight.Pilot)
+	}
+	if flight.GliderType != "" {
+		lines = append(lines, "Glider: "+flight.GliderType)
+	}
+	if flight.GliderID != "" {
+		lines = append(lines, "Glider ID: "+flight.GliderID)
+	}
+	if flight.CompetitionID != "" {
+		lines = append(lines, "Competition ID: "+flight.CompetitionID)
+	}
+	return strings.Join(lines, "<br>")
+}
@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"igc-tool/internal/cli"
 	"igc-tool/internal/config"
 	"igc-tool/internal/flags"
+	flightpkg "igc-tool/internal/flight"
 	"igc-tool/internal/logbook"
 	"igc-tool/internal/parser"
+	"igc-tool/internal/utils"
 
 	"github.com/spf13/cobra"
 )
@@ -19,13 +22,20 @@ func NewLogbookCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Comm
 	var logbookCmd = &cobra.Command{
 		Use:   "logbook [IGC files or directories...]",
 		Short: "Generate logbook entries for flights",
-		Long: fmt.Sprintf(`Generate logbook entries for flights. Accepts multiple IGC files and/or directories containing IGC files.
+		Long: fmt.Sprintf(`Generate logbook entries for flights. Accepts multiple IGC files, directories containing IGC files and/or glob patterns.
 
 Template Variables (always available):
   Individual flight fields (access via .Flights array): %s
   
   Aggregated statistics: %s
 
+Template Functions (besides the Go template builtins such as printf):
+  add A B, sub A B        sum and difference, e.g. {{sub .MaxAltitude .TakeoffAlt}}
+  number N VALUE          VALUE with N decimals, e.g. {{.TrackDistance | number 1}}
+  pad W VALUE             VALUE padded with spaces to W characters (padLeft to right-align)
+  upper S, lower S        S in upper or lower case
+  date LAYOUT DATE        YYYY-MM-DD DATE in a Go time layout, e.g. {{.Date | date "02.01.2006"}}
+
 Examples:
   # Basic usage (single flight)
   igc-tool logbook flight1.igc
@@ -42,15 +52,121 @@ Examples:
   # Show only summary statistics
   igc-tool logbook --format "Summary: {{.TotalFlights}} flights, {{.TotalTime}} total time\n" *.igc
   
+  # Aligned columns with template functions
+  igc-tool logbook --format "{{range .Flights}}{{.Date | date \"02.01.06\"}} {{.Pilot | upper | pad 20}} {{.TrackDistance | number 1 | padLeft 6}}\n{{end}}" *.igc
+
   # Mix individual and aggregated data
-  igc-tool logbook --format "Flights:\n{{range .Flights}}- {{.Date}}: {{.FlightDuration}}\n{{end}}Total time: {{.TotalTime}}\n" *.igc`,
+  igc-tool logbook --format "Flights:\n{{range .Flights}}- {{.Date}}: {{.FlightDuration}}\n{{end}}Total time: {{.TotalTime}}\n" *.igc
+
+  # CSV for spreadsheets (semicolon-delimited with decimal commas for European locales)
+  igc-tool logbook --format csv --delimiter ";" --decimal-comma *.igc
+
+  # Quoted glob patterns are expanded by igc-tool, with ** matching nested directories
+  igc-tool logbook "2023/**/*.igc"
+
+  # Yearly summary from a whole archive
+  igc-tool logbook -r --since 2024-01-01 --until 2024-12-31 --stats-only ~/flights
+
+  # Flights and aggregates as JSON, for feeding other tools
+  igc-tool logbook --format json *.igc
+
+  # Styled HTML page with the flights and a summary, escaping names safely
+  igc-tool logbook --format html *.igc > logbook.html
+
+  # Multi-line layout kept in a file
+  igc-tool logbook --template-file logbook.tmpl *.igc
+
+  # HTML with your own html/template layout
+  igc-tool logbook --format html --template-file my-logbook.html *.igc > logbook.html
+
+  # JSON Schema describing the logbook data, for validation and generating bindings
+  igc-tool logbook --format json-schema
+
+  # Aggregate numbers only, for dashboards (key=value lines, or --stats-only=json)
+  igc-tool logbook --stats-only *.igc
+
+Exit Codes:
+  0  every file was read
+  1  no flights were processed, or an error stopped the command
+  2  the logbook was written, but some files could not be read or were not valid IGC
+
+With --strict, the first such file aborts the run with exit code 1 and no logbook is
+written, for guaranteed-complete output or nothing.`,
 			strings.Join(logbook.GetDataFields(), ", "),
 			strings.Join(logbook.GetTemplateDataFields(), ", ")),
-		Args: cobra.MinimumNArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			// The schema describes the data format and needs no input files
+			if flagConfig.GetLogbookFromConfig(cmd, cfg).Format == logbook.FormatJSONSchema {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			logbookFlags := flagConfig.GetLogbookFromConfig(cmd, cfg)
 			commonFlags := flagConfig.GetCommonFromConfig(cmd, cfg)
 
+			if logbookFlags.Format == logbook.FormatJSONSchema {
+				jsonFlags := flagConfig.GetJSONFromFlags(cmd, utils.IsTerminal(os.Stdout))
+				schema, err := utils.MarshalJSON(logbook.JSONSchema(), jsonFlags.Indent)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error rendering JSON schema: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Println(string(schema))
+				return
+			}
+			anonymizeFlags := flagConfig.GetAnonymizeFromFlags(cmd)
+			strictFlags := flagConfig.GetStrictFromFlags(cmd)
+
+			if err := flightpkg.ValidateAnonymizeLevel(anonymizeFlags.Level); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			switch logbookFlags.StatsOnly {
+			case "", logbook.StatsFormatKeyValue, logbook.StatsFormatJSON:
+			default:
+				fmt.Fprintf(os.Stderr, "Error: invalid --stats-only format %q: must be %s or %s\n",
+					logbookFlags.StatsOnly, logbook.StatsFormatKeyValue, logbook.StatsFormatJSON)
+				os.Exit(1)
+			}
+
+			dateFilter, err := logbook.ParseDateFilter(logbookFlags.Since, logbookFlags.Until)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := logbook.ValidateSortKey(logbookFlags.Sort); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := cli.CheckAltitudeSource(logbookFlags.AltitudeSource, logbookFlags.QNH); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			// A template file replaces an inline --format template, and the built-in
+			// layout of --format html
+			var htmlTemplate string
+			if logbookFlags.TemplateFile != "" {
+				switch {
+				case logbookFlags.FormatSet && logbookFlags.Format == logbook.FormatHTML:
+					data, err := os.ReadFile(logbookFlags.TemplateFile)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error reading template file: %v\n", err)
+						os.Exit(1)
+					}
+					htmlTemplate = string(data)
+				case logbookFlags.FormatSet:
+					fmt.Fprintf(os.Stderr, "Error: --template-file and --format are mutually exclusive, except with --format %s\n", logbook.FormatHTML)
+					os.Exit(1)
+				default:
+					logbookFlags.Format = ""
+				}
+			}
+
 			// Load landing sites if specified
 			landingSites, err := cli.LoadLandingSitesIfSpecified(logbookFlags.Sites)
 			if err != nil {
@@ -69,38 +185,116 @@ Examples:
 				fmt.Fprintf(os.Stderr, "No IGC files found\n")
 				os.Exit(1)
 			}
+			cfg.Logger.Debug("found IGC files", "count", len(igcFiles), "jobs", logbookFlags.Jobs)
 
 			// Collect all flight data
 			var allFlights []*logbook.Data
 			processedCount := 0
 
-			// Process each IGC file
-			for _, filename := range igcFiles {
-				flight, err := parser.ParseIGCFile(filename)
+			parseOptions := parser.Options{
+				Logger: cfg.Logger,
+				Retry: parser.RetryPolicy{
+					Retries: logbookFlags.Retries,
+					Backoff: logbookFlags.RetryBackoff,
+				},
+			}
+
+			// Process the IGC files concurrently; results keep the order of igcFiles
+			type fileResult struct {
+				data *logbook.Data
+				err  error
+			}
+			var progress *cli.Progress
+			if flagConfig.GetProgressFromFlags(cmd, utils.IsTerminal(os.Stderr)) {
+				progress = cli.NewProgress(os.Stderr, len(igcFiles))
+			}
+			start := time.Now()
+			results := cli.ProcessFiles(igcFiles, logbookFlags.Jobs, func(filename string) fileResult {
+				defer progress.Increment()
+				if err := parser.ValidateFile(filename); parser.IsInvalidContent(err) {
+					return fileResult{err: err}
+				}
+				flight, err := parser.ParseIGCFileWithRetry(filename, parseOptions)
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", filename, err)
-					continue
+					return fileResult{err: err}
+				}
+				if !dateFilter.Includes(flight.Date) {
+					cfg.Logger.Debug("skipping flight outside the date range", "file", filename, "date", flight.Date.Format(time.DateOnly))
+					return fileResult{}
 				}
+				flight = flight.Anonymize(anonymizeFlags.Level)
+				if logbookFlags.CollapseStalled {
+					flight = flight.CollapseStalled()
+				}
+				flight = flight.WithAltitudeSource(logbookFlags.AltitudeSource, logbookFlags.QNH)
 
 				// Create options using flag values
 				opts := logbook.Options{
-					LandingSites: landingSites,
-					Filename:     filename,
-					SpeedWindow:  logbookFlags.SpeedWindow,
-					AltitudeUnit: commonFlags.AltitudeUnit,
-					SpeedUnit:    logbookFlags.SpeedUnit,
-					ClimbUnit:    logbookFlags.ClimbUnit,
-					TimeFormat:   commonFlags.TimeFormat,
-				}
-				data := logbook.CreateData(flight, opts)
-				if data != nil {
-					allFlights = append(allFlights, data)
+					LandingSites:         landingSites,
+					Filename:             filename,
+					SpeedWindow:          logbookFlags.SpeedWindow,
+					LevelThreshold:       logbookFlags.LevelThreshold,
+					MinFixes:             logbookFlags.MinFixes,
+					ClimbNoise:           logbookFlags.ClimbNoise,
+					SmoothWindow:         logbookFlags.SmoothWindow,
+					AltitudeUnit:         commonFlags.AltitudeUnit,
+					SpeedUnit:            logbookFlags.SpeedUnit,
+					ClimbUnit:            logbookFlags.ClimbUnit,
+					DistanceUnit:         logbookFlags.DistanceUnit,
+					TimeFormat:           commonFlags.TimeFormat,
+					CoordFormat:          logbookFlags.CoordFormat,
+					CoordPrecision:       logbookFlags.CoordPrecision,
+					NearSiteDistance:     logbookFlags.NearSite,
+					TurnpointRadius:      logbookFlags.TurnpointRadius,
+					DetectTakeoffLanding: !logbookFlags.NoDetection,
+				}
+				return fileResult{data: logbook.CreateData(flight, opts)}
+			})
+			progress.Finish()
+			cfg.Logger.Debug("processed files", "count", len(igcFiles), "elapsed", time.Since(start))
+
+			failedCount := 0
+			for i, result := range results {
+				if result.err != nil && strictFlags.Strict {
+					fmt.Fprintf(os.Stderr, "Error: %s: %v\n", igcFiles[i], result.err)
+					os.Exit(cli.ExitFailure)
+				}
+				if parser.IsInvalidContent(result.err) {
+					fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", igcFiles[i], result.err)
+					failedCount++
+					continue
+				}
+				if result.err != nil {
+					fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", igcFiles[i], result.err)
+					failedCount++
+					continue
+				}
+				if result.data != nil {
+					allFlights = append(allFlights, result.data)
 					processedCount++
 				}
 			}
 
+			allFlights, skipped := logbook.FilterMinDuration(allFlights, logbookFlags.MinDuration)
+			if skipped > 0 {
+				fmt.Fprintf(os.Stderr, "Skipped %d flights shorter than %s\n", skipped, logbookFlags.MinDuration)
+				processedCount -= skipped
+			}
+
 			if processedCount == 0 {
 				fmt.Fprintf(os.Stderr, "No valid flights found\n")
+				os.Exit(cli.ExitFailure)
+			}
+
+			// A degraded run still writes the logbook, then exits with its own code
+			exitCode := cli.ExitOK
+			if failedCount > 0 {
+				fmt.Fprintf(os.Stderr, "Failed to read %d of %d files\n", failedCount, len(igcFiles))
+				exitCode = cli.ExitPartialFailure
+			}
+
+			if err := logbook.SortFlights(allFlights, logbookFlags.Sort, logbookFlags.Reverse); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 
@@ -109,22 +303,78 @@ Examples:
 				AltitudeUnit: commonFlags.AltitudeUnit,
 				SpeedUnit:    logbookFlags.SpeedUnit,
 				ClimbUnit:    logbookFlags.ClimbUnit,
+				DistanceUnit: logbookFlags.DistanceUnit,
 			})
 
-			// Use the template as-is - no automatic wrapping
-			templateStr := logbookFlags.Format
+			switch {
+			case logbookFlags.StatsOnly != "":
+				jsonFlags := flagConfig.GetJSONFromFlags(cmd, utils.IsTerminal(os.Stdout))
+				err := logbook.WriteAggregates(os.Stdout, templateData.Aggregates(), logbookFlags.StatsOnly, jsonFlags.Indent)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing statistics: %v\n", err)
+					os.Exit(1)
+				}
 
-			err = cli.PrintTemplatedLogbookData(templateData, templateStr)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error rendering template: %v\n", err)
-				os.Exit(1)
+			case logbookFlags.Format == logbook.FormatJSON:
+				jsonFlags := flagConfig.GetJSONFromFlags(cmd, utils.IsTerminal(os.Stdout))
+				data, err := utils.MarshalJSON(templateData, jsonFlags.Indent)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error rendering JSON: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Println(string(data))
+
+			case logbookFlags.Format == logbook.FormatCSV:
+				delimiter, err := cli.ParseDelimiter(logbookFlags.Delimiter)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+
+				err = cli.PrintCSVLogbookData(templateData, logbook.CSVOptions{
+					Delimiter:    delimiter,
+					DecimalComma: logbookFlags.DecimalComma,
+					BOM:          logbookFlags.BOM,
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+					os.Exit(1)
+				}
+
+			case logbookFlags.Format == logbook.FormatHTML:
+				if err := logbook.WriteHTML(os.Stdout, templateData, logbook.HTMLOptions{
+					Template: htmlTemplate,
+					BOM:      logbookFlags.BOM,
+				}); err != nil {
+					fmt.Fprintf(os.Stderr, "Error rendering HTML: %v\n", err)
+					os.Exit(1)
+				}
+
+			default:
+				if logbookFlags.TemplateFile != "" {
+					err = cli.PrintTemplateFileLogbookData(templateData, logbookFlags.TemplateFile)
+				} else {
+					// Use the template as-is - no automatic wrapping
+					err = cli.PrintTemplatedLogbookData(templateData, logbookFlags.Format)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error rendering template: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			if exitCode != cli.ExitOK {
+				os.Exit(exitCode)
 			}
 		},
 	}
 
 	// Set up flags
 	flagConfig.AddLogbookFlags(logbookCmd)
+	flagConfig.AddStrictFlags(logbookCmd)
 	flagConfig.AddCommonFlags(logbookCmd)
+	flagConfig.AddAnonymizeFlags(logbookCmd)
+	flagConfig.AddJSONFlags(logbookCmd)
 
 	return logbookCmd
 }
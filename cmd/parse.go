@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"strings"
 
+	"igc-tool/internal/cli"
 	"igc-tool/internal/config"
 	"igc-tool/internal/display"
 	"igc-tool/internal/flags"
+	flightpkg "igc-tool/internal/flight"
 	"igc-tool/internal/parser"
+	"igc-tool/internal/source"
 
 	"github.com/spf13/cobra"
 )
@@ -15,28 +20,125 @@ import (
 // NewParseCmd creates and returns the parse command
 func NewParseCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
 	var parseCmd = &cobra.Command{
-		Use:   "parse [IGC file]",
+		Use:   "parse [IGC files or URLs...]",
 		Short: "Parse and display detailed IGC flight data",
-		Long:  `Parse an IGC file and display all flight information including fixes, waypoints, and metadata.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Parse IGC files and display all flight information including fixes, waypoints, and metadata.
+With several files, each is printed under a "==> file <==" header. With --table, the
+fixes are listed in aligned columns, and --speeds adds the instantaneous ground speed
+and vertical speed since the previous fix.
+
+With --fields, only the listed fields are printed, on one tab-separated line per file,
+which makes the output easy to use in scripts; with several files the line starts with
+the file name. Available fields: ` + strings.Join(display.FlightFields(), ", ") + `.
+
+Malformed lines are skipped with a warning, and files that cannot be parsed are
+reported and skipped, exiting with code 2 once the others are printed (1 when none
+could be parsed). With --strict, the first malformed line or unparsable file fails the
+command.
+
+Files may also be http:// or https:// URLs, fetched with a 30 second timeout
+and a 10 MiB size limit.`,
+		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			filename := args[0]
 			parseFlags := flagConfig.GetParseFromFlags(cmd)
+			strictFlags := flagConfig.GetStrictFromFlags(cmd)
 			commonFlags := flagConfig.GetCommonFromConfig(cmd, cfg)
+			anonymizeFlags := flagConfig.GetAnonymizeFromFlags(cmd)
+
+			if err := flightpkg.ValidateAnonymizeLevel(anonymizeFlags.Level); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := display.ValidateFlightFields(parseFlags.Fields); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			multiple := len(args) > 1
+			failed := 0
+			var buf bytes.Buffer
+			for _, filename := range args {
+				result, err := parser.ParseIGCWithWarnings(source.ForRef(filename), filename, parser.Options{Logger: cfg.Logger})
+				if err != nil {
+					if !multiple {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						os.Exit(cli.ExitFailure)
+					}
+					fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", filename, err)
+					if strictFlags.Strict {
+						os.Exit(cli.ExitFailure)
+					}
+					failed++
+					continue
+				}
+				flight := result.Flight
+
+				for _, warning := range result.Warnings {
+					fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", filename, warning)
+				}
+				if strictFlags.Strict && result.MalformedLines > 0 {
+					fmt.Fprintf(os.Stderr, "Error: %s: malformed lines: %d\n", filename, result.MalformedLines)
+					os.Exit(cli.ExitFailure)
+				}
+
+				cli.WarnIfNotWGS84(flight, filename)
+				cli.WarnIfClockProblems(flight, filename)
+				flight = flight.Anonymize(anonymizeFlags.Level)
+
+				if len(parseFlags.Fields) > 0 {
+					if multiple {
+						fmt.Fprintf(&buf, "%s\t", filename)
+					}
+					if err := display.PrintFlightFields(&buf, flight, parseFlags.Fields); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						os.Exit(1)
+					}
+					continue
+				}
 
-			flight, err := parser.ParseIGCFile(filename)
-			if err != nil {
+				if multiple {
+					if buf.Len() > 0 {
+						buf.WriteString("\n")
+					}
+					fmt.Fprintf(&buf, "==> %s <==\n", filename)
+				}
+				if parseFlags.Table {
+					err = display.PrintFlightDataTable(&buf, flight, parseFlags.Summary, display.FixTableOptions{
+						AltitudeUnit: commonFlags.AltitudeUnit,
+						TimeFormat:   commonFlags.TimeFormat,
+						Speeds:       parseFlags.Speeds,
+					})
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						os.Exit(1)
+					}
+					continue
+				}
+				display.PrintFlightData(&buf, flight, parseFlags.Summary, commonFlags.AltitudeUnit, commonFlags.TimeFormat)
+			}
+
+			if failed == len(args) {
+				os.Exit(cli.ExitFailure)
+			}
+
+			if err := cli.WriteOutput(parseFlags.Output, "Flight data", buf.Bytes()); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 
-			display.PrintFlightData(flight, parseFlags.Summary, commonFlags.AltitudeUnit, commonFlags.TimeFormat)
+			if failed > 0 {
+				fmt.Fprintf(os.Stderr, "Failed to parse %d of %d files\n", failed, len(args))
+				os.Exit(cli.ExitPartialFailure)
+			}
 		},
 	}
 
 	// Set up flags
 	flagConfig.AddParseFlags(parseCmd)
+	flagConfig.AddStrictFlags(parseCmd)
 	flagConfig.AddCommonFlags(parseCmd)
+	flagConfig.AddAnonymizeFlags(parseCmd)
 
 	return parseCmd
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"igc-tool/internal/config"
 	"igc-tool/internal/flags"
@@ -16,6 +17,26 @@ func NewRootCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command
 		Use:   "igc-tool",
 		Short: "Parse and display IGC flight data",
 		Long:  `A tool to parse IGC (International Gliding Commission) flight files and display flight information including fixes, waypoints, and metadata.`,
+		// Reject unknown units, invalid speed windows and negative JSON indents before
+		// any command runs instead of silently falling back
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if flagConfig.GetGlobalFromFlags(cmd).Verbose {
+				cfg.Logger = config.NewLogger(os.Stderr, true)
+			}
+
+			if err := flagConfig.ValidateUnits(cmd); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := flagConfig.ValidateSpeedWindow(cmd); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := flagConfig.ValidateIndent(cmd); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			// Handle global version flag when no subcommand is provided
 			globalFlags := flagConfig.GetGlobalFromFlags(cmd)
@@ -35,6 +56,15 @@ func NewRootCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command
 	rootCmd.AddCommand(NewParseCmd(cfg, flagConfig))
 	rootCmd.AddCommand(NewLogbookCmd(cfg, flagConfig))
 	rootCmd.AddCommand(NewGeoJSONCmd(cfg, flagConfig))
+	rootCmd.AddCommand(NewCZMLCmd(cfg, flagConfig))
+	rootCmd.AddCommand(NewKMLCmd(cfg, flagConfig))
+	rootCmd.AddCommand(NewCSVCmd(cfg, flagConfig))
+	rootCmd.AddCommand(NewStatsCmd(cfg, flagConfig))
+	rootCmd.AddCommand(NewTaskCmd(cfg, flagConfig))
+	rootCmd.AddCommand(NewScoreCmd(cfg, flagConfig))
+	rootCmd.AddCommand(NewInspectCmd(cfg, flagConfig))
+	rootCmd.AddCommand(NewValidateCmd(cfg, flagConfig))
+	rootCmd.AddCommand(NewAirspaceCmd(cfg, flagConfig))
 	rootCmd.AddCommand(NewConfigCmd(cfg, flagConfig))
 	rootCmd.AddCommand(NewVersionCmd(cfg, flagConfig))
 
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"igc-tool/internal/cli"
+	"igc-tool/internal/config"
+	"igc-tool/internal/display"
+	"igc-tool/internal/flags"
+	"igc-tool/internal/parser"
+	"igc-tool/internal/scoring"
+	"igc-tool/internal/source"
+
+	"github.com/spf13/cobra"
+)
+
+// NewScoreCmd creates and returns the score command
+func NewScoreCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
+	var scoreCmd = &cobra.Command{
+		Use:   "score [IGC file or URL]",
+		Short: "Score the flight as an OLC free distance",
+		Long: fmt.Sprintf(`Find the start, turnpoints and finish along the track that give the longest
+distance, as scored for OLC free flights, and display them with the length of each
+leg. The points are fixes taken in track order; --turnpoints sets how many may be
+used between the start and the finish, %d by default and at most %d.
+
+With --triangles, the best closed triangles are scored too: three turnpoints in
+track order, closed when fixes before the first and after the last are within 20%%
+of the perimeter of each other, and scored as the perimeter less that gap. An FAI
+triangle also has no leg shorter than 28%% of the perimeter (25%% from 750 km, with
+no leg longer than 45%%); a flat triangle has no shape rule.
+
+Examples:
+  igc-tool score flight.igc
+  igc-tool score --turnpoints 5 --distance-unit mi flight.igc
+  igc-tool score --triangles flight.igc`, scoring.DefaultTurnpoints, scoring.MaxTurnpoints),
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			filename := args[0]
+			scoreFlags := flagConfig.GetScoreFromConfig(cmd, cfg)
+			commonFlags := flagConfig.GetCommonFromConfig(cmd, cfg)
+
+			if err := scoring.ValidateTurnpoints(scoreFlags.Turnpoints); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			flight, err := parser.ParseIGC(source.ForRef(filename), filename, parser.Options{Logger: cfg.Logger})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			cli.WarnIfNotWGS84(flight, filename)
+			cli.WarnIfClockProblems(flight, filename)
+
+			result := scoring.FreeDistance(flight, scoreFlags.Turnpoints)
+			if result == nil {
+				fmt.Fprintf(os.Stderr, "Error: %s has too few fixes to score\n", filename)
+				os.Exit(1)
+			}
+
+			display.PrintFreeDistance(os.Stdout, result, scoreFlags.DistanceUnit, commonFlags.TimeFormat, scoreFlags.CoordFormat, scoreFlags.CoordPrecision)
+
+			if scoreFlags.Triangles {
+				fmt.Println()
+				display.PrintTriangle(os.Stdout, "FAI Triangle", scoring.Triangle(flight, true),
+					scoreFlags.DistanceUnit, commonFlags.TimeFormat, scoreFlags.CoordFormat, scoreFlags.CoordPrecision)
+				fmt.Println()
+				display.PrintTriangle(os.Stdout, "Flat Triangle", scoring.Triangle(flight, false),
+					scoreFlags.DistanceUnit, commonFlags.TimeFormat, scoreFlags.CoordFormat, scoreFlags.CoordPrecision)
+			}
+		},
+	}
+
+	flagConfig.AddScoreFlags(scoreCmd)
+	flagConfig.AddCommonFlags(scoreCmd)
+
+	return scoreCmd
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"igc-tool/internal/cli"
+	"igc-tool/internal/config"
+	"igc-tool/internal/display"
+	"igc-tool/internal/elevation"
+	"igc-tool/internal/flags"
+	flightpkg "igc-tool/internal/flight"
+	"igc-tool/internal/parser"
+	"igc-tool/internal/source"
+	"igc-tool/internal/units"
+	"igc-tool/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// NewStatsCmd creates and returns the stats command
+func NewStatsCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
+	var statsCmd = &cobra.Command{
+		Use:   "stats [IGC file or URL]",
+		Short: "Show flight statistics",
+		Long: `Parse an IGC file and display a summary of its flight statistics, either as text or as a JSON object with stable keys.
+The text summary follows the --altitude-unit, --speed-unit, --climb-unit and --distance-unit flags;
+JSON distances are always in kilometers.
+
+With --alt-source baro, altitudes come from the pressure altitude instead of GPS.
+Give the day's QNH with --qnh to convert it to altitude above sea level; the
+correction is approximated as 8.23 m (27 ft) per hPa and applied to every fix.
+
+With --dem pointing at a directory of SRTM .hgt elevation tiles, the lowest save is
+reported as height above ground: the lowest point the flight climbed back at least
+50 m from. Without elevation data it is left out.
+
+The file may also be an http:// or https:// URL, fetched with a 30 second timeout
+and a 10 MiB size limit.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			filename := args[0]
+			statsFlags := flagConfig.GetStatsFromConfig(cmd, cfg)
+			commonFlags := flagConfig.GetCommonFromConfig(cmd, cfg)
+			anonymizeFlags := flagConfig.GetAnonymizeFromFlags(cmd)
+
+			if err := flightpkg.ValidateAnonymizeLevel(anonymizeFlags.Level); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := cli.CheckAltitudeSource(statsFlags.AltitudeSource, statsFlags.QNH); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			var elevationProvider flightpkg.ElevationProvider
+			if statsFlags.DEM != "" {
+				dem, err := elevation.NewHGTDirectory(statsFlags.DEM)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				elevationProvider = dem
+			}
+
+			flight, err := parser.ParseIGC(source.ForRef(filename), filename, parser.Options{Logger: cfg.Logger})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			cli.WarnIfNotWGS84(flight, filename)
+			cli.WarnIfClockProblems(flight, filename)
+			flight = flight.Anonymize(anonymizeFlags.Level)
+			if statsFlags.CollapseStalled {
+				flight = flight.CollapseStalled()
+			}
+			flight = flight.WithAltitudeSource(statsFlags.AltitudeSource, statsFlags.QNH)
+
+			insufficientData := len(flight.Fixes) < statsFlags.MinFixes
+			var buf bytes.Buffer
+			if insufficientData && !statsFlags.JSON {
+				display.PrintInsufficientData(&buf, flight, statsFlags.MinFixes)
+			} else {
+				start := time.Now()
+				stats := flight.GetStatistics(flightpkg.StatsOptions{
+					SpeedWindow:    statsFlags.SpeedWindow,
+					LevelThreshold: statsFlags.LevelThreshold,
+					ClimbNoise:     statsFlags.ClimbNoise,
+					SmoothWindow:   statsFlags.SmoothWindow,
+					Elevation:      elevationProvider,
+				})
+				cfg.Logger.Debug("computed statistics", "file", filename, "fixes", len(flight.Fixes), "elapsed", time.Since(start))
+
+				if statsFlags.JSON {
+					jsonFlags := flagConfig.GetJSONFromFlags(cmd, statsFlags.Output == "" && utils.IsTerminal(os.Stdout))
+					jsonData, err := renderStatsJSON(flight, stats, filename, insufficientData, statsFlags, commonFlags, jsonFlags.Indent)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error rendering JSON: %v\n", err)
+						os.Exit(1)
+					}
+					buf.Write(jsonData)
+					buf.WriteByte('\n')
+				} else {
+					display.PrintStatistics(&buf, flight, stats, commonFlags.AltitudeUnit, statsFlags.SpeedUnit, statsFlags.ClimbUnit, statsFlags.DistanceUnit, commonFlags.TimeFormat)
+				}
+			}
+
+			if err := cli.WriteOutput(statsFlags.Output, "Statistics", buf.Bytes()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	// Set up flags
+	flagConfig.AddStatsFlags(statsCmd)
+	flagConfig.AddJSONFlags(statsCmd)
+	flagConfig.AddCommonFlags(statsCmd)
+	flagConfig.AddAnonymizeFlags(statsCmd)
+
+	return statsCmd
+}
+
+// renderStatsJSON renders the statistics of a flight as a JSON object with stable keys.
+// Statistics are null rather than misleading values when there are too few fixes.
+func renderStatsJSON(f *flightpkg.Flight, stats *flightpkg.Statistics, filename string, insufficientData bool, statsFlags flags.StatsFlags, commonFlags flags.CommonFlags, indent string) ([]byte, error) {
+	var statistics map[string]interface{}
+	if !insufficientData {
+		statistics = stats.AsMap(commonFlags.AltitudeUnit, statsFlags.SpeedUnit, statsFlags.ClimbUnit)
+	}
+
+	output := map[string]interface{}{
+		"file":              filename,
+		"date":              f.Date.Format("2006-01-02"),
+		"pilot":             f.Pilot,
+		"fix_count":         len(f.Fixes),
+		"insufficient_data": insufficientData,
+		"statistics":        statistics,
+		"units": map[string]string{
+			"altitude": units.AltitudeSymbol(commonFlags.AltitudeUnit),
+			"height":   units.AltitudeSymbol(units.HeightUnit(commonFlags.AltitudeUnit)),
+			"speed":    units.SpeedSymbol(statsFlags.SpeedUnit),
+			"climb":    units.ClimbSymbol(statsFlags.ClimbUnit),
+			"duration": "s",
+			"turn":     "deg/s",
+		},
+	}
+
+	return utils.MarshalJSON(output, indent)
+}
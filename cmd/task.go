@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"igc-tool/internal/config"
+	"igc-tool/internal/display"
+	"igc-tool/internal/flags"
+	"igc-tool/internal/parser"
+	"igc-tool/internal/source"
+
+	"github.com/spf13/cobra"
+)
+
+// NewTaskCmd creates and returns the task command
+func NewTaskCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
+	var taskCmd = &cobra.Command{
+		Use:   "task [IGC file or URL]",
+		Short: "Show the declared task",
+		Long: `Display the task declared in the C records of an IGC file: takeoff, start,
+turnpoints, finish and landing, with the length of each leg and the task distance
+from the start through the turnpoints to the finish.
+
+The flight then completes the task by reaching the start, each turnpoint and the
+finish in order, a waypoint being reached by the first fix within --turnpoint-radius
+meters of it. An incomplete task is credited with the completed legs plus the
+progress toward the next waypoint.
+
+Examples:
+  igc-tool task flight.igc
+  igc-tool task --distance-unit nm --coord-format dms flight.igc`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			filename := args[0]
+			taskFlags := flagConfig.GetTaskFromConfig(cmd, cfg)
+			commonFlags := flagConfig.GetCommonFromConfig(cmd, cfg)
+
+			flight, err := parser.ParseIGC(source.ForRef(filename), filename, parser.Options{Logger: cfg.Logger})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if flight.Task == nil {
+				fmt.Printf("No task declared in %s\n", filename)
+				return
+			}
+
+			display.PrintTask(os.Stdout, flight.Task, taskFlags.DistanceUnit, commonFlags.TimeFormat, taskFlags.CoordFormat, taskFlags.CoordPrecision)
+
+			fmt.Println()
+			completion := flight.CalculateTaskCompletion(taskFlags.TurnpointRadius)
+			display.PrintTaskCompletion(os.Stdout, completion, taskFlags.DistanceUnit, commonFlags.TimeFormat)
+		},
+	}
+
+	flagConfig.AddTaskFlags(taskCmd)
+	flagConfig.AddCommonFlags(taskCmd)
+
+	return taskCmd
+}
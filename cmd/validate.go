@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"igc-tool/internal/cli"
+	"igc-tool/internal/config"
+	"igc-tool/internal/flags"
+	flightpkg "igc-tool/internal/flight"
+	"igc-tool/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+// NewValidateCmd creates and returns the validate command
+func NewValidateCmd(cfg *config.Config, flagConfig *flags.FlagConfig) *cobra.Command {
+	var validateCmd = &cobra.Command{
+		Use:   "validate [IGC files or directories...]",
+		Short: "Check IGC files for data-quality problems",
+		Long: `Check IGC files for problems and print one line per file: OK, WARN or FAIL.
+
+A file fails when it cannot be parsed or has no GPS fixes. Warnings are printed for
+a GPS datum other than WGS84 and for stalled fixes: consecutive fixes with identical
+latitude, longitude and GPS altitude, as repeated by some loggers while the GPS has
+lost lock. Stalled fixes can be dropped before statistics with --collapse-stalled
+in the stats and logbook commands.
+
+By default every file is checked and all problems are reported; the command then
+exits with a non-zero status if any file failed. With --fail-fast it stops at the
+first failure, which suits quick gate checks in CI.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			validateFlags := flagConfig.GetValidateFromFlags(cmd)
+
+			igcFiles, err := cli.FindIGCFiles(args, validateFlags.Recursive)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding IGC files: %v\n", err)
+				os.Exit(1)
+			}
+
+			if len(igcFiles) == 0 {
+				fmt.Fprintf(os.Stderr, "No IGC files found\n")
+				os.Exit(1)
+			}
+
+			failed := 0
+			for _, filename := range igcFiles {
+				flight, err := parser.ParseIGCFile(filename, parser.Options{Logger: cfg.Logger})
+				if err != nil {
+					fmt.Printf("FAIL  %s: %v\n", filename, err)
+					failed++
+					if validateFlags.FailFast {
+						fmt.Fprintf(os.Stderr, "Stopped at the first failure (--fail-fast)\n")
+						os.Exit(1)
+					}
+					continue
+				}
+
+				warnings := validationWarnings(flight)
+				if len(warnings) == 0 {
+					fmt.Printf("OK    %s (%d fixes)\n", filename, len(flight.Fixes))
+					continue
+				}
+				fmt.Printf("WARN  %s: %s\n", filename, strings.Join(warnings, "; "))
+			}
+
+			if failed > 0 {
+				fmt.Fprintf(os.Stderr, "%d of %d files failed validation\n", failed, len(igcFiles))
+				os.Exit(1)
+			}
+		},
+	}
+
+	// Set up flags
+	flagConfig.AddValidateFlags(validateCmd)
+
+	return validateCmd
+}
+
+// validationWarnings returns the data-quality problems of a flight that do not make it unusable
+func validationWarnings(flight *flightpkg.Flight) []string {
+	var warnings []string
+
+	if !flight.HasWGS84Datum() {
+		warnings = append(warnings, fmt.Sprintf("GPS datum %q is not WGS84", flight.GPSDatum))
+	}
+
+	if runs := flight.StalledRuns(); len(runs) > 0 {
+		var ranges []string
+		for _, run := range runs {
+			ranges = append(ranges, flight.Fixes[run.Start].Time.Format("15:04:05")+"-"+flight.Fixes[run.End].Time.Format("15:04:05"))
+		}
+		runWord := "runs"
+		if len(runs) == 1 {
+			runWord = "run"
+		}
+		warnings = append(warnings, fmt.Sprintf("%d stalled fixes of %d in %d %s (%s)",
+			flight.CountStalledFixes(), len(flight.Fixes), len(runs), runWord, strings.Join(ranges, ", ")))
+	}
+
+	warnings = append(warnings, flight.TimeWarnings()...)
+
+	return warnings
+}
@@ -0,0 +1,153 @@
+package airspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// Airspace is a volume of controlled or restricted airspace: a horizontal boundary
+// between a floor and a ceiling
+type Airspace struct {
+	Name     string
+	Polygons orb.MultiPolygon // boundary in [longitude, latitude] points
+	Floor    float64          // lower limit in meters above sea level
+	Ceiling  float64          // upper limit in meters above sea level, +Inf when unlimited
+}
+
+// Infringement is a continuous stretch of fixes inside an airspace
+type Infringement struct {
+	Airspace              string
+	Entry                 time.Time // time of the first fix inside the airspace
+	Exit                  time.Time // time of the last fix inside the airspace
+	Fixes                 int       // number of fixes inside the airspace
+	HorizontalPenetration float64   // deepest distance inside the boundary in meters
+	VerticalPenetration   float64   // deepest distance inside the floor or ceiling in meters
+}
+
+// feature is the subset of a GeoJSON feature read from airspace files
+type feature struct {
+	Geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		Name    string   `json:"name"`
+		Floor   *float64 `json:"floor"`
+		Ceiling *float64 `json:"ceiling"`
+	} `json:"properties"`
+}
+
+// LoadAirspaces loads airspaces from a GeoJSON FeatureCollection of Polygon or
+// MultiPolygon features. Each feature needs a "name" property and may have "floor"
+// and "ceiling" properties in meters above sea level; a missing floor is the ground
+// (0) and a missing ceiling is unlimited. Features with other geometries are skipped.
+func LoadAirspaces(filename string) ([]Airspace, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read airspace file %s: %w", filename, err)
+	}
+
+	var collection struct {
+		Features []feature `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+
+	var airspaces []Airspace
+	for i, f := range collection.Features {
+		var polygons orb.MultiPolygon
+		switch f.Geometry.Type {
+		case "Polygon":
+			var polygon orb.Polygon
+			err = json.Unmarshal(f.Geometry.Coordinates, &polygon)
+			polygons = orb.MultiPolygon{polygon}
+		case "MultiPolygon":
+			err = json.Unmarshal(f.Geometry.Coordinates, &polygons)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: invalid coordinates: %w", i, err)
+		}
+
+		airspace := Airspace{Name: f.Properties.Name, Polygons: polygons, Ceiling: math.Inf(1)}
+		if airspace.Name == "" {
+			airspace.Name = fmt.Sprintf("airspace %d", i+1)
+		}
+		if f.Properties.Floor != nil {
+			airspace.Floor = *f.Properties.Floor
+		}
+		if f.Properties.Ceiling != nil {
+			airspace.Ceiling = *f.Properties.Ceiling
+		}
+		if airspace.Ceiling <= airspace.Floor {
+			return nil, fmt.Errorf("airspace %s: ceiling %.0f m is not above floor %.0f m", airspace.Name, airspace.Ceiling, airspace.Floor)
+		}
+		airspaces = append(airspaces, airspace)
+	}
+
+	return airspaces, nil
+}
+
+// Contains reports whether a point at the given altitude in meters is inside the airspace
+func (a Airspace) Contains(lat, lon, altitude float64) bool {
+	return altitude >= a.Floor && altitude <= a.Ceiling && planar.MultiPolygonContains(a.Polygons, orb.Point{lon, lat})
+}
+
+// CheckFlight returns the infringements of the flight's fixes into the airspaces,
+// ordered by entry time. Altitudes are the fixes' GPS altitudes.
+func CheckFlight(f *flight.Flight, airspaces []Airspace) []Infringement {
+	var infringements []Infringement
+
+	for _, airspace := range airspaces {
+		var current *Infringement
+		for _, fix := range f.Fixes {
+			lat, lon, altitude := fix.Lat, fix.Lon, fix.AltWGS84
+			if !airspace.Contains(lat, lon, altitude) {
+				if current != nil {
+					infringements = append(infringements, *current)
+					current = nil
+				}
+				continue
+			}
+
+			if current == nil {
+				current = &Infringement{Airspace: airspace.Name, Entry: fix.Time}
+			}
+			current.Exit = fix.Time
+			current.Fixes++
+			current.HorizontalPenetration = math.Max(current.HorizontalPenetration, airspace.boundaryDistance(lat, lon))
+			current.VerticalPenetration = math.Max(current.VerticalPenetration, math.Min(altitude-airspace.Floor, airspace.Ceiling-altitude))
+		}
+		if current != nil {
+			infringements = append(infringements, *current)
+		}
+	}
+
+	sort.SliceStable(infringements, func(i, j int) bool {
+		return infringements[i].Entry.Before(infringements[j].Entry)
+	})
+	return infringements
+}
+
+// boundaryDistance returns the distance in meters from a point to the nearest edge of
+// the airspace boundary
+func (a Airspace) boundaryDistance(lat, lon float64) float64 {
+	nearest := math.Inf(1)
+	for _, polygon := range a.Polygons {
+		for _, ring := range polygon {
+			nearest = math.Min(nearest, flight.RingDistance(lat, lon, ring))
+		}
+	}
+	return nearest
+}
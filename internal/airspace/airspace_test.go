@@ -0,0 +1,125 @@
+package airspace
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+const testAirspaces = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "geometry": {"type": "Polygon", "coordinates": [[[6.0, 45.0], [6.1, 45.0], [6.1, 45.1], [6.0, 45.1], [6.0, 45.0]]]},
+      "properties": {"name": "CTR", "floor": 0, "ceiling": 1500}
+    },
+    {
+      "type": "Feature",
+      "geometry": {"type": "MultiPolygon", "coordinates": [[[[7.0, 45.0], [7.1, 45.0], [7.1, 45.1], [7.0, 45.0]]]]},
+      "properties": {"name": "TMA", "floor": 2000}
+    },
+    {
+      "type": "Feature",
+      "geometry": {"type": "Point", "coordinates": [6.0, 45.0]},
+      "properties": {"name": "Reporting point"}
+    }
+  ]
+}`
+
+func writeTestFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "airspace.geojson")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	return path
+}
+
+func TestLoadAirspaces(t *testing.T) {
+	airspaces, err := LoadAirspaces(writeTestFile(t, testAirspaces))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(airspaces) != 2 {
+		t.Fatalf("expected 2 airspaces, got %d", len(airspaces))
+	}
+	if airspaces[0].Name != "CTR" || airspaces[0].Floor != 0 || airspaces[0].Ceiling != 1500 {
+		t.Errorf("unexpected CTR: %+v", airspaces[0])
+	}
+	if airspaces[1].Floor != 2000 || !math.IsInf(airspaces[1].Ceiling, 1) {
+		t.Errorf("expected TMA from 2000 m to unlimited, got %v-%v", airspaces[1].Floor, airspaces[1].Ceiling)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"invalid JSON", "{"},
+		{"ceiling below floor", `{"features": [{"geometry": {"type": "Polygon", "coordinates": [[[6, 45], [7, 45], [7, 46], [6, 45]]]}, "properties": {"floor": 1000, "ceiling": 500}}]}`},
+		{"invalid coordinates", `{"features": [{"geometry": {"type": "Polygon", "coordinates": "here"}, "properties": {}}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadAirspaces(writeTestFile(t, tt.content)); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+
+	if _, err := LoadAirspaces(filepath.Join(t.TempDir(), "missing.geojson")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestCheckFlight(t *testing.T) {
+	airspaces, err := LoadAirspaces(writeTestFile(t, testAirspaces))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+	fix := func(seconds int, lat, lon, alt float64) *igc.BRecord {
+		return &igc.BRecord{Time: start.Add(time.Duration(seconds) * time.Second), Lat: lat, Lon: lon, AltWGS84: alt}
+	}
+	f := &flight.Flight{Fixes: []*igc.BRecord{
+		fix(0, 44.95, 6.05, 1000),  // south of the CTR
+		fix(10, 45.01, 6.05, 1000), // inside the CTR, 0.01° from its southern edge
+		fix(20, 45.05, 6.05, 1200), // inside the CTR, in its middle
+		fix(30, 45.05, 6.05, 1600), // above the CTR ceiling
+		fix(40, 45.05, 6.05, 1400), // back inside the CTR
+		fix(50, 45.20, 6.05, 1400), // north of the CTR
+	}}
+
+	infringements := CheckFlight(f, airspaces)
+	if len(infringements) != 2 {
+		t.Fatalf("expected 2 infringements, got %d: %+v", len(infringements), infringements)
+	}
+
+	first := infringements[0]
+	if first.Airspace != "CTR" || !first.Entry.Equal(start.Add(10*time.Second)) || !first.Exit.Equal(start.Add(20*time.Second)) || first.Fixes != 2 {
+		t.Errorf("unexpected first infringement: %+v", first)
+	}
+	// The middle of the CTR is 0.05° of longitude (about 3.9 km at 45°N) from its east and west edges
+	if math.Abs(first.HorizontalPenetration-3900) > 100 {
+		t.Errorf("expected horizontal penetration of about 3900 m, got %.0f", first.HorizontalPenetration)
+	}
+	if first.VerticalPenetration != 500 {
+		t.Errorf("expected vertical penetration of 500 m, got %.0f", first.VerticalPenetration)
+	}
+
+	second := infringements[1]
+	if !second.Entry.Equal(start.Add(40*time.Second)) || second.Fixes != 1 || second.VerticalPenetration != 100 {
+		t.Errorf("unexpected second infringement: %+v", second)
+	}
+
+	if got := CheckFlight(&flight.Flight{}, airspaces); len(got) != 0 {
+		t.Errorf("expected no infringements without fixes, got %+v", got)
+	}
+}
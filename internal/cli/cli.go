@@ -2,83 +2,275 @@ package cli
 
 import (
 	"fmt"
-	"io/fs"
+	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	"runtime"
+	"sync"
 	"text/template"
+	"unicode/utf8"
 
+	"igc-tool/internal/flight"
 	"igc-tool/internal/logbook"
 	"igc-tool/internal/sites"
+	"igc-tool/internal/source"
+)
+
+// Exit codes of the commands, so scripts and CI can tell a degraded run or a failed
+// check from a clean one
+const (
+	ExitOK             = 0 // every file was processed
+	ExitFailure        = 1 // nothing was processed, or the command failed
+	ExitPartialFailure = 2 // output was produced, but some files could not be read
+	ExitInfringement   = 3 // the flight was checked and infringes an airspace
 )
 
 // FindIGCFiles finds all IGC files from the given paths (files or directories)
 // If recursive is true, it will search subdirectories as well
 func FindIGCFiles(paths []string, recursive bool) ([]string, error) {
+	return FindIGCFilesFrom(source.FileSystem{}, paths, recursive)
+}
+
+// FindIGCFilesFrom finds all IGC files referenced by paths in the given source
+func FindIGCFilesFrom(src source.Source, paths []string, recursive bool) ([]string, error) {
 	var igcFiles []string
 
 	for _, path := range paths {
-		stat, err := os.Stat(path)
+		files, err := src.List(path, recursive)
 		if err != nil {
-			return nil, fmt.Errorf("error accessing %s: %w", path, err)
+			return nil, err
 		}
+		igcFiles = append(igcFiles, files...)
+	}
 
-		if stat.IsDir() {
-			// Handle directory
-			if recursive {
-				err = filepath.WalkDir(path, func(filePath string, d fs.DirEntry, err error) error {
-					if err != nil {
-						return err
-					}
-					if !d.IsDir() && strings.ToLower(filepath.Ext(filePath)) == ".igc" {
-						igcFiles = append(igcFiles, filePath)
-					}
-					return nil
-				})
-			} else {
-				entries, err := os.ReadDir(path)
-				if err != nil {
-					return nil, fmt.Errorf("error reading directory %s: %w", path, err)
-				}
-				for _, entry := range entries {
-					if !entry.IsDir() && strings.ToLower(filepath.Ext(entry.Name())) == ".igc" {
-						igcFiles = append(igcFiles, filepath.Join(path, entry.Name()))
-					}
-				}
-			}
-			if err != nil {
-				return nil, fmt.Errorf("error walking directory %s: %w", path, err)
-			}
-		} else {
-			// Handle regular file
-			if strings.ToLower(filepath.Ext(path)) == ".igc" {
-				igcFiles = append(igcFiles, path)
-			} else {
-				return nil, fmt.Errorf("file %s is not an IGC file", path)
+	return igcFiles, nil
+}
+
+// ProcessFiles calls process for every file on up to jobs goroutines, or one per CPU
+// when jobs is 0 or less, and returns the results in the order of files so output does
+// not depend on scheduling. process must be safe for concurrent use.
+func ProcessFiles[T any](files []string, jobs int, process func(filename string) T) []T {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > len(files) {
+		jobs = len(files)
+	}
+
+	results := make([]T, len(files))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = process(files[i])
 			}
-		}
+		}()
 	}
 
-	return igcFiles, nil
+	for i := range files {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return results
+}
+
+// Progress reports a "parsed N/total" counter on a single line, rewritten in place as
+// files are processed. A nil *Progress is valid and prints nothing.
+type Progress struct {
+	mu    sync.Mutex
+	w     io.Writer
+	done  int
+	total int
+}
+
+// NewProgress returns a counter for total files written to w, usually os.Stderr so the
+// counter does not mix with the command output
+func NewProgress(w io.Writer, total int) *Progress {
+	return &Progress{w: w, total: total}
+}
+
+// Increment counts one more processed file and redraws the counter; it is safe for
+// concurrent use
+func (p *Progress) Increment() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	fmt.Fprintf(p.w, "\rparsed %d/%d", p.done, p.total)
+}
+
+// Finish ends the counter line so following messages start on a new line
+func (p *Progress) Finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done > 0 {
+		fmt.Fprintln(p.w)
+	}
 }
 
 // PrintTemplatedLogbookData prints logbook output using the provided template with TemplateData
 func PrintTemplatedLogbookData(data *logbook.TemplateData, templateStr string) error {
+	return printTemplate(data, "logbook", templateStr)
+}
+
+// PrintTemplateFileLogbookData prints logbook output using the template read from a
+// file. Parse errors name the file and line.
+func PrintTemplateFileLogbookData(data *logbook.TemplateData, path string) error {
+	templateStr, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read template file: %w", err)
+	}
+	return printTemplate(data, path, string(templateStr))
+}
+
+// printTemplate parses templateStr under the given name, which prefixes parse and
+// execution errors, and executes it with TemplateData on stdout. The functions of
+// logbook.TemplateFuncs are available to the template. Unknown fields and missing
+// map keys (e.g. a pilot absent from ByPilot) are errors.
+func printTemplate(data *logbook.TemplateData, name, templateStr string) error {
 	if data == nil {
 		fmt.Println("No flight data available for logbook entry")
 		return nil
 	}
 
-	tmpl, err := template.New("logbook").Parse(templateStr)
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(logbook.TemplateFuncs).Parse(templateStr)
 	if err != nil {
 		return fmt.Errorf("failed to parse template: %w", err)
 	}
 
 	err = tmpl.Execute(os.Stdout, data)
 	if err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+		return fmt.Errorf("failed to execute template: %w", logbook.DescribeTemplateError(err))
+	}
+
+	return nil
+}
+
+// PrintCSVLogbookData prints the individual flights of the logbook as CSV
+func PrintCSVLogbookData(data *logbook.TemplateData, opts logbook.CSVOptions) error {
+	if data == nil {
+		fmt.Println("No flight data available for logbook entry")
+		return nil
+	}
+
+	if err := logbook.WriteCSV(os.Stdout, data.Flights, opts); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	return nil
+}
+
+// ParseDelimiter converts a --delimiter value into a CSV field separator.
+// Besides single characters it accepts "tab" and `\t` for tab-separated output.
+func ParseDelimiter(value string) (rune, error) {
+	if value == "tab" || value == "\\t" {
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(value)
+	if r == utf8.RuneError || size != len(value) || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("invalid delimiter %q: must be a single character other than a quote or newline", value)
+	}
+
+	return r, nil
+}
+
+// WriteOutput writes a command's output to the file at path, atomically, and reports
+// it on stderr as "<kind> written to <path>". With an empty path, data is written to
+// stdout.
+func WriteOutput(path, kind string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "%s written to %s\n", kind, path)
+	return nil
+}
+
+// WriteFileAtomic writes data to a temporary file in the same directory as path and
+// renames it into place on success, so an interrupted run never leaves a truncated file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmpFile, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
 	}
+	tmpName := tmpFile.Name()
+
+	// Remove the temporary file unless it was successfully renamed
+	renamed := false
+	defer func() {
+		if !renamed {
+			os.Remove(tmpName)
+		}
+	}()
 
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+	renamed = true
+
+	return nil
+}
+
+// WarnIfNotWGS84 prints a warning to stderr when the flight declares a datum other than WGS84
+func WarnIfNotWGS84(f *flight.Flight, filename string) {
+	if !f.HasWGS84Datum() {
+		fmt.Fprintf(os.Stderr, "Warning: %s declares GPS datum %q; coordinates are assumed to be WGS84 and may be offset on maps\n", filename, f.GPSDatum)
+	}
+}
+
+// WarnIfClockProblems prints a warning to stderr for each logger clock problem of the flight
+func WarnIfClockProblems(f *flight.Flight, filename string) {
+	for _, warning := range f.TimeWarnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", filename, warning)
+	}
+}
+
+// CheckAltitudeSource validates the altitude source and QNH options, and warns when a
+// QNH is given that will not be applied because the altitude source is GPS
+func CheckAltitudeSource(source string, qnh float64) error {
+	if err := flight.ValidateAltitudeSource(source); err != nil {
+		return err
+	}
+	if qnh != 0 && (qnh < 850 || qnh > 1100) {
+		return fmt.Errorf("invalid QNH %g hPa: expected a value between 850 and 1100", qnh)
+	}
+	if qnh != 0 && source != flight.AltSourceBaro {
+		fmt.Fprintf(os.Stderr, "Warning: --qnh only applies with --alt-source %s and is ignored\n", flight.AltSourceBaro)
+	}
 	return nil
 }
 
@@ -1,9 +1,14 @@
 package cli
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"text/template"
 
@@ -122,6 +127,41 @@ func TestFindIGCFiles(t *testing.T) {
 	}
 }
 
+// fakeSource is an in-memory source.Source listing a fixed set of files per ref
+type fakeSource map[string][]string
+
+func (s fakeSource) Open(ref string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (s fakeSource) List(ref string, recursive bool) ([]string, error) {
+	files, ok := s[ref]
+	if !ok {
+		return nil, fmt.Errorf("error accessing %s", ref)
+	}
+	return files, nil
+}
+
+func TestFindIGCFilesFrom(t *testing.T) {
+	src := fakeSource{
+		"a": {"a/1.igc", "a/2.igc"},
+		"b": {"b/3.igc"},
+	}
+
+	files, err := FindIGCFilesFrom(src, []string{"a", "b"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"a/1.igc", "a/2.igc", "b/3.igc"}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+
+	if _, err := FindIGCFilesFrom(src, []string{"a", "missing"}, false); err == nil {
+		t.Errorf("expected error for unknown ref")
+	}
+}
+
 func TestLoadLandingSitesIfSpecified(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -369,6 +409,184 @@ func TestPrintTemplatedLogbookData(t *testing.T) {
 	}
 }
 
+func TestPrintTemplateFileLogbookData(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := &logbook.TemplateData{TotalFlights: 3}
+
+	valid := filepath.Join(tmpDir, "summary.tmpl")
+	if err := os.WriteFile(valid, []byte("Flights:\n{{.TotalFlights}}\n"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	invalid := filepath.Join(tmpDir, "broken.tmpl")
+	if err := os.WriteFile(invalid, []byte("Flights:\n{{.TotalFlights\n"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := PrintTemplateFileLogbookData(data, valid)
+	w.Close()
+	os.Stdout = oldStdout
+
+	output, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != "Flights:\n3\n" {
+		t.Errorf("expected %q, got %q", "Flights:\n3\n", string(output))
+	}
+
+	// Parse errors point at the file and line
+	err = PrintTemplateFileLogbookData(data, invalid)
+	if err == nil || !strings.Contains(err.Error(), invalid+":2") {
+		t.Errorf("expected error naming %s:2, got %v", invalid, err)
+	}
+
+	if err := PrintTemplateFileLogbookData(data, filepath.Join(tmpDir, "missing.tmpl")); err == nil {
+		t.Error("expected error for missing template file")
+	}
+}
+
+func TestProcessFiles(t *testing.T) {
+	files := []string{"a.igc", "b.igc", "c.igc", "d.igc", "e.igc"}
+
+	for _, jobs := range []int{0, 1, 3, 10} {
+		t.Run(fmt.Sprintf("jobs=%d", jobs), func(t *testing.T) {
+			var calls atomic.Int32
+			results := ProcessFiles(files, jobs, func(filename string) string {
+				calls.Add(1)
+				return strings.ToUpper(filename)
+			})
+
+			expected := []string{"A.IGC", "B.IGC", "C.IGC", "D.IGC", "E.IGC"}
+			if !reflect.DeepEqual(results, expected) {
+				t.Errorf("expected %v, got %v", expected, results)
+			}
+			if calls.Load() != int32(len(files)) {
+				t.Errorf("expected %d calls, got %d", len(files), calls.Load())
+			}
+		})
+	}
+
+	if results := ProcessFiles(nil, 4, func(string) int { return 1 }); len(results) != 0 {
+		t.Errorf("expected no results for no files, got %v", results)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	var buf bytes.Buffer
+	progress := NewProgress(&buf, 3)
+	ProcessFiles([]string{"a.igc", "b.igc", "c.igc"}, 2, func(string) bool {
+		progress.Increment()
+		return true
+	})
+	progress.Finish()
+
+	expected := "\rparsed 1/3\rparsed 2/3\rparsed 3/3\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	// A disabled progress prints nothing
+	var disabled *Progress
+	disabled.Increment()
+	disabled.Finish()
+}
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    rune
+		expectError bool
+	}{
+		{name: "comma", value: ",", expected: ','},
+		{name: "semicolon", value: ";", expected: ';'},
+		{name: "tab keyword", value: "tab", expected: '\t'},
+		{name: "escaped tab", value: `\t`, expected: '\t'},
+		{name: "empty", value: "", expectError: true},
+		{name: "multiple characters", value: ";;", expectError: true},
+		{name: "quote", value: `"`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseDelimiter(tt.value)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "track.geojson")
+
+	if err := os.WriteFile(path, []byte("old content"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	if err := WriteFileAtomic(path, []byte("new content"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(content) != "new content" {
+		t.Errorf("expected %q, got %q", "new content", string(content))
+	}
+
+	// No temporary files should be left behind
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the output file, got %d entries", len(entries))
+	}
+
+	// Writing into a missing directory fails without creating the target
+	missing := filepath.Join(tmpDir, "missing", "track.geojson")
+	if err := WriteFileAtomic(missing, []byte("data"), 0644); err == nil {
+		t.Errorf("expected error for missing directory")
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.txt")
+
+	if err := WriteOutput(path, "Statistics", []byte("Max Altitude: 1980m\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(content) != "Max Altitude: 1980m\n" {
+		t.Errorf("expected output in file, got %q", string(content))
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing", "stats.txt")
+	if err := WriteOutput(missing, "Statistics", []byte("data")); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("expected error naming %s, got %v", missing, err)
+	}
+}
+
 // Helper function to test template execution without capturing output
 func testTemplateExecution(data *logbook.Data, templateStr string) error {
 	if data == nil {
@@ -384,3 +602,30 @@ func testTemplateExecution(data *logbook.Data, templateStr string) error {
 	var builder strings.Builder
 	return tmpl.Execute(&builder, data)
 }
+
+func TestCheckAltitudeSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		qnh         float64
+		expectError bool
+	}{
+		{name: "gps", source: "gps", qnh: 0, expectError: false},
+		{name: "baro with qnh", source: "baro", qnh: 1020, expectError: false},
+		{name: "gps with ignored qnh", source: "gps", qnh: 1020, expectError: false},
+		{name: "unknown source", source: "radar", qnh: 0, expectError: true},
+		{name: "implausible qnh", source: "baro", qnh: 29.92, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckAltitudeSource(tt.source, tt.qnh)
+			if tt.expectError && err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
@@ -1,42 +1,95 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"igc-tool/internal/flight"
 	"igc-tool/internal/units"
+	"igc-tool/internal/utils"
 
+	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/viper"
 )
 
 // Config holds the application configuration
 type Config struct {
 	// General settings
-	AltitudeUnit string `mapstructure:"altitude-unit"`
-	TimeFormat   string `mapstructure:"time-format"`
-	SpeedUnit    string `mapstructure:"speed-unit"`
-	ClimbUnit    string `mapstructure:"climb-unit"`
+	AltitudeUnit string `mapstructure:"altitude-unit" toml:"altitude-unit" json:"altitude-unit"`
+	TimeFormat   string `mapstructure:"time-format" toml:"time-format" json:"time-format"`
+	SpeedUnit    string `mapstructure:"speed-unit" toml:"speed-unit" json:"speed-unit"`
+	ClimbUnit    string `mapstructure:"climb-unit" toml:"climb-unit" json:"climb-unit"`
+	DistanceUnit string `mapstructure:"distance-unit" toml:"distance-unit" json:"distance-unit"`
+
+	// Coordinate display settings
+	CoordFormat    string `mapstructure:"coord-format" toml:"coord-format" json:"coord-format"`
+	CoordPrecision int    `mapstructure:"coord-precision" toml:"coord-precision" json:"coord-precision"`
 
 	// Logbook command settings
-	LogbookFormat             string  `mapstructure:"logbook-format"`
-	SitesDatabaseFileLocation string  `mapstructure:"sites-database-location"`
-	SpeedWindow               float64 `mapstructure:"speed-window"`
+	LogbookFormat             string  `mapstructure:"logbook-format" toml:"logbook-format" json:"logbook-format"`
+	SitesDatabaseFileLocation string  `mapstructure:"sites-database-location" toml:"sites-database-location" json:"sites-database-location"`
+	SpeedWindow               float64 `mapstructure:"speed-window" toml:"speed-window" json:"speed-window"`
+	LevelThreshold            float64 `mapstructure:"level-threshold" toml:"level-threshold" json:"level-threshold"`
+	MinFixes                  int     `mapstructure:"min-fixes" toml:"min-fixes" json:"min-fixes"`
+	ClimbNoise                float64 `mapstructure:"climb-noise" toml:"climb-noise" json:"climb-noise"`
+	SmoothWindow              float64 `mapstructure:"smooth" toml:"smooth" json:"smooth"`
+	AltitudeSource            string  `mapstructure:"alt-source" toml:"alt-source" json:"alt-source"`
+	DEMDirectory              string  `mapstructure:"dem" toml:"dem" json:"dem"`
+
+	// Task settings
+	TurnpointRadius float64 `mapstructure:"turnpoint-radius" toml:"turnpoint-radius" json:"turnpoint-radius"`
 
 	// Internal fields (not loaded from config file)
-	ConfigFile string `mapstructure:"-"`
+	ConfigFile string       `mapstructure:"-" toml:"-" json:"-"`
+	Logger     *slog.Logger `mapstructure:"-" toml:"-" json:"-"` // debug messages, silent unless --verbose
+}
+
+// Output formats of Marshal
+const (
+	OutputTOML = "toml"
+	OutputJSON = "json"
+)
+
+// Marshal encodes the configuration as TOML, in the config file syntax, or as JSON
+// indented with indent, compact when it is empty. ConfigFile is not included.
+func (c *Config) Marshal(format, indent string) ([]byte, error) {
+	switch format {
+	case OutputTOML:
+		return toml.Marshal(c)
+	case OutputJSON:
+		data, err := utils.MarshalJSON(c, indent)
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	default:
+		return nil, fmt.Errorf("invalid output format %q: must be %s or %s", format, OutputTOML, OutputJSON)
+	}
 }
 
-// Load initializes and returns the application configuration
-func Load() *Config {
-	viper.SetConfigName("igc-tool")
+// Load initializes and returns the application configuration, read from configFile when
+// it is set (as by --config) or else from the first igc-tool.toml in the search paths.
+// A missing or unreadable explicit config file is fatal.
+func Load(configFile string) *Config {
 	viper.SetConfigType("toml")
 
-	// Look for config in various locations
-	viper.AddConfigPath(".") // Current directory
-	viper.AddConfigPath("$HOME/.config/igc-tool")
-	viper.AddConfigPath("$HOME")
-	viper.AddConfigPath("/etc/igc-tool")
+	if configFile != "" {
+		if _, err := os.Stat(configFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: config file %s: %v\n", configFile, errors.Unwrap(err))
+			os.Exit(1)
+		}
+		viper.SetConfigFile(configFile)
+	} else {
+		viper.SetConfigName("igc-tool")
+		cwd, _ := os.Getwd()
+		home, _ := os.UserHomeDir()
+		addConfigPaths(viper.GetViper(), cwd, home)
+	}
 
 	// Set environment variable prefix
 	viper.SetEnvPrefix("IGC")
@@ -46,10 +99,14 @@ func Load() *Config {
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 
 	// Set defaults
-	setDefaults()
+	setDefaults(viper.GetViper())
 
 	// Try to read config file
 	if err := viper.ReadInConfig(); err != nil {
+		if configFile != "" {
+			fmt.Fprintf(os.Stderr, "Error: failed to read config file %s: %v\n", configFile, err)
+			os.Exit(1)
+		}
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			fmt.Fprintf(os.Stderr, "Error reading config file: %v\n", err)
 		}
@@ -64,18 +121,89 @@ func Load() *Config {
 
 	// Set the config file path that was actually used
 	cfg.ConfigFile = viper.ConfigFileUsed()
+	cfg.Logger = NewLogger(os.Stderr, false)
 
 	return cfg
 }
 
+// NewLogger returns the logger for debug messages: written to w as key=value lines when
+// verbose, and discarded otherwise
+func NewLogger(w io.Writer, verbose bool) *slog.Logger {
+	if !verbose {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// FileFromArgs returns the value of the --config flag in the command-line arguments, or ""
+// when it is not given. The config is loaded before the commands are built, since flag
+// defaults come from it, so the flag is looked up ahead of cobra's parsing.
+func FileFromArgs(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return ""
+		case arg == "--config" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "--config="):
+			return strings.TrimPrefix(arg, "--config=")
+		}
+	}
+	return ""
+}
+
+// addConfigPaths adds the directories searched for igc-tool.toml to v, in order of
+// precedence: the working directory cwd, its parents below home, so a config at the root
+// of a flights archive applies in all its subfolders, and then the user and system
+// locations. The walk stops before home and the filesystem root so that it cannot shadow
+// ~/.config/igc-tool. An empty cwd or home is not known.
+func addConfigPaths(v *viper.Viper, cwd, home string) {
+	v.AddConfigPath(".")
+	if cwd != "" {
+		if home != "" {
+			home = filepath.Clean(home)
+		}
+		for _, dir := range parentDirs(cwd, home) {
+			v.AddConfigPath(dir)
+		}
+	}
+	v.AddConfigPath("$HOME/.config/igc-tool")
+	v.AddConfigPath("$HOME")
+	v.AddConfigPath("/etc/igc-tool")
+}
+
+// parentDirs returns the ancestors of dir from its parent up to, but excluding, stop
+// and the filesystem root
+func parentDirs(dir, stop string) []string {
+	var dirs []string
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir || parent == stop || filepath.Dir(parent) == parent {
+			return dirs
+		}
+		dirs = append(dirs, parent)
+		dir = parent
+	}
+}
+
 // setDefaults sets default configuration values
-func setDefaults() {
-	viper.SetDefault("altitude-unit", units.AltitudeMeters)
-	viper.SetDefault("time-format", units.TimeFormat24h)
-	viper.SetDefault("speed-unit", units.SpeedKmh)
-	viper.SetDefault("climb-unit", units.ClimbMs)
-	defaultTemplate := "{{range .Flights}}{{.Date}} {{.TakeoffSite}} {{.TakeoffAlt}}{{.AltitudeUnit}} {{.AltitudeDiff}}{{.AltitudeUnit}} {{.FlightDuration}} {{.MaxAltitude}}{{.AltitudeUnit}} {{.MaxGroundSpeed}}{{.SpeedUnit}} +{{.MaxClimbRate}}{{.VerticalSpeedUnit}} -{{.MaxDescentRate}}{{.VerticalSpeedUnit}}\n{{end}}{{if gt .TotalFlights 1}}# total flight time: {{.TotalTime}}\n{{end}}"
-	viper.SetDefault("logbook-format", defaultTemplate)
-	viper.SetDefault("sites-database-location", "")
-	viper.SetDefault("speed-window", 5.0)
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("altitude-unit", units.AltitudeMeters)
+	v.SetDefault("time-format", units.TimeFormat24h)
+	v.SetDefault("speed-unit", units.SpeedKmh)
+	v.SetDefault("climb-unit", units.ClimbMs)
+	v.SetDefault("distance-unit", units.DistanceKm)
+	v.SetDefault("coord-format", units.CoordFormatDecimal)
+	v.SetDefault("coord-precision", 3)
+	defaultTemplate := "{{range .Flights}}{{.Date}} {{.TakeoffSite}} {{.TakeoffAlt | altitude .AltitudeUnit}} {{.AltitudeDiff}}{{.HeightUnit}} {{.FlightDuration}} {{if .InsufficientData}}(insufficient data){{else}}{{.MaxAltitude | altitude .AltitudeUnit}} {{.MaxGroundSpeed}}{{.SpeedUnit}} +{{.MaxClimbRate}}{{.VerticalSpeedUnit}} -{{.MaxDescentRate}}{{.VerticalSpeedUnit}}{{end}}\n{{end}}{{if gt .TotalFlights 1}}# total flight time: {{.TotalTime}}\n{{end}}"
+	v.SetDefault("logbook-format", defaultTemplate)
+	v.SetDefault("sites-database-location", "")
+	v.SetDefault("speed-window", 5.0)
+	v.SetDefault("level-threshold", 0.5)
+	v.SetDefault("min-fixes", 10)
+	v.SetDefault("climb-noise", 3.0)
+	v.SetDefault("smooth", 0.0)
+	v.SetDefault("alt-source", flight.AltSourceGPS)
+	v.SetDefault("turnpoint-radius", flight.DefaultTurnpointRadius)
+	v.SetDefault("dem", "")
 }
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestParentDirs(t *testing.T) {
+	tests := []struct {
+		name     string
+		dir      string
+		stop     string
+		expected []string
+	}{
+		{"nested", filepath.FromSlash("/flights/2024/alps"), "", []string{filepath.FromSlash("/flights/2024"), filepath.FromSlash("/flights")}},
+		{"top level", filepath.FromSlash("/flights"), "", nil},
+		{"root", filepath.FromSlash("/"), "", nil},
+		{"stops before home", filepath.FromSlash("/home/pilot/flights/2024"), filepath.FromSlash("/home/pilot"), []string{filepath.FromSlash("/home/pilot/flights")}},
+		{"home itself", filepath.FromSlash("/home/pilot"), filepath.FromSlash("/home/pilot"), []string{filepath.FromSlash("/home")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := parentDirs(tt.dir, tt.stop); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestAddConfigPathsPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	archive := filepath.Join(home, "flights")
+	cwd := filepath.Join(archive, "2024")
+	userDir := filepath.Join(home, ".config", "igc-tool")
+	for _, dir := range []string{cwd, userDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+
+	// From the most to the least specific: the archive, the user config and the home
+	// directory itself
+	files := []string{
+		filepath.Join(archive, "igc-tool.toml"),
+		filepath.Join(userDir, "igc-tool.toml"),
+		filepath.Join(home, "igc-tool.toml"),
+	}
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("altitude-unit = \"ft\"\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+
+	for _, expected := range files {
+		v := viper.New()
+		v.SetConfigName("igc-tool")
+		v.SetConfigType("toml")
+		addConfigPaths(v, cwd, home)
+		if err := v.ReadInConfig(); err != nil {
+			t.Fatalf("failed to read config: %v", err)
+		}
+		if used := v.ConfigFileUsed(); used != expected {
+			t.Errorf("expected %s, got %s", expected, used)
+		}
+		// The next file takes over once this one is gone
+		if err := os.Remove(expected); err != nil {
+			t.Fatalf("failed to remove %s: %v", expected, err)
+		}
+	}
+}
+
+func TestFileFromArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"not given", []string{"logbook", "flights"}, ""},
+		{"separate value", []string{"--config", "ci.toml", "logbook", "flights"}, "ci.toml"},
+		{"after subcommand", []string{"stats", "--config", "ci.toml", "flight.igc"}, "ci.toml"},
+		{"equals form", []string{"stats", "--config=ci.toml", "flight.igc"}, "ci.toml"},
+		{"missing value", []string{"stats", "--config"}, ""},
+		{"after end of flags", []string{"stats", "--", "--config", "ci.toml"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := FileFromArgs(tt.args); result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestConfigMarshal(t *testing.T) {
+	defaults := viper.New()
+	setDefaults(defaults)
+	var cfg Config
+	if err := defaults.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConfigFile = "/home/pilot/.config/igc-tool/igc-tool.toml"
+
+	// The TOML output reads back as a config file with every key
+	data, err := cfg.Marshal(OutputTOML, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v := viper.New()
+	v.SetConfigType("toml")
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		t.Fatalf("failed to read TOML output: %v\n%s", err, data)
+	}
+	for _, key := range defaults.AllKeys() {
+		if !v.IsSet(key) {
+			t.Errorf("%s missing from TOML output", key)
+		} else if got, expected := v.GetString(key), defaults.GetString(key); got != expected {
+			t.Errorf("%s: expected %q, got %q", key, expected, got)
+		}
+	}
+	if strings.Contains(string(data), cfg.ConfigFile) {
+		t.Errorf("expected ConfigFile to be left out of the TOML output")
+	}
+
+	data, err = cfg.Marshal(OutputJSON, "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		t.Fatalf("failed to read JSON output: %v", err)
+	}
+	if len(values) != len(defaults.AllKeys()) {
+		t.Errorf("expected %d JSON keys, got %d: %v", len(defaults.AllKeys()), len(values), values)
+	}
+	if values["altitude-unit"] != "m" {
+		t.Errorf("expected altitude-unit m, got %v", values["altitude-unit"])
+	}
+	if !strings.Contains(string(data), "\n  \"altitude-unit\": ") {
+		t.Errorf("expected JSON indented by two spaces, got %s", data)
+	}
+
+	compact, err := cfg.Marshal(OutputJSON, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(string(compact), "\n") != 1 {
+		t.Errorf("expected compact JSON on a single line, got %s", compact)
+	}
+
+	if _, err := cfg.Marshal("yaml", ""); err == nil || !strings.Contains(err.Error(), "invalid output format") {
+		t.Errorf("expected invalid output format error, got %v", err)
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		verbose  bool
+		expected string
+	}{
+		{name: "silent by default", verbose: false, expected: ""},
+		{name: "verbose", verbose: true, expected: `level=DEBUG msg="parsed file" fixes=10`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			NewLogger(&buf, tt.verbose).Debug("parsed file", "fixes", 10)
+			if !strings.Contains(buf.String(), tt.expected) || (tt.expected == "" && buf.Len() > 0) {
+				t.Errorf("expected output containing %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
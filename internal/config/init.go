@@ -0,0 +1,214 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"igc-tool/internal/flight"
+	"igc-tool/internal/units"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+)
+
+// FileName is the name of the config file looked up by Load
+const FileName = "igc-tool.toml"
+
+// Setting describes a config file key for the scaffolded config file and config set
+type Setting struct {
+	Key         string
+	Description string
+	// parse converts a command-line value to the value stored in the config file,
+	// returning an error for invalid values
+	parse func(value string) (interface{}, error)
+}
+
+// Settings lists every config file key with its description, in the order written by
+// WriteDefaultFile. Each key has a default in setDefaults.
+var Settings = []Setting{
+	{"altitude-unit", "Unit for altitudes: m, ft, or fl for flight levels",
+		oneOf(units.ValidateAltitudeUnit, units.AltitudeMeters, units.AltitudeFeet, units.AltitudeFL)},
+	{"time-format", "Time format: 24h or ampm",
+		oneOf(units.ValidateTimeFormat, units.TimeFormat24h, units.TimeFormatAMPM)},
+	{"speed-unit", "Unit for speeds: kmh, mph, kts or ms",
+		oneOf(units.ValidateSpeedUnit, units.SpeedKmh, units.SpeedMph, units.SpeedKnots, units.SpeedMs)},
+	{"climb-unit", "Unit for climb rates: ms or fpm",
+		oneOf(units.ValidateClimbUnit, units.ClimbMs, units.ClimbFpm)},
+	{"distance-unit", "Unit for distances: km, mi for statute miles, or nm for nautical miles",
+		oneOf(units.ValidateDistanceUnit, units.DistanceKm, units.DistanceMiles, units.DistanceNauticalMiles)},
+	{"coord-format", "Format of logbook positions: decimal degrees, or dms for degrees, minutes and seconds",
+		oneOf(units.ValidateCoordFormat, units.CoordFormatDecimal, units.CoordFormatDMS)},
+	{"coord-precision", "Decimal places of positions in the decimal coordinate format", parsePositiveInt},
+	{"logbook-format", "Go template for the logbook, or csv, json or html", parseString},
+	{"sites-database-location", "Path to a GeoJSON file of landing sites", parseString},
+	{"speed-window", "Time window in seconds for ground speed calculations", parsePositiveFloat},
+	{"level-threshold", "Vertical speed in m/s below which flight counts as level", parseNonNegativeFloat},
+	{"min-fixes", "Minimum number of fixes for reliable statistics", parseNonNegativeInt},
+	{"climb-noise", "Altitude change in meters treated as sensor noise", parseNonNegativeFloat},
+	{"smooth", "Moving-average window in seconds over altitudes for climb and descent rates (0 disables)", parseNonNegativeFloat},
+	{"alt-source", "Altitude used for statistics: gps, or baro for pressure altitude", parseAltitudeSource},
+	{"dem", "Directory of SRTM .hgt elevation tiles for height above ground", parseString},
+	{"turnpoint-radius", "Radius in meters of the turnpoint cylinders for task completion", parsePositiveFloat},
+}
+
+// oneOf returns a parser accepting the values valid reports as valid, listing values
+// in the error
+func oneOf(valid func(string) bool, values ...string) func(string) (interface{}, error) {
+	return func(value string) (interface{}, error) {
+		if !valid(value) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(values, ", "))
+		}
+		return value, nil
+	}
+}
+
+func parseString(value string) (interface{}, error) {
+	return value, nil
+}
+
+func parseAltitudeSource(value string) (interface{}, error) {
+	if err := flight.ValidateAltitudeSource(value); err != nil {
+		return nil, fmt.Errorf("must be %s or %s", flight.AltSourceGPS, flight.AltSourceBaro)
+	}
+	return value, nil
+}
+
+func parsePositiveFloat(value string) (interface{}, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f <= 0 {
+		return nil, fmt.Errorf("must be a positive number")
+	}
+	return f, nil
+}
+
+func parseNonNegativeFloat(value string) (interface{}, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return nil, fmt.Errorf("must be a number of at least 0")
+	}
+	return f, nil
+}
+
+func parsePositiveInt(value string) (interface{}, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil || i <= 0 {
+		return nil, fmt.Errorf("must be a positive whole number")
+	}
+	return i, nil
+}
+
+func parseNonNegativeInt(value string) (interface{}, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return nil, fmt.Errorf("must be a whole number of at least 0")
+	}
+	return i, nil
+}
+
+// LookupSetting returns the setting with the given key
+func LookupSetting(key string) (Setting, bool) {
+	for _, setting := range Settings {
+		if setting.Key == key {
+			return setting, true
+		}
+	}
+	return Setting{}, false
+}
+
+// SetValue validates value for key and stores it in the TOML config file at path,
+// creating the file and its directory if missing. Other values in the file are kept,
+// but comments are not.
+func SetValue(path, key, value string) error {
+	setting, ok := LookupSetting(key)
+	if !ok {
+		keys := make([]string, len(Settings))
+		for i, setting := range Settings {
+			keys[i] = setting.Key
+		}
+		return fmt.Errorf("unknown config key %q, available: %s", key, strings.Join(keys, ", "))
+	}
+	parsed, err := setting.parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	v.Set(key, parsed)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := v.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// DefaultDir returns the per-user config directory searched by Load
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "igc-tool"), nil
+}
+
+// DefaultFileContent returns a TOML config file setting every key to its default, each
+// preceded by a comment describing it
+func DefaultFileContent() (string, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	var b strings.Builder
+	b.WriteString("# igc-tool configuration\n")
+	b.WriteString("# Command-line flags and IGC_* environment variables override these values.\n")
+	for _, setting := range Settings {
+		line, err := toml.Marshal(map[string]interface{}{setting.Key: v.Get(setting.Key)})
+		if err != nil {
+			return "", fmt.Errorf("failed to encode %s: %w", setting.Key, err)
+		}
+		fmt.Fprintf(&b, "\n# %s\n%s", setting.Description, line)
+	}
+	return b.String(), nil
+}
+
+// WriteDefaultFile writes the default config file as FileName in dir, creating the
+// directory if needed, and returns its path. An existing file is only replaced when
+// force is set.
+func WriteDefaultFile(dir string, force bool) (string, error) {
+	content, err := DefaultFileContent()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	path := filepath.Join(dir, FileName)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return "", fmt.Errorf("config file %s already exists (use --force to overwrite)", path)
+		}
+		return "", fmt.Errorf("failed to create config file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSettingsCoverDefaults(t *testing.T) {
+	v := viper.New()
+	setDefaults(v)
+
+	described := make(map[string]bool)
+	for _, setting := range Settings {
+		described[setting.Key] = true
+	}
+	for _, key := range v.AllKeys() {
+		if !described[key] {
+			t.Errorf("default %s has no entry in Settings", key)
+		}
+	}
+	if len(Settings) != len(v.AllKeys()) {
+		t.Errorf("expected %d settings, got %d", len(v.AllKeys()), len(Settings))
+	}
+}
+
+func TestWriteDefaultFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "igc-tool")
+
+	path, err := WriteDefaultFile(dir, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != filepath.Join(dir, FileName) {
+		t.Errorf("expected path %s, got %s", filepath.Join(dir, FileName), path)
+	}
+
+	// The written file reads back to the defaults
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("failed to read written config: %v", err)
+	}
+	defaults := viper.New()
+	setDefaults(defaults)
+	for _, key := range defaults.AllKeys() {
+		if got, expected := v.GetString(key), defaults.GetString(key); got != expected {
+			t.Errorf("%s: expected %q, got %q", key, expected, got)
+		}
+	}
+
+	// An existing file is kept unless forced
+	if err := os.WriteFile(path, []byte("altitude-unit = 'ft'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteDefaultFile(dir, false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+	if _, err := WriteDefaultFile(dir, true); err != nil {
+		t.Fatalf("unexpected error with force: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "altitude-unit = 'm'") {
+		t.Errorf("expected the forced write to restore the defaults, got:\n%s", content)
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "igc-tool", FileName)
+
+	tests := []struct {
+		name        string
+		key         string
+		value       string
+		expectError string
+	}{
+		{name: "unit", key: "altitude-unit", value: "ft"},
+		{name: "float", key: "speed-window", value: "3.5"},
+		{name: "int", key: "min-fixes", value: "20"},
+		{name: "invalid unit", key: "speed-unit", value: "kph", expectError: `invalid speed-unit "kph": must be one of kmh, mph, kts, ms`},
+		{name: "zero speed window", key: "speed-window", value: "0", expectError: `invalid speed-window "0": must be a positive number`},
+		{name: "not a number", key: "min-fixes", value: "many", expectError: `invalid min-fixes "many": must be a whole number of at least 0`},
+		{name: "invalid altitude source", key: "alt-source", value: "radar", expectError: `invalid alt-source "radar": must be gps or baro`},
+		{name: "unknown key", key: "altitude", value: "m", expectError: `unknown config key "altitude"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetValue(path, tt.key, tt.value)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expectError) {
+				t.Errorf("expected error %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+
+	// The file, created by the first call, keeps every valid value with its type
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	if got := v.GetString("altitude-unit"); got != "ft" {
+		t.Errorf("expected altitude-unit ft, got %q", got)
+	}
+	if got := v.GetFloat64("speed-window"); got != 3.5 {
+		t.Errorf("expected speed-window 3.5, got %v", got)
+	}
+	if got := v.GetInt("min-fixes"); got != 20 {
+		t.Errorf("expected min-fixes 20, got %v", got)
+	}
+	if v.IsSet("speed-unit") {
+		t.Errorf("expected the invalid speed-unit not to be written")
+	}
+}
@@ -2,93 +2,324 @@ package display
 
 import (
 	"fmt"
+	"io"
 
 	"igc-tool/internal/flight"
+	"igc-tool/internal/scoring"
 	"igc-tool/internal/units"
 	"igc-tool/internal/utils"
 
 	"github.com/twpayne/go-igc"
 )
 
-// PrintFlightHeaders prints the flight header information
-func PrintFlightHeaders(f *flight.Flight) {
+// PrintFlightHeaders prints the flight header information to w
+func PrintFlightHeaders(w io.Writer, f *flight.Flight) {
 	// Print parsed header data
-	fmt.Printf("Date: %s\n", f.Date.Format("2006-01-02"))
-	fmt.Printf("Pilot: %s\n", f.Pilot)
+	fmt.Fprintf(w, "Date: %s\n", f.Date.Format("2006-01-02"))
+	if f.FlightOfDay > 0 {
+		fmt.Fprintf(w, "Flight of Day: %d\n", f.FlightOfDay)
+	}
+	fmt.Fprintf(w, "Pilot: %s\n", f.Pilot)
 	if f.Crew != "" && f.Crew != "NIL" {
-		fmt.Printf("Crew: %s\n", f.Crew)
+		fmt.Fprintf(w, "Crew: %s\n", f.Crew)
 	}
-	fmt.Printf("Glider Type: %s\n", f.GliderType)
+	fmt.Fprintf(w, "Glider Type: %s\n", f.GliderType)
 	if f.GliderID != "" && f.GliderID != "NKN" {
-		fmt.Printf("Glider ID: %s\n", f.GliderID)
+		fmt.Fprintf(w, "Glider ID: %s\n", f.GliderID)
 	}
 	if f.CompetitionID != "" && f.CompetitionID != "NKN" {
-		fmt.Printf("Competition ID: %s\n", f.CompetitionID)
+		fmt.Fprintf(w, "Competition ID: %s\n", f.CompetitionID)
 	}
 	if f.GPSDatum != "" {
-		fmt.Printf("GPS Datum: %s\n", f.GPSDatum)
+		fmt.Fprintf(w, "GPS Datum: %s\n", f.GPSDatum)
 	}
 	if f.FirmwareVersion != "" {
-		fmt.Printf("Firmware Version: %s\n", f.FirmwareVersion)
+		fmt.Fprintf(w, "Firmware Version: %s\n", f.FirmwareVersion)
 	}
 	if f.HardwareVersion != "" {
-		fmt.Printf("Hardware Version: %s\n", f.HardwareVersion)
+		fmt.Fprintf(w, "Hardware Version: %s\n", f.HardwareVersion)
 	}
 	if f.FlightRecorderType != "" {
-		fmt.Printf("Flight Recorder Type: %s\n", f.FlightRecorderType)
+		fmt.Fprintf(w, "Flight Recorder Type: %s\n", f.FlightRecorderType)
 	}
 	if f.GPSReceiver != "" {
-		fmt.Printf("GPS Receiver: %s\n", f.GPSReceiver)
+		fmt.Fprintf(w, "GPS Receiver: %s\n", f.GPSReceiver)
 	}
 	if f.TimeZone != "" {
-		fmt.Printf("Time Zone: %s\n", f.TimeZone)
+		fmt.Fprintf(w, "Time Zone: %s\n", f.TimeZone)
 	}
 	if f.PressureAltSensor != "" {
-		fmt.Printf("Pressure Altitude Sensor: %s\n", f.PressureAltSensor)
+		fmt.Fprintf(w, "Pressure Altitude Sensor: %s\n", f.PressureAltSensor)
 	}
 	if f.AltGPSRef != "" {
-		fmt.Printf("GPS Altitude Reference: %s\n", f.AltGPSRef)
+		fmt.Fprintf(w, "GPS Altitude Reference: %s\n", f.AltGPSRef)
 	}
 	if f.AltPressureRef != "" {
-		fmt.Printf("Pressure Altitude Reference: %s\n", f.AltPressureRef)
+		fmt.Fprintf(w, "Pressure Altitude Reference: %s\n", f.AltPressureRef)
+	}
+}
+
+// PrintTask prints the declared task with its waypoints, the length of each leg and
+// the task distance. Takeoff and landing waypoints without a position, as many loggers
+// declare them, are printed by name only. A coordPrecision below 1 uses
+// utils.DefaultCoordPrecision.
+func PrintTask(w io.Writer, task *flight.Task, distanceUnit, timeFormat, coordFormat string, coordPrecision int) {
+	distanceSymbol := units.DistanceSymbol(distanceUnit)
+	if coordPrecision < 1 {
+		coordPrecision = utils.DefaultCoordPrecision
+	}
+	position := func(wp flight.Waypoint) string {
+		return utils.FormatPosition(wp.Lat, wp.Lon, coordFormat, coordPrecision)
+	}
+
+	if task.Description != "" {
+		fmt.Fprintf(w, "Task: %s\n", task.Description)
+	}
+	if !task.DeclarationTime.IsZero() {
+		fmt.Fprintf(w, "Declared: %s %s\n", task.DeclarationTime.Format("2006-01-02"), utils.FormatTime(task.DeclarationTime, timeFormat))
+	}
+
+	if task.Takeoff.Lat != 0 || task.Takeoff.Lon != 0 {
+		fmt.Fprintf(w, "Takeoff: %s (%s)\n", task.Takeoff.Name, position(task.Takeoff))
+	} else if task.Takeoff.Name != "" {
+		fmt.Fprintf(w, "Takeoff: %s\n", task.Takeoff.Name)
+	}
+
+	route := task.Route()
+	legs := task.Legs()
+	for i, wp := range route {
+		label := fmt.Sprintf("Turnpoint %d", i)
+		switch i {
+		case 0:
+			label = "Start"
+		case len(route) - 1:
+			label = "Finish"
+		}
+		fmt.Fprintf(w, "%s: %s (%s)", label, wp.Name, position(wp))
+		if i > 0 {
+			fmt.Fprintf(w, ", leg %.1f%s", units.Distance(legs[i-1], distanceUnit), distanceSymbol)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if task.Landing.Lat != 0 || task.Landing.Lon != 0 {
+		fmt.Fprintf(w, "Landing: %s (%s)\n", task.Landing.Name, position(task.Landing))
+	} else if task.Landing.Name != "" {
+		fmt.Fprintf(w, "Landing: %s\n", task.Landing.Name)
+	}
+
+	fmt.Fprintf(w, "Task Distance: %.1f%s\n", units.Distance(task.Distance(), distanceUnit), distanceSymbol)
+}
+
+// PrintTaskCompletion prints when each waypoint of the task route was reached and the
+// share of the task distance flown
+func PrintTaskCompletion(w io.Writer, completion *flight.TaskCompletion, distanceUnit, timeFormat string) {
+	distanceSymbol := units.DistanceSymbol(distanceUnit)
+
+	for _, pass := range completion.Turnpoints {
+		if pass.Reached {
+			fmt.Fprintf(w, "Reached %s: %s\n", pass.Waypoint.Name, utils.FormatTime(pass.Time, timeFormat))
+		} else {
+			fmt.Fprintf(w, "Missed %s\n", pass.Waypoint.Name)
+		}
+	}
+	status := "incomplete"
+	if completion.Completed {
+		status = "completed"
+	}
+	fmt.Fprintf(w, "Task %s: %.1f%s of %.1f%s (%.1f%%)\n", status,
+		units.Distance(completion.AchievedDistance, distanceUnit), distanceSymbol,
+		units.Distance(completion.TaskDistance, distanceUnit), distanceSymbol,
+		completion.Percentage)
+}
+
+// PrintFreeDistance prints the scored free distance with its start, turnpoints and
+// finish. A coordPrecision below 1 uses utils.DefaultCoordPrecision.
+func PrintFreeDistance(w io.Writer, result *scoring.Result, distanceUnit, timeFormat, coordFormat string, coordPrecision int) {
+	distanceSymbol := units.DistanceSymbol(distanceUnit)
+	if coordPrecision < 1 {
+		coordPrecision = utils.DefaultCoordPrecision
+	}
+
+	fmt.Fprintf(w, "Free Distance (%d turnpoints): %.1f%s\n", result.Turnpoints,
+		units.Distance(result.Distance, distanceUnit), distanceSymbol)
+	for i, point := range result.Points {
+		label := fmt.Sprintf("Turnpoint %d", i)
+		switch i {
+		case 0:
+			label = "Start"
+		case len(result.Points) - 1:
+			label = "Finish"
+		}
+		fmt.Fprintf(w, "%s: %s (%s)", label, utils.FormatTime(point.Time, timeFormat),
+			utils.FormatPosition(point.Lat, point.Lon, coordFormat, coordPrecision))
+		if i > 0 {
+			fmt.Fprintf(w, ", leg %.1f%s", units.Distance(result.Legs[i-1], distanceUnit), distanceSymbol)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// PrintTriangle prints a scored triangle with its vertices, legs and closing gap under
+// the given label, or that there is none when result is nil. A coordPrecision below 1
+// uses utils.DefaultCoordPrecision.
+func PrintTriangle(w io.Writer, label string, result *scoring.TriangleResult, distanceUnit, timeFormat, coordFormat string, coordPrecision int) {
+	if result == nil {
+		fmt.Fprintf(w, "%s: none\n", label)
+		return
+	}
+	distanceSymbol := units.DistanceSymbol(distanceUnit)
+	if coordPrecision < 1 {
+		coordPrecision = utils.DefaultCoordPrecision
+	}
+	position := func(p scoring.Point) string {
+		return fmt.Sprintf("%s (%s)", utils.FormatTime(p.Time, timeFormat),
+			utils.FormatPosition(p.Lat, p.Lon, coordFormat, coordPrecision))
 	}
+
+	fmt.Fprintf(w, "%s: %.1f%s\n", label, units.Distance(result.Distance, distanceUnit), distanceSymbol)
+	for i, point := range result.Points {
+		fmt.Fprintf(w, "Turnpoint %d: %s, leg %.1f%s\n", i+1, position(point),
+			units.Distance(result.Legs[i], distanceUnit), distanceSymbol)
+	}
+	fmt.Fprintf(w, "Perimeter: %.1f%s\n", units.Distance(result.Perimeter, distanceUnit), distanceSymbol)
+	fmt.Fprintf(w, "Closing: %.1f%s from %s to %s\n", units.Distance(result.ClosingDistance, distanceUnit), distanceSymbol,
+		position(result.ClosingStart), position(result.ClosingEnd))
 }
 
 // PrintFix prints a single fix with formatting
-func PrintFix(fix *igc.BRecord, prefix string, altitudeUnit string, timeFormat string) {
-	altitudeSymbol := units.AltitudeSymbol(altitudeUnit)
-	altGPS := int(units.Altitude(float64(fix.AltWGS84), altitudeUnit))
-	altBaro := int(units.Altitude(float64(fix.AltBarometric), altitudeUnit))
+func PrintFix(w io.Writer, fix *igc.BRecord, prefix string, altitudeUnit string, timeFormat string) {
 	timeStr := utils.FormatTime(fix.Time, timeFormat)
 
-	fmt.Printf("  %s%s: (%.5f, %.5f), Alt(GPS): %d%s, Alt(Baro): %d%s\n",
+	fmt.Fprintf(w, "  %s%s: (%.5f, %.5f), Alt(GPS): %s, Alt(Baro): %s\n",
 		prefix,
 		timeStr,
 		fix.Lat, fix.Lon,
-		altGPS, altitudeSymbol,
-		altBaro, altitudeSymbol,
+		units.FormatAltitude(fix.AltWGS84, altitudeUnit),
+		units.FormatAltitude(fix.AltBarometric, altitudeUnit),
 	)
 }
 
 // PrintFlightData prints complete flight data with optional summary mode
-func PrintFlightData(f *flight.Flight, summary bool, altitudeUnit string, timeFormat string) {
-	PrintFlightHeaders(f)
-
-	fmt.Printf("\nFixes (%d total):\n", len(f.Fixes))
+func PrintFlightData(w io.Writer, f *flight.Flight, summary bool, altitudeUnit string, timeFormat string) {
+	printFlightInfo(w, f)
 
 	if summary {
 		// Show only first and last fix in summary mode
 		if len(f.Fixes) > 0 {
-			PrintFix(f.Fixes[0], "First: ", altitudeUnit, timeFormat)
+			PrintFix(w, f.Fixes[0], "First: ", altitudeUnit, timeFormat)
 
 			if len(f.Fixes) > 1 {
-				PrintFix(f.Fixes[len(f.Fixes)-1], "Last:  ", altitudeUnit, timeFormat)
+				PrintFix(w, f.Fixes[len(f.Fixes)-1], "Last:  ", altitudeUnit, timeFormat)
 			}
 		}
 	} else {
 		// Show all fixes in full mode
 		for _, fix := range f.Fixes {
-			PrintFix(fix, "", altitudeUnit, timeFormat)
+			PrintFix(w, fix, "", altitudeUnit, timeFormat)
+		}
+	}
+}
+
+// PrintFlightDataTable prints complete flight data like PrintFlightData, with the
+// fixes as an aligned table
+func PrintFlightDataTable(w io.Writer, f *flight.Flight, summary bool, opts FixTableOptions) error {
+	printFlightInfo(w, f)
+	return PrintFixTable(w, f, summary, opts)
+}
+
+// printFlightInfo prints the headers and comments of a flight and the heading of its
+// fix list
+func printFlightInfo(w io.Writer, f *flight.Flight) {
+	PrintFlightHeaders(w, f)
+
+	if len(f.Comments) > 0 {
+		fmt.Fprintf(w, "\nComments (%d total):\n", len(f.Comments))
+		for _, comment := range f.Comments {
+			if comment.Source != "" {
+				fmt.Fprintf(w, "%s: %s\n", comment.Source, comment.Text)
+			} else {
+				fmt.Fprintf(w, "%s\n", comment.Text)
+			}
 		}
 	}
+
+	fmt.Fprintf(w, "\nFixes (%d total):\n", len(f.Fixes))
+}
+
+// PrintInsufficientData prints the flight summary in place of statistics when the
+// flight has too few fixes for them to be meaningful
+func PrintInsufficientData(w io.Writer, f *flight.Flight, minFixes int) {
+	fmt.Fprintf(w, "Date: %s\n", f.Date.Format("2006-01-02"))
+	fmt.Fprintf(w, "Pilot: %s\n", f.Pilot)
+	fmt.Fprintf(w, "Insufficient data: %d fixes, at least %d required for statistics\n", len(f.Fixes), minFixes)
+}
+
+// printCentering prints the circle radius and its deviation for a thermal, if any
+func printCentering(w io.Writer, label string, c *flight.ThermalCentering, altitudeUnit, timeFormat string) {
+	if c == nil {
+		return
+	}
+	heightSymbol := units.AltitudeSymbol(units.HeightUnit(altitudeUnit))
+	fmt.Fprintf(w, "%s: %s, radius %.0f%s ±%.0f%s\n", label,
+		utils.FormatTime(c.Thermal.StartTime, timeFormat),
+		units.Height(c.AvgRadius, altitudeUnit), heightSymbol,
+		units.Height(c.RadiusStdDev, altitudeUnit), heightSymbol)
+}
+
+// PrintStatistics prints a compact human-readable summary of the flight statistics
+func PrintStatistics(w io.Writer, f *flight.Flight, stats *flight.Statistics, altitudeUnit, speedUnit, climbUnit, distanceUnit, timeFormat string) {
+	heightSymbol := units.AltitudeSymbol(units.HeightUnit(altitudeUnit))
+	distanceSymbol := units.DistanceSymbol(distanceUnit)
+	speedSymbol := units.SpeedSymbol(speedUnit)
+	climbSymbol := units.ClimbSymbol(climbUnit)
+
+	fmt.Fprintf(w, "Date: %s\n", f.Date.Format("2006-01-02"))
+	fmt.Fprintf(w, "Pilot: %s\n", f.Pilot)
+	fmt.Fprintf(w, "Duration: %s\n", utils.FormatDurationLong(stats.FlightDuration))
+	fmt.Fprintf(w, "Moving Time: %s\n", utils.FormatDurationLong(stats.MovingTime))
+	fmt.Fprintf(w, "Max Altitude: %s\n", units.FormatAltitude(float64(stats.MaxAltitude), altitudeUnit))
+	fmt.Fprintf(w, "Min Altitude: %s\n", units.FormatAltitude(float64(stats.MinAltitude), altitudeUnit))
+	if stats.MaxAltitudeBaro != 0 || stats.MinAltitudeBaro != 0 {
+		fmt.Fprintf(w, "Max Pressure Altitude: %s\n", units.FormatAltitude(float64(stats.MaxAltitudeBaro), altitudeUnit))
+		fmt.Fprintf(w, "Min Pressure Altitude: %s\n", units.FormatAltitude(float64(stats.MinAltitudeBaro), altitudeUnit))
+	}
+	fmt.Fprintf(w, "Max Climb Rate: %.1f%s\n", units.Climb(stats.MaxClimbRate, climbUnit), climbSymbol)
+	fmt.Fprintf(w, "Max Descent Rate: %.1f%s\n", units.Climb(stats.MaxDescentRate, climbUnit), climbSymbol)
+	fmt.Fprintf(w, "Max Ground Speed: %.0f%s\n", units.Speed(stats.MaxGroundSpeed, speedUnit), speedSymbol)
+	fmt.Fprintf(w, "95th Percentile Ground Speed: %.0f%s\n", units.Speed(stats.P95GroundSpeed, speedUnit), speedSymbol)
+	fmt.Fprintf(w, "Track Distance: %.1f%s\n", units.Distance(stats.TrackDistance, distanceUnit), distanceSymbol)
+	fmt.Fprintf(w, "Open Distance: %.1f%s\n", units.Distance(stats.OpenDistance, distanceUnit), distanceSymbol)
+	if stats.GlideRatio > 0 {
+		fmt.Fprintf(w, "Glide Ratio: %.1f:1\n", stats.GlideRatio)
+	}
+	fmt.Fprintf(w, "Straight-line Speed: %.1f%s\n", units.Speed(stats.StraightLineSpeed, speedUnit), speedSymbol)
+	if stats.HasCourse {
+		fmt.Fprintf(w, "Course: %.0f°\n", stats.Course)
+	}
+	fmt.Fprintf(w, "Max Turn Rate: %.0f°/s\n", stats.MaxTurnRate)
+	fmt.Fprintf(w, "Total Climb: %d%s\n", int(units.Height(stats.TotalClimb, altitudeUnit)), heightSymbol)
+	if stats.BiggestClimbGain > 0 {
+		fmt.Fprintf(w, "Biggest Climb: %d%s at %s (%s)\n",
+			int(units.Height(stats.BiggestClimbGain, altitudeUnit)), heightSymbol,
+			utils.FormatTime(stats.BiggestClimbTime, timeFormat),
+			utils.FormatCoordinates(stats.BiggestClimbLat, stats.BiggestClimbLon, utils.DefaultCoordPrecision))
+	}
+	if stats.HasMinAGL {
+		fmt.Fprintf(w, "Lowest Save: %d%s above ground at %s (%s)\n",
+			int(units.Height(stats.MinAGL, altitudeUnit)), heightSymbol,
+			utils.FormatTime(stats.MinAGLTime, timeFormat),
+			utils.FormatCoordinates(stats.MinAGLLat, stats.MinAGLLon, utils.DefaultCoordPrecision))
+	}
+	printCentering(w, "Best Centered Thermal", stats.BestCentering, altitudeUnit, timeFormat)
+	if stats.WorstCentering != nil && stats.WorstCentering.Thermal.StartIndex != stats.BestCentering.Thermal.StartIndex {
+		printCentering(w, "Worst Centered Thermal", stats.WorstCentering, altitudeUnit, timeFormat)
+	}
+	climbPercent, sinkPercent, levelPercent := stats.VerticalTimePercentages()
+	fmt.Fprintf(w, "Vertical Profile: %.0f%% climbing, %.0f%% sinking, %.0f%% level\n", climbPercent, sinkPercent, levelPercent)
+	if stats.CirclingTime+stats.GlidingTime > 0 {
+		circlingPercent := stats.CirclingTimePercent()
+		fmt.Fprintf(w, "Circling: %.0f%% of time, %.1f%s\n", circlingPercent, units.Distance(stats.CirclingDistance, distanceUnit), distanceSymbol)
+		fmt.Fprintf(w, "Gliding: %.0f%% of time, %.1f%s\n", 100-circlingPercent, units.Distance(stats.GlidingDistance, distanceUnit), distanceSymbol)
+	}
 }
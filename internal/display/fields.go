@@ -0,0 +1,119 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"igc-tool/internal/flight"
+	"igc-tool/internal/utils"
+)
+
+// Computed fields selectable alongside the Flight header fields
+const (
+	FieldDuration = "duration"
+	FieldFixCount = "fix_count"
+)
+
+// FlightFields returns the field names accepted by FlightFieldValues: the scalar
+// Flight header fields in snake_case (e.g. "pilot", "glider_type", "gps_datum")
+// followed by the computed fields
+func FlightFields() []string {
+	var fields []string
+	t := reflect.TypeOf(flight.Flight{})
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// Only include exported scalar fields; the task and fixes have their own output
+		if field.PkgPath == "" && field.Type.Kind() != reflect.Ptr && field.Type.Kind() != reflect.Slice {
+			fields = append(fields, snakeCase(field.Name))
+		}
+	}
+
+	return append(fields, FieldDuration, FieldFixCount)
+}
+
+// ValidateFlightFields checks that every field is one of FlightFields
+func ValidateFlightFields(fields []string) error {
+	available := FlightFields()
+	for _, field := range fields {
+		if !contains(available, field) {
+			return fmt.Errorf("unknown field %q: must be one of %s", field, strings.Join(available, ", "))
+		}
+	}
+	return nil
+}
+
+// FlightFieldValues returns the values of the named fields of a flight, in order.
+// Dates are formatted as YYYY-MM-DD and the duration as by utils.FormatDurationLong.
+func FlightFieldValues(f *flight.Flight, fields []string) ([]string, error) {
+	if err := ValidateFlightFields(fields); err != nil {
+		return nil, err
+	}
+
+	v := reflect.ValueOf(*f)
+	t := v.Type()
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		switch field {
+		case FieldDuration:
+			values[i] = utils.FormatDurationLong(f.Duration())
+		case FieldFixCount:
+			values[i] = fmt.Sprint(len(f.Fixes))
+		default:
+			for j := 0; j < t.NumField(); j++ {
+				if snakeCase(t.Field(j).Name) != field {
+					continue
+				}
+				if date, ok := v.Field(j).Interface().(time.Time); ok {
+					values[i] = date.Format("2006-01-02")
+				} else {
+					values[i] = fmt.Sprint(v.Field(j).Interface())
+				}
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// PrintFlightFields prints the values of the named fields to w on one tab-separated line
+func PrintFlightFields(w io.Writer, f *flight.Flight, fields []string) error {
+	values, err := FlightFieldValues(f, fields)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, strings.Join(values, "\t"))
+	return nil
+}
+
+// snakeCase converts a Go field name to snake_case, keeping acronyms together:
+// "GliderType" becomes "glider_type" and "GPSDatum" becomes "gps_datum"
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			acronymEnd := unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || acronymEnd {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
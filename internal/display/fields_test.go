@@ -0,0 +1,57 @@
+package display
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Pilot":              "pilot",
+		"GliderType":         "glider_type",
+		"GPSDatum":           "gps_datum",
+		"AltGPSRef":          "alt_gps_ref",
+		"GliderID":           "glider_id",
+		"FlightOfDay":        "flight_of_day",
+		"FlightRecorderType": "flight_recorder_type",
+	}
+	for name, expected := range tests {
+		if got := snakeCase(name); got != expected {
+			t.Errorf("snakeCase(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestFlightFieldValues(t *testing.T) {
+	start := time.Date(2023, 7, 30, 11, 0, 0, 0, time.UTC)
+	f := &flight.Flight{
+		Date:        time.Date(2023, 7, 30, 0, 0, 0, 0, time.UTC),
+		FlightOfDay: 2,
+		Pilot:       "John Doe",
+		GPSDatum:    "WGS-1984",
+		Fixes: []*igc.BRecord{
+			{Time: start},
+			{Time: start.Add(90 * time.Minute)},
+		},
+	}
+
+	values, err := FlightFieldValues(f, []string{"pilot", "date", "duration", "gps_datum", "flight_of_day", "fix_count"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"John Doe", "2023-07-30", "1h30m0s", "WGS-1984", "2", "2"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	_, err = FlightFieldValues(f, []string{"pilot", "fixes"})
+	if err == nil || !strings.Contains(err.Error(), `unknown field "fixes"`) || !strings.Contains(err.Error(), "glider_type") {
+		t.Errorf("expected unknown field error listing available fields, got %v", err)
+	}
+}
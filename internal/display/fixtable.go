@@ -0,0 +1,68 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"igc-tool/internal/flight"
+	"igc-tool/internal/units"
+	"igc-tool/internal/utils"
+)
+
+// FixTableOptions controls the units and columns of PrintFixTable
+type FixTableOptions struct {
+	AltitudeUnit string
+	TimeFormat   string
+	Speeds       bool // add ground speed and vertical speed columns
+}
+
+// PrintFixTable prints the fixes of a flight as a table with aligned columns for time,
+// position and GPS and barometric altitudes, or only the first and last fixes in
+// summary mode. With opts.Speeds the instantaneous ground speed in km/h and vertical
+// speed in m/s since the previous fix are added, shown as "-" for the first fix and for
+// fixes sharing a timestamp.
+func PrintFixTable(w io.Writer, f *flight.Flight, summary bool, opts FixTableOptions) error {
+	altitudeSymbol := units.AltitudeSymbol(opts.AltitudeUnit)
+	header := []string{"TIME", "LAT", "LON", "ALT GPS (" + altitudeSymbol + ")", "ALT BARO (" + altitudeSymbol + ")"}
+	if opts.Speeds {
+		header = append(header, "GROUND SPEED (km/h)", "VERTICAL SPEED (m/s)")
+	}
+
+	indices := make([]int, 0, len(f.Fixes))
+	for i := range f.Fixes {
+		if !summary || i == 0 || i == len(f.Fixes)-1 {
+			indices = append(indices, i)
+		}
+	}
+
+	// Right-align so the numbers line up on their last digit
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\t\n", strings.Join(header, "\t"))
+	for _, i := range indices {
+		fix := f.Fixes[i]
+		row := []string{
+			utils.FormatTime(fix.Time, opts.TimeFormat),
+			strconv.FormatFloat(fix.Lat, 'f', 5, 64),
+			strconv.FormatFloat(fix.Lon, 'f', 5, 64),
+			strconv.Itoa(int(units.Altitude(fix.AltWGS84, opts.AltitudeUnit))),
+			strconv.Itoa(int(units.Altitude(fix.AltBarometric, opts.AltitudeUnit))),
+		}
+
+		if opts.Speeds {
+			groundSpeed, verticalSpeed := "-", "-"
+			if i > 0 {
+				if ground, vertical, ok := flight.FixSpeeds(f.Fixes[i-1], fix); ok {
+					groundSpeed = strconv.FormatFloat(ground, 'f', 1, 64)
+					verticalSpeed = strconv.FormatFloat(vertical, 'f', 1, 64)
+				}
+			}
+			row = append(row, groundSpeed, verticalSpeed)
+		}
+
+		fmt.Fprintf(tw, "%s\t\n", strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,78 @@
+package display
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestPrintFixTable(t *testing.T) {
+	baseTime := time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC)
+	f := &flight.Flight{Fixes: []*igc.BRecord{
+		{Time: baseTime, Lat: 45.8, Lon: 6.2, AltWGS84: 1500, AltBarometric: 1480},
+		{Time: baseTime.Add(10 * time.Second), Lat: 45.801, Lon: 6.2, AltWGS84: 1520, AltBarometric: 1500},
+		{Time: baseTime.Add(10 * time.Second), Lat: 45.801, Lon: 6.2, AltWGS84: 1520, AltBarometric: 1500},
+		{Time: baseTime.Add(20 * time.Second), Lat: 45.802, Lon: 6.2, AltWGS84: 1510, AltBarometric: 1490},
+	}}
+
+	tests := []struct {
+		name     string
+		summary  bool
+		opts     FixTableOptions
+		expected []string
+	}{
+		{
+			name: "all fixes",
+			opts: FixTableOptions{AltitudeUnit: "m", TimeFormat: "24h"},
+			expected: []string{
+				"      TIME       LAT      LON  ALT GPS (m)  ALT BARO (m)",
+				"  12:00:00  45.80000  6.20000         1500          1480",
+				"  12:00:10  45.80100  6.20000         1520          1500",
+				"  12:00:10  45.80100  6.20000         1520          1500",
+				"  12:00:20  45.80200  6.20000         1510          1490",
+			},
+		},
+		{
+			name:    "summary with speeds",
+			summary: true,
+			opts:    FixTableOptions{AltitudeUnit: "m", TimeFormat: "24h", Speeds: true},
+			expected: []string{
+				"      TIME       LAT      LON  ALT GPS (m)  ALT BARO (m)  GROUND SPEED (km/h)  VERTICAL SPEED (m/s)",
+				"  12:00:00  45.80000  6.20000         1500          1480                    -                     -",
+				"  12:00:20  45.80200  6.20000         1510          1490                 40.0                  -1.0",
+			},
+		},
+		{
+			name: "speeds",
+			opts: FixTableOptions{AltitudeUnit: "ft", TimeFormat: "24h", Speeds: true},
+			expected: []string{
+				"      TIME       LAT      LON  ALT GPS (ft)  ALT BARO (ft)  GROUND SPEED (km/h)  VERTICAL SPEED (m/s)",
+				"  12:00:00  45.80000  6.20000          4921           4855                    -                     -",
+				"  12:00:10  45.80100  6.20000          4986           4921                 40.0                   2.0",
+				"  12:00:10  45.80100  6.20000          4986           4921                    -                     -",
+				"  12:00:20  45.80200  6.20000          4954           4888                 40.0                  -1.0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := PrintFixTable(&buf, f, tt.summary, tt.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			for i := range lines {
+				lines[i] = strings.TrimRight(lines[i], " ")
+			}
+			if got := strings.Join(lines, "\n"); got != strings.Join(tt.expected, "\n") {
+				t.Errorf("unexpected table:\n%s\nexpected:\n%s", got, strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
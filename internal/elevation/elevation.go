@@ -0,0 +1,155 @@
+package elevation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// hgtVoid marks samples without data in SRTM tiles
+const hgtVoid = -32768
+
+// HGTDirectory provides ground elevations from the SRTM .hgt tiles in a directory, as
+// downloaded for offline use. Each tile covers one degree of latitude and longitude and
+// is named after its south-west corner, e.g. N45E006.hgt; both the 3 arc-second (1201
+// samples square) and 1 arc-second (3601 samples square) resolutions are read. Tiles
+// are loaded on first use, so a directory covering a whole country costs only the
+// tiles a flight crosses.
+type HGTDirectory struct {
+	paths map[string]string // tile name, e.g. N45E006, to file path
+
+	mu    sync.Mutex
+	tiles map[string]*tile // loaded tiles, nil for tiles that failed to load
+}
+
+// tile is the grid of one .hgt file, rows running north to south
+type tile struct {
+	size    int
+	samples []int16
+}
+
+// NewHGTDirectory indexes the .hgt tiles in dir, failing when dir cannot be read or a
+// tile has a size matching neither SRTM resolution
+func NewHGTDirectory(dir string) (*HGTDirectory, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read elevation directory %s: %w", dir, err)
+	}
+
+	d := &HGTDirectory{paths: make(map[string]string), tiles: make(map[string]*tile)}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(name), ".hgt") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read elevation tile %s: %w", name, err)
+		}
+		if tileSize(info.Size()) == 0 {
+			return nil, fmt.Errorf("invalid elevation tile %s: %d bytes is not an SRTM tile size", name, info.Size())
+		}
+		d.paths[strings.ToUpper(strings.TrimSuffix(name, filepath.Ext(name)))] = filepath.Join(dir, name)
+	}
+	if len(d.paths) == 0 {
+		return nil, fmt.Errorf("no .hgt elevation tiles in %s", dir)
+	}
+	return d, nil
+}
+
+// Tiles returns the number of tiles found in the directory
+func (d *HGTDirectory) Tiles() int {
+	return len(d.paths)
+}
+
+// Elevation returns the ground elevation in meters above sea level at a position,
+// interpolated between the four surrounding samples. It reports false outside the
+// tiles of the directory and where a surrounding sample is void.
+func (d *HGTDirectory) Elevation(lat, lon float64) (float64, bool) {
+	south, west := math.Floor(lat), math.Floor(lon)
+	t := d.tile(TileName(lat, lon))
+	if t == nil {
+		return 0, false
+	}
+
+	last := float64(t.size - 1)
+	row := (south + 1 - lat) * last
+	col := (lon - west) * last
+	r0, c0 := min(int(row), t.size-2), min(int(col), t.size-2)
+	dr, dc := row-float64(r0), col-float64(c0)
+
+	var corners [4]float64
+	for i, index := range []int{r0*t.size + c0, r0*t.size + c0 + 1, (r0+1)*t.size + c0, (r0+1)*t.size + c0 + 1} {
+		if t.samples[index] == hgtVoid {
+			return 0, false
+		}
+		corners[i] = float64(t.samples[index])
+	}
+
+	north := corners[0]*(1-dc) + corners[1]*dc
+	southRow := corners[2]*(1-dc) + corners[3]*dc
+	return north*(1-dr) + southRow*dr, true
+}
+
+// tile returns the named tile, loading it on first use, or nil when the directory has
+// no such tile or it cannot be read
+func (d *HGTDirectory) tile(name string) *tile {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if t, ok := d.tiles[name]; ok {
+		return t
+	}
+	var t *tile
+	if path, ok := d.paths[name]; ok {
+		t, _ = loadTile(path)
+	}
+	d.tiles[name] = t
+	return t
+}
+
+// loadTile reads a .hgt file of big-endian 16-bit samples
+func loadTile(path string) (*tile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read elevation tile %s: %w", path, err)
+	}
+	size := tileSize(int64(len(data)))
+	if size == 0 {
+		return nil, fmt.Errorf("invalid elevation tile %s: %d bytes is not an SRTM tile size", path, len(data))
+	}
+
+	samples := make([]int16, size*size)
+	for i := range samples {
+		samples[i] = int16(binary.BigEndian.Uint16(data[2*i:]))
+	}
+	return &tile{size: size, samples: samples}, nil
+}
+
+// tileSize returns the samples per side of a .hgt file of the given length in bytes,
+// or 0 when it matches neither SRTM resolution
+func tileSize(bytes int64) int {
+	for _, size := range []int{1201, 3601} {
+		if bytes == int64(2*size*size) {
+			return size
+		}
+	}
+	return 0
+}
+
+// TileName returns the name of the SRTM tile covering a position, e.g. N45E006
+func TileName(lat, lon float64) string {
+	south, west := int(math.Floor(lat)), int(math.Floor(lon))
+	ns, ew := 'N', 'E'
+	if south < 0 {
+		ns, south = 'S', -south
+	}
+	if west < 0 {
+		ew, west = 'W', -west
+	}
+	return fmt.Sprintf("%c%02d%c%03d", ns, south, ew, west)
+}
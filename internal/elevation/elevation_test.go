@@ -0,0 +1,107 @@
+package elevation
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTile writes a 3 arc-second tile whose elevation is given per row and column
+func writeTile(t *testing.T, dir, name string, elevation func(row, col int) int16) {
+	t.Helper()
+	const size = 1201
+	data := make([]byte, 2*size*size)
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			binary.BigEndian.PutUint16(data[2*(row*size+col):], uint16(elevation(row, col)))
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		t.Fatalf("failed to write tile: %v", err)
+	}
+}
+
+func TestTileName(t *testing.T) {
+	tests := []struct {
+		lat, lon float64
+		expected string
+	}{
+		{lat: 45.8, lon: 6.2, expected: "N45E006"},
+		{lat: -33.9, lon: 18.4, expected: "S34E018"},
+		{lat: 40.7, lon: -74.0, expected: "N40W074"},
+		{lat: 0.5, lon: -0.5, expected: "N00W001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if name := TileName(tt.lat, tt.lon); name != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, name)
+			}
+		})
+	}
+}
+
+func TestHGTDirectoryElevation(t *testing.T) {
+	dir := t.TempDir()
+	// Ground rising 1 m per sample eastward from 1000 m, with a void in the north-west corner
+	writeTile(t, dir, "N45E006.hgt", func(row, col int) int16 {
+		if row < 10 && col < 10 {
+			return hgtVoid
+		}
+		return int16(1000 + col)
+	})
+
+	d, err := NewHGTDirectory(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Tiles() != 1 {
+		t.Errorf("expected 1 tile, got %d", d.Tiles())
+	}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		expected float64
+		ok       bool
+	}{
+		{name: "sample", lat: 45.5, lon: 6.5, expected: 1600, ok: true},
+		{name: "between samples", lat: 45.5, lon: 6.5 + 0.5/1200, expected: 1600.5, ok: true},
+		{name: "east edge", lat: 45.5, lon: 6.999999, expected: 2200, ok: true},
+		{name: "void", lat: 45.999, lon: 6.001, ok: false},
+		{name: "no tile", lat: 46.5, lon: 6.5, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elevation, ok := d.Elevation(tt.lat, tt.lon)
+			if ok != tt.ok {
+				t.Fatalf("expected ok %v, got %v", tt.ok, ok)
+			}
+			if ok && math.Abs(elevation-tt.expected) > 0.01 {
+				t.Errorf("expected %.2f m, got %.2f m", tt.expected, elevation)
+			}
+		})
+	}
+}
+
+func TestNewHGTDirectoryErrors(t *testing.T) {
+	empty := t.TempDir()
+	if _, err := NewHGTDirectory(empty); err == nil {
+		t.Errorf("expected an error for a directory without tiles")
+	}
+
+	invalid := t.TempDir()
+	if err := os.WriteFile(filepath.Join(invalid, "N45E006.hgt"), []byte("not a tile"), 0644); err != nil {
+		t.Fatalf("failed to write tile: %v", err)
+	}
+	if _, err := NewHGTDirectory(invalid); err == nil {
+		t.Errorf("expected an error for a tile of the wrong size")
+	}
+
+	if _, err := NewHGTDirectory(filepath.Join(empty, "missing")); err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+}
@@ -1,7 +1,15 @@
 package flags
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"igc-tool/internal/config"
+	"igc-tool/internal/flight"
+	"igc-tool/internal/inspect"
+	"igc-tool/internal/logbook"
+	"igc-tool/internal/scoring"
 	"igc-tool/internal/units"
 
 	"github.com/spf13/cobra"
@@ -16,16 +24,65 @@ type CommonFlags struct {
 // ParseFlags defines flags specific to the parse command
 type ParseFlags struct {
 	Summary bool
+	Fields  []string // fields to print instead of the full dump, nil for all
+	Output  string
+	Table   bool // list the fixes as an aligned table
+	Speeds  bool // add ground speed and vertical speed columns to the table
 }
 
 // LogbookFlags defines flags specific to the logbook command
 type LogbookFlags struct {
-	Format      string
-	Sites       string
-	SpeedWindow float64
-	SpeedUnit   string
-	ClimbUnit   string
-	Recursive   bool
+	Format          string
+	Sites           string
+	SpeedWindow     float64
+	LevelThreshold  float64
+	MinFixes        int
+	ClimbNoise      float64
+	SmoothWindow    time.Duration
+	SpeedUnit       string
+	ClimbUnit       string
+	DistanceUnit    string
+	CoordFormat     string
+	CoordPrecision  int
+	Recursive       bool
+	Delimiter       string
+	DecimalComma    bool
+	BOM             bool
+	Retries         int
+	RetryBackoff    time.Duration
+	StatsOnly       string
+	CollapseStalled bool
+	AltitudeSource  string
+	QNH             float64
+	NearSite        float64
+	TurnpointRadius float64
+	NoDetection     bool
+	Sort            string
+	Reverse         bool
+	Since           string
+	Until           string
+	MinDuration     time.Duration
+	Jobs            int
+	TemplateFile    string
+	FormatSet       bool // --format was given explicitly rather than taken from the config
+}
+
+// StatsFlags defines flags specific to the stats command
+type StatsFlags struct {
+	SpeedWindow     float64
+	LevelThreshold  float64
+	MinFixes        int
+	ClimbNoise      float64
+	SmoothWindow    time.Duration
+	SpeedUnit       string
+	ClimbUnit       string
+	DistanceUnit    string
+	JSON            bool
+	CollapseStalled bool
+	AltitudeSource  string
+	QNH             float64
+	DEM             string
+	Output          string
 }
 
 // VersionFlags defines flags specific to the version command
@@ -33,16 +90,100 @@ type VersionFlags struct {
 	Detailed bool
 }
 
+// ValidateFlags defines flags specific to the validate command
+type ValidateFlags struct {
+	Recursive bool
+	FailFast  bool
+}
+
+// InspectFlags defines flags specific to the inspect command
+type InspectFlags struct {
+	Field     string
+	Recursive bool
+}
+
+// AirspaceFlags defines flags specific to the airspace command
+type AirspaceFlags struct {
+	File         string
+	DistanceUnit string // unit of the horizontal penetration
+}
+
+// TaskFlags defines flags specific to the task command
+type TaskFlags struct {
+	DistanceUnit    string
+	CoordFormat     string
+	CoordPrecision  int
+	TurnpointRadius float64
+}
+
+// ScoreFlags defines flags specific to the score command
+type ScoreFlags struct {
+	Turnpoints     int
+	Triangles      bool
+	DistanceUnit   string
+	CoordFormat    string
+	CoordPrecision int
+}
+
+// ConfigFlags defines flags specific to the config command
+type ConfigFlags struct {
+	Output string
+}
+
+// ConfigInitFlags defines flags specific to the config init command
+type ConfigInitFlags struct {
+	Dir   string
+	Force bool
+}
+
 // RenderFlags defines flags specific to the render command
 type RenderFlags struct {
 	Pretty          bool
 	IncludeMetadata bool
 	Output          string
+	Force           bool
+	SmoothAltitude  time.Duration
+}
+
+// GeoJSONFlags defines flags specific to the geojson command
+type GeoJSONFlags struct {
+	SpeedWindow float64
+	Thermals    bool
+	Markers     bool
+	CoordTimes  bool
+}
+
+// KMLFlags defines flags specific to the kml command
+type KMLFlags struct {
+	Track        bool
+	ColorByClimb bool
+}
+
+// CSVFlags defines flags specific to the csv command
+type CSVFlags struct {
+	Output string
+	Speeds bool
+}
+
+// JSONFlags defines the formatting flags shared by all JSON-emitting commands
+type JSONFlags struct {
+	Indent string // indentation per nesting level, empty for compact output
+}
+
+// StrictFlags defines the flag for failing on bad input instead of skipping it
+type StrictFlags struct {
+	Strict bool
+}
+
+// AnonymizeFlags defines the flag for stripping personal data from outputs
+type AnonymizeFlags struct {
+	Level string // anonymization level, empty to keep all header fields
 }
 
 // GlobalFlags defines global flags
 type GlobalFlags struct {
 	Version bool
+	Verbose bool
 }
 
 // FlagConfig holds all flag configurations and provides unified flag resolution
@@ -100,26 +241,101 @@ func (r *FlagResolver) getFloat64(flagName string, configValue float64) float64
 	return configValue
 }
 
-// AddCommonFlags adds common flags to a command
+// getInt resolves an int flag with priority: explicit flag > default
+func (r *FlagResolver) getInt(flagName string, defaultValue int) int {
+	if flag := r.cmd.Flags().Lookup(flagName); flag != nil && flag.Changed {
+		if val, err := r.cmd.Flags().GetInt(flagName); err == nil {
+			return val
+		}
+	}
+	return defaultValue
+}
+
+// getDuration resolves a duration flag with priority: explicit flag > default
+func (r *FlagResolver) getDuration(flagName string, defaultValue time.Duration) time.Duration {
+	if flag := r.cmd.Flags().Lookup(flagName); flag != nil && flag.Changed {
+		if val, err := r.cmd.Flags().GetDuration(flagName); err == nil {
+			return val
+		}
+	}
+	return defaultValue
+}
+
+// changed reports whether a flag was explicitly set on the command line
+func (r *FlagResolver) changed(flagName string) bool {
+	flag := r.cmd.Flags().Lookup(flagName)
+	return flag != nil && flag.Changed
+}
+
 // AddCommonFlags adds common flags to a command
 func (fc *FlagConfig) AddCommonFlags(cmd *cobra.Command) {
-	cmd.Flags().StringP("altitude-unit", "a", fc.cfg.AltitudeUnit, "Unit for altitude display ("+units.AltitudeMeters+", "+units.AltitudeFeet+")")
+	cmd.Flags().StringP("altitude-unit", "a", fc.cfg.AltitudeUnit, "Unit for altitude display ("+units.AltitudeMeters+", "+units.AltitudeFeet+", "+units.AltitudeFL+" for flight levels)")
 	cmd.Flags().StringP("time-format", "t", fc.cfg.TimeFormat, "Time format ("+units.TimeFormat24h+", "+units.TimeFormatAMPM+")")
 }
 
 // AddParseFlags adds parse-specific flags to a command
 func (fc *FlagConfig) AddParseFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool("summary", false, "Show only headers and first/last fixes instead of all fixes")
+	cmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+	cmd.Flags().String("fields", "", "Comma-separated fields to print on one tab-separated line instead of the full dump (e.g. pilot,date,duration)")
+	cmd.Flags().Bool("table", false, "List the fixes as a table with aligned columns")
+	cmd.Flags().Bool("speeds", false, "Add instantaneous ground speed (km/h) and vertical speed (m/s) columns to the fix table (implies --table)")
 }
 
 // AddLogbookFlags adds logbook-specific flags to a command
 func (fc *FlagConfig) AddLogbookFlags(cmd *cobra.Command) {
-	cmd.Flags().StringP("format", "f", fc.cfg.LogbookFormat, "Go template string for formatting the output")
+	cmd.Flags().StringP("format", "f", fc.cfg.LogbookFormat, "Go template string for formatting the output, \"csv\" for spreadsheet export, \"json\" for machine-readable output, \"html\" for a styled HTML page, or \"json-schema\" for the schema of the logbook data")
+	cmd.Flags().String("template-file", "", "Read the Go template from a file instead of --format, or replace the built-in layout of --format html")
 	cmd.Flags().StringP("sites", "s", fc.cfg.SitesDatabaseFileLocation, "Path to GeoJSON file containing landing site definitions")
 	cmd.Flags().Float64P("speed-window", "w", fc.cfg.SpeedWindow, "Time window in seconds for ground speed calculations (larger values reduce GPS noise)")
 	cmd.Flags().StringP("speed-unit", "u", fc.cfg.SpeedUnit, "Unit for speed display ("+units.SpeedKmh+", "+units.SpeedMph+", "+units.SpeedKnots+", "+units.SpeedMs+")")
 	cmd.Flags().StringP("climb-unit", "c", fc.cfg.ClimbUnit, "Unit for climb rate display ("+units.ClimbMs+", "+units.ClimbFpm+")")
+	cmd.Flags().String("distance-unit", fc.cfg.DistanceUnit, "Unit for distances ("+units.DistanceKm+", "+units.DistanceMiles+" for statute miles, "+units.DistanceNauticalMiles+" for nautical miles)")
+	cmd.Flags().String("coord-format", fc.cfg.CoordFormat, "Format of positions and of sites outside the sites database ("+units.CoordFormatDecimal+" degrees, or "+units.CoordFormatDMS+" for degrees, minutes and seconds)")
+	cmd.Flags().Int("coord-precision", fc.cfg.CoordPrecision, "Decimal places, from 1, of positions in the "+units.CoordFormatDecimal+" coordinate format (3 is about 110 m, 5 about 1 m)")
 	cmd.Flags().BoolP("recursive", "r", false, "Recursively search for IGC files in directories")
+	cmd.Flags().Float64("level-threshold", fc.cfg.LevelThreshold, "Vertical speed in m/s below which flight counts as level rather than climbing or sinking")
+	cmd.Flags().Float64("climb-noise", fc.cfg.ClimbNoise, "Altitude change in meters treated as sensor noise for total climb and the vertical profile (about 1 for barometric, 3-5 for GPS altitude)")
+	cmd.Flags().Float64("smooth", fc.cfg.SmoothWindow, smoothUsage)
+	cmd.Flags().Int("min-fixes", fc.cfg.MinFixes, "Minimum number of fixes for reliable statistics; sparser flights are listed but marked as insufficient data")
+	cmd.Flags().String("delimiter", ",", "Field delimiter for --format csv (e.g. ';' or 'tab')")
+	cmd.Flags().Bool("decimal-comma", false, "Write decimals with a comma for --format csv (combine with --delimiter ';' to avoid ambiguity)")
+	cmd.Flags().Bool("bom", false, "Prepend a UTF-8 byte order mark to --format csv or html output for Excel on Windows")
+	cmd.Flags().String("stats-only", "", "Print only the aggregate numbers, as \"kv\" key=value lines or a flat \"json\" object")
+	cmd.Flags().Lookup("stats-only").NoOptDefVal = "kv"
+	cmd.Flags().String("alt-source", fc.cfg.AltitudeSource, "Altitude used for statistics ("+flight.AltSourceGPS+", or "+flight.AltSourceBaro+" for pressure altitude)")
+	cmd.Flags().Float64("qnh", 0, "QNH in hPa to convert pressure altitude to altitude above sea level with --alt-source baro (approximately 8.23 m per hPa from 1013.25)")
+	cmd.Flags().Float64("turnpoint-radius", fc.cfg.TurnpointRadius, turnpointRadiusUsage)
+	cmd.Flags().Float64("near-site", 0, "Name takeoffs and landings outside every site but within this many meters of one as \"near <site> (<distance>m)\" (0 disables)")
+	cmd.Flags().Bool("collapse-stalled", false, "Drop fixes repeating the previous position (stuck logger) before computing statistics")
+	cmd.Flags().String("since", "", "Only include flights on or after this date (YYYY-MM-DD)")
+	cmd.Flags().String("until", "", "Only include flights on or before this date (YYYY-MM-DD)")
+	cmd.Flags().Duration("min-duration", 0, "Skip flights shorter than this from takeoff to landing, e.g. 5m to drop ground handling and short hops")
+	cmd.Flags().String("sort", "", "Sort flights by "+logbook.SortDate+", "+logbook.SortDuration+", "+logbook.SortMaxAltitude+" or "+logbook.SortDistance+" (default: input order)")
+	cmd.Flags().Bool("reverse", false, "Reverse the sort order, e.g. longest flights first with --sort duration")
+	cmd.Flags().Bool("no-takeoff-detection", false, "Use the first and last fix as takeoff and landing instead of detecting when motion begins and ends")
+	cmd.Flags().Bool("progress", false, "Print a \"parsed N/total\" counter to stderr (default: on when stderr is a terminal)")
+	cmd.Flags().IntP("jobs", "j", 0, "Number of files parsed concurrently (0 uses one per CPU)")
+	cmd.Flags().Int("retries", 0, "Retry transient file read errors this many times (missing files, permission errors and invalid IGC data are never retried)")
+	cmd.Flags().Duration("retry-backoff", 500*time.Millisecond, "Delay before the first retry, doubled for each further retry")
+}
+
+// AddStatsFlags adds stats-specific flags to a command
+func (fc *FlagConfig) AddStatsFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64P("speed-window", "w", fc.cfg.SpeedWindow, "Time window in seconds for ground speed calculations (larger values reduce GPS noise)")
+	cmd.Flags().StringP("speed-unit", "u", fc.cfg.SpeedUnit, "Unit for speed display ("+units.SpeedKmh+", "+units.SpeedMph+", "+units.SpeedKnots+", "+units.SpeedMs+")")
+	cmd.Flags().StringP("climb-unit", "c", fc.cfg.ClimbUnit, "Unit for climb rate display ("+units.ClimbMs+", "+units.ClimbFpm+")")
+	cmd.Flags().String("distance-unit", fc.cfg.DistanceUnit, "Unit for distance display ("+units.DistanceKm+", "+units.DistanceMiles+" for statute miles, "+units.DistanceNauticalMiles+" for nautical miles)")
+	cmd.Flags().Float64("level-threshold", fc.cfg.LevelThreshold, "Vertical speed in m/s below which flight counts as level rather than climbing or sinking")
+	cmd.Flags().Float64("climb-noise", fc.cfg.ClimbNoise, "Altitude change in meters treated as sensor noise for total climb and the vertical profile (about 1 for barometric, 3-5 for GPS altitude)")
+	cmd.Flags().Float64("smooth", fc.cfg.SmoothWindow, smoothUsage)
+	cmd.Flags().Int("min-fixes", fc.cfg.MinFixes, "Minimum number of fixes for reliable statistics; sparser flights are reported as insufficient data")
+	cmd.Flags().Bool("json", false, "Output statistics as a JSON object")
+	cmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+	cmd.Flags().String("alt-source", fc.cfg.AltitudeSource, "Altitude used for statistics ("+flight.AltSourceGPS+", or "+flight.AltSourceBaro+" for pressure altitude)")
+	cmd.Flags().Float64("qnh", 0, "QNH in hPa to convert pressure altitude to altitude above sea level with --alt-source baro (approximately 8.23 m per hPa from 1013.25)")
+	cmd.Flags().String("dem", fc.cfg.DEMDirectory, "Directory of SRTM .hgt elevation tiles (e.g. N45E006.hgt) to report the lowest save as height above ground")
+	cmd.Flags().Bool("collapse-stalled", false, "Drop fixes repeating the previous position (stuck logger) before computing statistics")
 }
 
 // AddVersionFlags adds version-specific flags to a command
@@ -129,14 +345,120 @@ func (fc *FlagConfig) AddVersionFlags(cmd *cobra.Command) {
 
 // AddRenderFlags adds render-specific flags to a command
 func (fc *FlagConfig) AddRenderFlags(cmd *cobra.Command) {
-	cmd.Flags().BoolP("pretty", "p", false, "Pretty-print the GeoJSON output")
+	cmd.Flags().BoolP("pretty", "p", false, "Pretty-print the GeoJSON output (same as --indent 2)")
 	cmd.Flags().BoolP("include-metadata", "m", false, "Include flight metadata in GeoJSON properties")
 	cmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+	cmd.Flags().Bool("force", false, "Render even if the file declares a GPS datum other than WGS84")
+	cmd.Flags().Float64("smooth-altitude", 0, smoothAltitudeUsage)
+}
+
+// smoothUsage is the help text of the --smooth flag shared by the statistics commands
+const smoothUsage = "Moving-average window in seconds over altitudes before computing climb and descent rates, for vario-like rates instead of GPS noise spikes (0 disables)"
+
+// smoothAltitudeUsage is the help text of the --smooth-altitude flag shared by the track renderers
+const smoothAltitudeUsage = "Moving-average window in seconds to smooth track altitudes for cleaner 3D display (0 disables; statistics are unaffected)"
+
+// turnpointRadiusUsage is the help text of the --turnpoint-radius flag shared by the task and logbook commands
+const turnpointRadiusUsage = "Radius in meters of the turnpoint cylinders a fix must enter to reach a declared waypoint"
+
+// AddGeoJSONFlags adds geojson-specific flags to a command
+func (fc *FlagConfig) AddGeoJSONFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64P("speed-window", "w", fc.cfg.SpeedWindow, "Time window in seconds for the ground speed in --include-metadata statistics")
+	cmd.Flags().Bool("thermals", false, "Add a Point feature for each detected thermal, colored and sized by climb rate (outputs a FeatureCollection)")
+	cmd.Flags().Bool("markers", false, "Add takeoff and landing Point features with time and altitude (outputs a FeatureCollection)")
+	cmd.Flags().Bool("coord-times", false, "Add a coordTimes property with the RFC3339 time of each track coordinate")
+}
+
+// AddKMLFlags adds kml-specific flags to a command
+func (fc *FlagConfig) AddKMLFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("track", false, "Add a time-stamped gx:Track for animated playback in Google Earth")
+	cmd.Flags().Bool("color-by-climb", false, "Color the track by vertical speed, from blue (strong sink) to red (strong climb)")
+}
+
+// AddCSVFlags adds csv-specific flags to a command
+func (fc *FlagConfig) AddCSVFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+	cmd.Flags().Bool("speeds", false, "Add instantaneous ground speed (km/h), vertical speed (m/s) and heading (degrees) columns")
+}
+
+// AddInspectFlags adds inspect-specific flags to a command
+func (fc *FlagConfig) AddInspectFlags(cmd *cobra.Command) {
+	cmd.Flags().String("field", inspect.FieldPilot, "Header field to list ("+strings.Join(inspect.Fields, ", ")+")")
+	cmd.Flags().BoolP("recursive", "r", false, "Recursively search for IGC files in directories")
+}
+
+// AddValidateFlags adds validate-specific flags to a command
+func (fc *FlagConfig) AddValidateFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("recursive", "r", false, "Recursively search for IGC files in directories")
+	cmd.Flags().Bool("fail-fast", false, "Stop at the first file that fails validation instead of checking all files")
+}
+
+// AddAirspaceFlags adds airspace-specific flags to a command
+func (fc *FlagConfig) AddAirspaceFlags(cmd *cobra.Command) {
+	cmd.Flags().String("airspaces", "", "Path to GeoJSON file containing airspace polygons with floor and ceiling properties in meters")
+	cmd.MarkFlagRequired("airspaces")
+	cmd.Flags().String("distance-unit", fc.cfg.DistanceUnit, "Unit for horizontal penetration ("+units.DistanceKm+", "+units.DistanceMiles+" for statute miles, "+units.DistanceNauticalMiles+" for nautical miles)")
+}
+
+// AddTaskFlags adds task-specific flags to a command
+func (fc *FlagConfig) AddTaskFlags(cmd *cobra.Command) {
+	cmd.Flags().String("distance-unit", fc.cfg.DistanceUnit, "Unit for distance display ("+units.DistanceKm+", "+units.DistanceMiles+" for statute miles, "+units.DistanceNauticalMiles+" for nautical miles)")
+	cmd.Flags().String("coord-format", fc.cfg.CoordFormat, "Format of waypoint positions ("+units.CoordFormatDecimal+" degrees, or "+units.CoordFormatDMS+" for degrees, minutes and seconds)")
+	cmd.Flags().Int("coord-precision", fc.cfg.CoordPrecision, "Decimal places, from 1, of waypoint positions in the "+units.CoordFormatDecimal+" coordinate format (3 is about 110 m, 5 about 1 m)")
+	cmd.Flags().Float64("turnpoint-radius", fc.cfg.TurnpointRadius, turnpointRadiusUsage)
+}
+
+// AddScoreFlags adds score-specific flags to a command
+func (fc *FlagConfig) AddScoreFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("turnpoints", scoring.DefaultTurnpoints, fmt.Sprintf("Number of turnpoints between the start and finish, from 0 to %d", scoring.MaxTurnpoints))
+	cmd.Flags().Bool("triangles", false, "Also score the best closed FAI triangle and flat triangle")
+	cmd.Flags().String("distance-unit", fc.cfg.DistanceUnit, "Unit for distance display ("+units.DistanceKm+", "+units.DistanceMiles+" for statute miles, "+units.DistanceNauticalMiles+" for nautical miles)")
+	cmd.Flags().String("coord-format", fc.cfg.CoordFormat, "Format of turnpoint positions ("+units.CoordFormatDecimal+" degrees, or "+units.CoordFormatDMS+" for degrees, minutes and seconds)")
+	cmd.Flags().Int("coord-precision", fc.cfg.CoordPrecision, "Decimal places, from 1, of turnpoint positions in the "+units.CoordFormatDecimal+" coordinate format (3 is about 110 m, 5 about 1 m)")
+}
+
+// AddConfigFlags adds config flags to a command
+func (fc *FlagConfig) AddConfigFlags(cmd *cobra.Command) {
+	cmd.Flags().String("output", config.OutputTOML, "Output format: \"toml\" or \"json\"")
+}
+
+// AddConfigInitFlags adds config init flags to a command
+func (fc *FlagConfig) AddConfigInitFlags(cmd *cobra.Command) {
+	cmd.Flags().String("dir", "", "Directory to write "+config.FileName+" to (default: ~/.config/igc-tool)")
+	cmd.Flags().Bool("force", false, "Overwrite an existing config file")
+}
+
+// AddCZMLFlags adds czml-specific flags to a command
+func (fc *FlagConfig) AddCZMLFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+	cmd.Flags().Bool("force", false, "Render even if the file declares a GPS datum other than WGS84")
+	cmd.Flags().Float64("smooth-altitude", 0, smoothAltitudeUsage)
+}
+
+// AddJSONFlags adds the shared JSON formatting flags to a command
+func (fc *FlagConfig) AddJSONFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("indent", 2, "Number of spaces per indentation level for JSON output (JSON is indented on a terminal and compact when piped unless set)")
+	cmd.Flags().Bool("compact", false, "Emit compact single-line JSON, even on a terminal")
+}
+
+// AddAnonymizeFlags adds the anonymization flag to a command. A bare --anonymize
+// selects the basic level.
+func (fc *FlagConfig) AddAnonymizeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("anonymize", "", "Remove personal data from the output: \"basic\" (pilot, crew, glider ID, competition ID) or \"strict\" (also glider type and recorder details)")
+	cmd.Flags().Lookup("anonymize").NoOptDefVal = "basic"
+}
+
+// AddStrictFlags adds the flag turning skipped input, such as malformed lines or
+// invalid files, into an error
+func (fc *FlagConfig) AddStrictFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("strict", false, "Fail on malformed lines or invalid files instead of skipping them with a warning")
 }
 
 // AddGlobalFlags adds global flags to a command
 func (fc *FlagConfig) AddGlobalFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().BoolP("version", "v", false, "Show version information")
+	cmd.PersistentFlags().String("config", "", "Config file to use instead of searching for "+config.FileName)
+	cmd.PersistentFlags().Bool("verbose", false, "Log each file parsed, its fix count, missing headers and timing to stderr")
 }
 
 // GetCommonFromConfig retrieves common flag values, preferring runtime flag values over config defaults
@@ -148,11 +470,178 @@ func (fc *FlagConfig) GetCommonFromConfig(cmd *cobra.Command, cfg *config.Config
 	}
 }
 
+// unitFlag describes a unit flag, its config default and the values it accepts
+type unitFlag struct {
+	name   string
+	config func(cfg *config.Config) string
+	valid  func(unit string) bool
+	values []string
+}
+
+var unitFlags = []unitFlag{
+	{"altitude-unit", func(cfg *config.Config) string { return cfg.AltitudeUnit }, units.ValidateAltitudeUnit,
+		[]string{units.AltitudeMeters, units.AltitudeFeet, units.AltitudeFL}},
+	{"speed-unit", func(cfg *config.Config) string { return cfg.SpeedUnit }, units.ValidateSpeedUnit,
+		[]string{units.SpeedKmh, units.SpeedMph, units.SpeedKnots, units.SpeedMs}},
+	{"climb-unit", func(cfg *config.Config) string { return cfg.ClimbUnit }, units.ValidateClimbUnit,
+		[]string{units.ClimbMs, units.ClimbFpm}},
+	{"distance-unit", func(cfg *config.Config) string { return cfg.DistanceUnit }, units.ValidateDistanceUnit,
+		[]string{units.DistanceKm, units.DistanceMiles, units.DistanceNauticalMiles}},
+	{"time-format", func(cfg *config.Config) string { return cfg.TimeFormat }, units.ValidateTimeFormat,
+		[]string{units.TimeFormat24h, units.TimeFormatAMPM}},
+	{"coord-format", func(cfg *config.Config) string { return cfg.CoordFormat }, units.ValidateCoordFormat,
+		[]string{units.CoordFormatDecimal, units.CoordFormatDMS}},
+}
+
+// ValidateUnits checks the unit and time format flags defined on the command, with
+// values from the command line or the config file, and returns an error listing the
+// valid choices for the first unknown value
+func (fc *FlagConfig) ValidateUnits(cmd *cobra.Command) error {
+	resolver := fc.NewResolver(cmd)
+	for _, flag := range unitFlags {
+		if cmd.Flags().Lookup(flag.name) == nil {
+			continue
+		}
+		value := resolver.getString(flag.name, flag.config(fc.cfg))
+		if !flag.valid(value) {
+			return fmt.Errorf("invalid --%s %q: must be one of %s", flag.name, value, strings.Join(flag.values, ", "))
+		}
+	}
+	return nil
+}
+
+// ValidateSpeedWindow checks the --speed-window flag when the command defines it, with
+// the value from the command line or the config file. A window that is not positive
+// would disable the smoothing of GPS noise in ground speed calculations.
+func (fc *FlagConfig) ValidateSpeedWindow(cmd *cobra.Command) error {
+	if cmd.Flags().Lookup("speed-window") == nil {
+		return nil
+	}
+	window := fc.NewResolver(cmd).getFloat64("speed-window", fc.cfg.SpeedWindow)
+	if window <= 0 {
+		return fmt.Errorf("invalid --speed-window %g: must be a positive number of seconds", window)
+	}
+	return nil
+}
+
+// ValidateIndent checks the --indent flag when the command defines it, as a negative
+// number of spaces has no meaning
+func (fc *FlagConfig) ValidateIndent(cmd *cobra.Command) error {
+	if cmd.Flags().Lookup("indent") == nil {
+		return nil
+	}
+	indent := fc.NewResolver(cmd).getInt("indent", 2)
+	if indent < 0 {
+		return fmt.Errorf("invalid --indent %d: must not be negative", indent)
+	}
+	return nil
+}
+
 // GetParseFromFlags retrieves parse flag values from cobra command
 func (fc *FlagConfig) GetParseFromFlags(cmd *cobra.Command) ParseFlags {
 	resolver := fc.NewResolver(cmd)
 	return ParseFlags{
 		Summary: resolver.getBool("summary", false),
+		Fields:  splitList(resolver.getString("fields", "")),
+		Output:  resolver.getString("output", ""),
+		Table:   resolver.getBool("table", false) || resolver.getBool("speeds", false),
+		Speeds:  resolver.getBool("speeds", false),
+	}
+}
+
+// GetInspectFromFlags retrieves inspect flag values from cobra command
+func (fc *FlagConfig) GetInspectFromFlags(cmd *cobra.Command) InspectFlags {
+	resolver := fc.NewResolver(cmd)
+	return InspectFlags{
+		Field:     resolver.getString("field", inspect.FieldPilot),
+		Recursive: resolver.getBool("recursive", false),
+	}
+}
+
+// GetValidateFromFlags retrieves validate flag values from cobra command
+func (fc *FlagConfig) GetValidateFromFlags(cmd *cobra.Command) ValidateFlags {
+	resolver := fc.NewResolver(cmd)
+	return ValidateFlags{
+		Recursive: resolver.getBool("recursive", false),
+		FailFast:  resolver.getBool("fail-fast", false),
+	}
+}
+
+// GetAirspaceFromFlags retrieves airspace flag values from cobra command
+func (fc *FlagConfig) GetAirspaceFromFlags(cmd *cobra.Command) AirspaceFlags {
+	resolver := fc.NewResolver(cmd)
+	return AirspaceFlags{
+		File:         resolver.getString("airspaces", ""),
+		DistanceUnit: resolver.getString("distance-unit", fc.cfg.DistanceUnit),
+	}
+}
+
+// GetTaskFromConfig retrieves task flag values, preferring runtime flag values over config defaults
+func (fc *FlagConfig) GetTaskFromConfig(cmd *cobra.Command, cfg *config.Config) TaskFlags {
+	resolver := fc.NewResolver(cmd)
+	return TaskFlags{
+		DistanceUnit:    resolver.getString("distance-unit", cfg.DistanceUnit),
+		CoordFormat:     resolver.getString("coord-format", cfg.CoordFormat),
+		CoordPrecision:  resolver.getInt("coord-precision", cfg.CoordPrecision),
+		TurnpointRadius: resolver.getFloat64("turnpoint-radius", cfg.TurnpointRadius),
+	}
+}
+
+// GetScoreFromConfig retrieves score flag values, preferring runtime flag values over config defaults
+func (fc *FlagConfig) GetScoreFromConfig(cmd *cobra.Command, cfg *config.Config) ScoreFlags {
+	resolver := fc.NewResolver(cmd)
+	return ScoreFlags{
+		Turnpoints:     resolver.getInt("turnpoints", scoring.DefaultTurnpoints),
+		Triangles:      resolver.getBool("triangles", false),
+		DistanceUnit:   resolver.getString("distance-unit", cfg.DistanceUnit),
+		CoordFormat:    resolver.getString("coord-format", cfg.CoordFormat),
+		CoordPrecision: resolver.getInt("coord-precision", cfg.CoordPrecision),
+	}
+}
+
+// GetConfigFromFlags retrieves config flag values from cobra command
+func (fc *FlagConfig) GetConfigFromFlags(cmd *cobra.Command) ConfigFlags {
+	resolver := fc.NewResolver(cmd)
+	return ConfigFlags{
+		Output: resolver.getString("output", config.OutputTOML),
+	}
+}
+
+// GetConfigInitFromFlags retrieves config init flag values from cobra command
+func (fc *FlagConfig) GetConfigInitFromFlags(cmd *cobra.Command) ConfigInitFlags {
+	resolver := fc.NewResolver(cmd)
+	return ConfigInitFlags{
+		Dir:   resolver.getString("dir", ""),
+		Force: resolver.getBool("force", false),
+	}
+}
+
+// GetGeoJSONFromFlags retrieves geojson flag values from cobra command
+func (fc *FlagConfig) GetGeoJSONFromFlags(cmd *cobra.Command) GeoJSONFlags {
+	resolver := fc.NewResolver(cmd)
+	return GeoJSONFlags{
+		SpeedWindow: resolver.getFloat64("speed-window", fc.cfg.SpeedWindow),
+		Thermals:    resolver.getBool("thermals", false),
+		Markers:     resolver.getBool("markers", false),
+		CoordTimes:  resolver.getBool("coord-times", false),
+	}
+}
+
+// GetKMLFromFlags retrieves kml flag values from cobra command
+func (fc *FlagConfig) GetKMLFromFlags(cmd *cobra.Command) KMLFlags {
+	resolver := fc.NewResolver(cmd)
+	return KMLFlags{
+		Track:        resolver.getBool("track", false),
+		ColorByClimb: resolver.getBool("color-by-climb", false),
+	}
+}
+
+// GetCSVFromFlags retrieves csv flag values from cobra command
+func (fc *FlagConfig) GetCSVFromFlags(cmd *cobra.Command) CSVFlags {
+	resolver := fc.NewResolver(cmd)
+	return CSVFlags{
+		Output: resolver.getString("output", ""),
+		Speeds: resolver.getBool("speeds", false),
 	}
 }
 
@@ -163,19 +652,112 @@ func (fc *FlagConfig) GetRenderFromFlags(cmd *cobra.Command) RenderFlags {
 		Pretty:          resolver.getBool("pretty", false),
 		IncludeMetadata: resolver.getBool("include-metadata", false),
 		Output:          resolver.getString("output", ""),
+		Force:           resolver.getBool("force", false),
+		SmoothAltitude:  time.Duration(resolver.getFloat64("smooth-altitude", 0) * float64(time.Second)),
 	}
 }
 
+// GetAnonymizeFromFlags retrieves the anonymization level from cobra command
+func (fc *FlagConfig) GetAnonymizeFromFlags(cmd *cobra.Command) AnonymizeFlags {
+	resolver := fc.NewResolver(cmd)
+	return AnonymizeFlags{
+		Level: resolver.getString("anonymize", ""),
+	}
+}
+
+// GetStrictFromFlags retrieves the strict flag value
+func (fc *FlagConfig) GetStrictFromFlags(cmd *cobra.Command) StrictFlags {
+	resolver := fc.NewResolver(cmd)
+	return StrictFlags{
+		Strict: resolver.getBool("strict", false),
+	}
+}
+
+// GetJSONFromFlags resolves JSON formatting. --compact wins over --indent and --pretty;
+// without any of them the output is indented when interactive (e.g. stdout is a
+// terminal) and compact otherwise, so piped output stays machine-friendly.
+func (fc *FlagConfig) GetJSONFromFlags(cmd *cobra.Command, interactive bool) JSONFlags {
+	resolver := fc.NewResolver(cmd)
+	indent := strings.Repeat(" ", resolver.getInt("indent", 2))
+
+	switch {
+	case resolver.getBool("compact", false):
+		return JSONFlags{}
+	case resolver.changed("indent"), resolver.getBool("pretty", false), interactive:
+		return JSONFlags{Indent: indent}
+	default:
+		return JSONFlags{}
+	}
+}
+
+// GetProgressFromFlags reports whether to show a progress counter: as set by --progress,
+// or by default when interactive (stderr is a terminal)
+func (fc *FlagConfig) GetProgressFromFlags(cmd *cobra.Command, interactive bool) bool {
+	resolver := fc.NewResolver(cmd)
+	if resolver.changed("progress") {
+		return resolver.getBool("progress", false)
+	}
+	return interactive
+}
+
 // GetLogbookFromConfig retrieves logbook flag values, preferring runtime flag values over config defaults
 func (fc *FlagConfig) GetLogbookFromConfig(cmd *cobra.Command, cfg *config.Config) LogbookFlags {
 	resolver := fc.NewResolver(cmd)
 	return LogbookFlags{
-		Format:      resolver.getString("format", cfg.LogbookFormat),
-		Sites:       resolver.getString("sites", cfg.SitesDatabaseFileLocation),
-		SpeedWindow: resolver.getFloat64("speed-window", cfg.SpeedWindow),
-		SpeedUnit:   resolver.getString("speed-unit", cfg.SpeedUnit),
-		ClimbUnit:   resolver.getString("climb-unit", cfg.ClimbUnit),
-		Recursive:   resolver.getBool("recursive", false),
+		Format:          resolver.getString("format", cfg.LogbookFormat),
+		Sites:           resolver.getString("sites", cfg.SitesDatabaseFileLocation),
+		SpeedWindow:     resolver.getFloat64("speed-window", cfg.SpeedWindow),
+		LevelThreshold:  resolver.getFloat64("level-threshold", cfg.LevelThreshold),
+		MinFixes:        resolver.getInt("min-fixes", cfg.MinFixes),
+		ClimbNoise:      resolver.getFloat64("climb-noise", cfg.ClimbNoise),
+		SmoothWindow:    time.Duration(resolver.getFloat64("smooth", cfg.SmoothWindow) * float64(time.Second)),
+		SpeedUnit:       resolver.getString("speed-unit", cfg.SpeedUnit),
+		ClimbUnit:       resolver.getString("climb-unit", cfg.ClimbUnit),
+		DistanceUnit:    resolver.getString("distance-unit", cfg.DistanceUnit),
+		CoordFormat:     resolver.getString("coord-format", cfg.CoordFormat),
+		CoordPrecision:  resolver.getInt("coord-precision", cfg.CoordPrecision),
+		Recursive:       resolver.getBool("recursive", false),
+		Delimiter:       resolver.getString("delimiter", ","),
+		DecimalComma:    resolver.getBool("decimal-comma", false),
+		BOM:             resolver.getBool("bom", false),
+		Retries:         resolver.getInt("retries", 0),
+		RetryBackoff:    resolver.getDuration("retry-backoff", 500*time.Millisecond),
+		StatsOnly:       resolver.getString("stats-only", ""),
+		AltitudeSource:  resolver.getString("alt-source", cfg.AltitudeSource),
+		QNH:             resolver.getFloat64("qnh", 0),
+		NearSite:        resolver.getFloat64("near-site", 0),
+		TurnpointRadius: resolver.getFloat64("turnpoint-radius", cfg.TurnpointRadius),
+		CollapseStalled: resolver.getBool("collapse-stalled", false),
+		NoDetection:     resolver.getBool("no-takeoff-detection", false),
+		Sort:            resolver.getString("sort", ""),
+		Reverse:         resolver.getBool("reverse", false),
+		Since:           resolver.getString("since", ""),
+		Until:           resolver.getString("until", ""),
+		MinDuration:     resolver.getDuration("min-duration", 0),
+		Jobs:            resolver.getInt("jobs", 0),
+		TemplateFile:    resolver.getString("template-file", ""),
+		FormatSet:       resolver.changed("format"),
+	}
+}
+
+// GetStatsFromConfig retrieves stats flag values, preferring runtime flag values over config defaults
+func (fc *FlagConfig) GetStatsFromConfig(cmd *cobra.Command, cfg *config.Config) StatsFlags {
+	resolver := fc.NewResolver(cmd)
+	return StatsFlags{
+		SpeedWindow:     resolver.getFloat64("speed-window", cfg.SpeedWindow),
+		LevelThreshold:  resolver.getFloat64("level-threshold", cfg.LevelThreshold),
+		MinFixes:        resolver.getInt("min-fixes", cfg.MinFixes),
+		ClimbNoise:      resolver.getFloat64("climb-noise", cfg.ClimbNoise),
+		SmoothWindow:    time.Duration(resolver.getFloat64("smooth", cfg.SmoothWindow) * float64(time.Second)),
+		SpeedUnit:       resolver.getString("speed-unit", cfg.SpeedUnit),
+		ClimbUnit:       resolver.getString("climb-unit", cfg.ClimbUnit),
+		DistanceUnit:    resolver.getString("distance-unit", cfg.DistanceUnit),
+		JSON:            resolver.getBool("json", false),
+		AltitudeSource:  resolver.getString("alt-source", cfg.AltitudeSource),
+		QNH:             resolver.getFloat64("qnh", 0),
+		DEM:             resolver.getString("dem", cfg.DEMDirectory),
+		CollapseStalled: resolver.getBool("collapse-stalled", false),
+		Output:          resolver.getString("output", ""),
 	}
 }
 
@@ -192,6 +774,7 @@ func (fc *FlagConfig) GetGlobalFromFlags(cmd *cobra.Command) GlobalFlags {
 	resolver := fc.NewResolver(cmd)
 	return GlobalFlags{
 		Version: resolver.getBool("version", false),
+		Verbose: resolver.getBool("verbose", false),
 	}
 }
 
@@ -204,3 +787,14 @@ func (fc *FlagConfig) GetAllFlags(cmd *cobra.Command, cfg *config.Config) (Commo
 
 	return common, logbook, parse, version
 }
+
+// splitList splits a comma-separated flag value, trimming spaces and dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -230,3 +230,166 @@ func TestGetAllFlags(t *testing.T) {
 		t.Error("expected Detailed to be false by default")
 	}
 }
+
+func TestGetJSONFromFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		flags       map[string]string
+		interactive bool
+		expected    string
+	}{
+		{name: "piped defaults to compact", expected: ""},
+		{name: "terminal defaults to indented", interactive: true, expected: "  "},
+		{name: "explicit indent when piped", flags: map[string]string{"indent": "4"}, expected: "    "},
+		{name: "pretty when piped", flags: map[string]string{"pretty": "true"}, expected: "  "},
+		{name: "compact on terminal", flags: map[string]string{"compact": "true"}, interactive: true, expected: ""},
+		{name: "compact wins over indent", flags: map[string]string{"compact": "true", "indent": "4"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := NewFlagConfig(&config.Config{})
+			cmd := &cobra.Command{}
+			fc.AddRenderFlags(cmd)
+			fc.AddJSONFlags(cmd)
+
+			for name, value := range tt.flags {
+				if err := cmd.Flags().Set(name, value); err != nil {
+					t.Fatalf("failed to set flag %s: %v", name, err)
+				}
+			}
+
+			result := fc.GetJSONFromFlags(cmd, tt.interactive)
+			if result.Indent != tt.expected {
+				t.Errorf("expected indent %q, got %q", tt.expected, result.Indent)
+			}
+		})
+	}
+}
+
+func TestValidateUnits(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		args        []string
+		expectError string
+	}{
+		{name: "defaults", cfg: config.Config{AltitudeUnit: "m", TimeFormat: "24h", SpeedUnit: "kmh", ClimbUnit: "ms", DistanceUnit: "km"}},
+		{name: "valid flags", cfg: config.Config{AltitudeUnit: "m", TimeFormat: "24h", SpeedUnit: "kmh", ClimbUnit: "ms", DistanceUnit: "km"},
+			args: []string{"--speed-unit", "kts", "--altitude-unit", "ft", "--time-format", "ampm"}},
+		{name: "invalid speed flag", cfg: config.Config{AltitudeUnit: "m", TimeFormat: "24h", SpeedUnit: "kmh", ClimbUnit: "ms", DistanceUnit: "km"},
+			args: []string{"--speed-unit", "kmph"}, expectError: `invalid --speed-unit "kmph": must be one of kmh, mph, kts, ms`},
+		{name: "invalid config value", cfg: config.Config{AltitudeUnit: "meters", TimeFormat: "24h", SpeedUnit: "kmh", ClimbUnit: "ms", DistanceUnit: "km"},
+			expectError: `invalid --altitude-unit "meters": must be one of m, ft, fl`},
+		{name: "invalid climb flag", cfg: config.Config{AltitudeUnit: "m", TimeFormat: "24h", SpeedUnit: "kmh", ClimbUnit: "ms", DistanceUnit: "km"},
+			args: []string{"-c", "ftmin"}, expectError: `invalid --climb-unit "ftmin": must be one of ms, fpm`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := NewFlagConfig(&tt.cfg)
+			cmd := &cobra.Command{}
+			fc.AddCommonFlags(cmd)
+			fc.AddStatsFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+
+			err := fc.ValidateUnits(cmd)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectError {
+				t.Errorf("expected error %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+
+	// Commands without unit flags are not checked
+	fc := NewFlagConfig(&config.Config{AltitudeUnit: "meters"})
+	if err := fc.ValidateUnits(&cobra.Command{}); err != nil {
+		t.Errorf("unexpected error for command without unit flags: %v", err)
+	}
+}
+
+func TestValidateSpeedWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		args        []string
+		expectError string
+	}{
+		{name: "config default", cfg: config.Config{SpeedWindow: 5}},
+		{name: "valid flag", cfg: config.Config{SpeedWindow: 5}, args: []string{"--speed-window", "2.5"}},
+		{name: "zero flag", cfg: config.Config{SpeedWindow: 5}, args: []string{"-w", "0"},
+			expectError: "invalid --speed-window 0: must be a positive number of seconds"},
+		{name: "negative config value", cfg: config.Config{SpeedWindow: -3},
+			expectError: "invalid --speed-window -3: must be a positive number of seconds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := NewFlagConfig(&tt.cfg)
+			cmd := &cobra.Command{}
+			fc.AddGeoJSONFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+
+			err := fc.ValidateSpeedWindow(cmd)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectError {
+				t.Errorf("expected error %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+
+	// Commands without a speed window are not checked
+	fc := NewFlagConfig(&config.Config{SpeedWindow: 0})
+	if err := fc.ValidateSpeedWindow(&cobra.Command{}); err != nil {
+		t.Errorf("unexpected error for command without speed window: %v", err)
+	}
+}
+
+func TestValidateIndent(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expectError string
+	}{
+		{name: "default"},
+		{name: "compact indent", args: []string{"--indent", "0"}},
+		{name: "negative indent", args: []string{"--indent", "-1"},
+			expectError: "invalid --indent -1: must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := NewFlagConfig(&config.Config{})
+			cmd := &cobra.Command{}
+			fc.AddJSONFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+
+			err := fc.ValidateIndent(cmd)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectError {
+				t.Errorf("expected error %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package flight
 
 import (
+	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"time"
 
+	"igc-tool/internal/units"
+
+	"github.com/paulmach/orb"
 	"github.com/twpayne/go-igc"
 )
 
@@ -12,11 +18,50 @@ const (
 	EarthRadiusMeters  = 6371000 // Earth radius in meters
 	DegreesToRadians   = math.Pi / 180
 	MinTimeDiffSeconds = 1 // minimum time difference for speed calculations
+	MovingSpeedKmh     = 5 // ground speed above which the glider is considered moving
+	MinBearingDistance = 2 // minimum distance in meters between fixes for a meaningful bearing
+
+	// RolloverThreshold separates a wrap of the fix times past midnight UTC, a backward
+	// time-of-day jump of nearly a day, from a logger clock reset of a few seconds
+	RolloverThreshold = 12 * time.Hour
+
+	// Thermal detection parameters
+	ThermalWindowSeconds = 20               // window over which the climb rate is averaged
+	ThermalMinClimbRate  = 0.5              // minimum averaged climb rate in m/s
+	ThermalMinDuration   = 30 * time.Second // shorter climbs are treated as turbulence
+
+	// Circling detection parameters
+	CirclingWindowSeconds = 20               // window over which the heading change is averaged
+	CirclingMinTurnRate   = 8                // minimum averaged heading change in degrees per second, a full turn in 45 s
+	CirclingMinDuration   = 20 * time.Second // shorter turns are treated as course corrections
+
+	// MinAGLRecovery is the height in meters a low point must be climbed out of to count
+	// as a low save in MinAGL, which keeps the ground before takeoff and the final glide
+	// to landing out of it
+	MinAGLRecovery = 50.0
+
+	// Takeoff and landing detection parameters
+	TakeoffWindowSeconds = 30  // window over which ground and vertical speed must show motion
+	TakeoffSpeedKmh      = 15  // averaged ground speed above walking pace that counts as flying
+	TakeoffVerticalSpeed = 1.0 // averaged vertical speed in m/s that counts as flying, e.g. climbing in lift
+
+	// MinCenteringFixes is the number of fixes a thermal needs for a centering estimate
+	MinCenteringFixes = 10
+
+	// Vertical time breakdown parameters
+	VerticalWindowSeconds = 10  // window over which the vertical speed of a fix interval is averaged
+	DefaultLevelThreshold = 0.5 // vertical speed in m/s below which flight is considered level
+
+	// DefaultClimbNoise is the altitude change in meters below which movements are treated
+	// as sensor noise. It suits GPS altitude from typical recorders; barometric altitude
+	// is far less jittery and works with 1 m, while poor GPS receivers may need 5 m or more.
+	DefaultClimbNoise = 3.0
 )
 
 // Flight represents parsed IGC flight data
 type Flight struct {
 	Date               time.Time
+	FlightOfDay        int // flight number of the day from HFDTEDATE:DDMMYY,NN, 0 when absent
 	Pilot              string
 	Crew               string
 	GliderType         string
@@ -31,17 +76,406 @@ type Flight struct {
 	PressureAltSensor  string
 	AltGPSRef          string
 	AltPressureRef     string
+	Task               *Task     // declared task, nil when the file has no C records
+	Comments           []Comment // logger comments from the L records, in file order
 	Fixes              []*igc.BRecord
 }
 
+// Comment is a logger comment from an L record
+type Comment struct {
+	Source string // three-letter manufacturer or program code, empty when absent
+	Text   string
+}
+
+// Waypoint is a named position from a task declaration
+type Waypoint struct {
+	Name string
+	Lat  float64
+	Lon  float64
+}
+
+// Task is the task declared in the C records of an IGC file
+type Task struct {
+	DeclarationTime time.Time
+	Description     string
+	Takeoff         Waypoint
+	Start           Waypoint
+	Turnpoints      []Waypoint
+	Finish          Waypoint
+	Landing         Waypoint
+}
+
+// Route returns the waypoints flown in the task: the start, each turnpoint and the finish
+func (t *Task) Route() []Waypoint {
+	route := make([]Waypoint, 0, len(t.Turnpoints)+2)
+	route = append(route, t.Start)
+	route = append(route, t.Turnpoints...)
+	return append(route, t.Finish)
+}
+
+// Legs returns the length in meters of each leg of the route, the first leg ending at
+// the first turnpoint
+func (t *Task) Legs() []float64 {
+	route := t.Route()
+	legs := make([]float64, len(route)-1)
+	for i := 1; i < len(route); i++ {
+		legs[i-1] = HaversineDistance(route[i-1].Lat, route[i-1].Lon, route[i].Lat, route[i].Lon)
+	}
+	return legs
+}
+
+// Distance returns the declared task distance in meters, summing the legs from the
+// start through each turnpoint to the finish
+func (t *Task) Distance() float64 {
+	distance := 0.0
+	for _, leg := range t.Legs() {
+		distance += leg
+	}
+	return distance
+}
+
+// Thermal represents a continuous climbing segment of the flight
+type Thermal struct {
+	StartIndex   int
+	EndIndex     int
+	StartTime    time.Time
+	EndTime      time.Time
+	Gain         float64 // altitude gained in meters
+	AvgClimbRate float64 // average climb rate in m/s
+	Lat          float64 // centroid of the fixes in the thermal
+	Lon          float64
+}
+
+// Duration returns the time spent in the thermal
+func (t Thermal) Duration() time.Duration {
+	return t.EndTime.Sub(t.StartTime)
+}
+
+// FlightSegment is a continuous stretch of the flight spent either circling or gliding
+type FlightSegment struct {
+	Circling   bool
+	StartIndex int
+	EndIndex   int
+	StartTime  time.Time
+	EndTime    time.Time
+	Duration   time.Duration // time flown, excluding intervals across clock resets
+	Distance   float64       // track distance in meters
+}
+
+// ElevationProvider returns the ground elevation in meters above sea level at a
+// position, reporting false where it has no data
+type ElevationProvider interface {
+	Elevation(lat, lon float64) (float64, bool)
+}
+
 // Statistics holds calculated flight statistics
 type Statistics struct {
-	MaxAltitude    int
-	MinAltitude    int
-	MaxGroundSpeed float64
+	MaxAltitude int
+	MinAltitude int
+	// Pressure altitude extremes, 0 when the logger has no pressure sensor
+	MaxAltitudeBaro int
+	MinAltitudeBaro int
+	MaxGroundSpeed  float64
+	// 95th percentile of the windowed ground speeds, robust to GPS glitches
+	P95GroundSpeed float64
+	// Length of the track in meters, summed fix to fix
+	TrackDistance float64
+	// Straight-line distance from the first to the last fix in meters
+	OpenDistance float64
+	// Open distance divided by the altitude lost between the first and last fix, 0 without a net loss
+	GlideRatio float64
+	// Straight-line distance from the first to the last fix divided by the flight duration, in km/h
+	StraightLineSpeed float64
+	// Bearing in degrees from the first to the last fix, only meaningful when HasCourse
+	Course         float64
+	HasCourse      bool
 	MaxClimbRate   float64
 	MaxDescentRate float64
 	FlightDuration time.Duration
+	MovingTime     time.Duration
+	// Sharpest turn (heading change rate in degrees per second) and where it occurred
+	MaxTurnRate     float64
+	MaxTurnRateTime time.Time
+	MaxTurnRateLat  float64
+	MaxTurnRateLon  float64
+	// Largest altitude gain in a single thermal, and where/when it started
+	BiggestClimbGain float64
+	BiggestClimbTime time.Time
+	BiggestClimbLat  float64
+	BiggestClimbLon  float64
+	// Thermals with the steadiest and most irregular circles, nil without circling thermals
+	BestCentering  *ThermalCentering
+	WorstCentering *ThermalCentering
+	// Sum of all altitude gains, ignoring changes within the climb noise threshold
+	TotalClimb float64
+	// Time and track distance in meters spent circling and gliding, see CirclingSegments
+	CirclingTime     time.Duration
+	GlidingTime      time.Duration
+	CirclingDistance float64
+	GlidingDistance  float64
+	// Lowest height above ground of a low save and where it occurred, only set when
+	// HasMinAGL, which needs ground elevation data
+	MinAGL     float64
+	MinAGLTime time.Time
+	MinAGLLat  float64
+	MinAGLLon  float64
+	HasMinAGL  bool
+	// Airtime spent climbing, sinking and in level flight
+	ClimbTime time.Duration
+	SinkTime  time.Duration
+	LevelTime time.Duration
+}
+
+// StatsOptions holds the thresholds used when calculating flight statistics
+type StatsOptions struct {
+	SpeedWindow    float64 // time window in seconds for ground speed calculations
+	LevelThreshold float64 // vertical speed in m/s separating level flight from climb and sink
+	ClimbNoise     float64 // altitude change in meters treated as sensor noise, see DefaultClimbNoise
+	// SmoothWindow is the moving-average window applied to GPS altitudes before the
+	// climb and descent rates are calculated; zero uses the raw altitudes
+	SmoothWindow time.Duration
+	// Elevation provides the ground elevation for the height above ground, nil to skip it
+	Elevation ElevationProvider
+}
+
+// DefaultStatsOptions returns the thresholds used when none are configured
+func DefaultStatsOptions() StatsOptions {
+	return StatsOptions{
+		SpeedWindow:    5.0,
+		LevelThreshold: DefaultLevelThreshold,
+		ClimbNoise:     DefaultClimbNoise,
+	}
+}
+
+// VerticalTimePercentages returns the share of climbing, sinking and level time in percent
+func (s *Statistics) VerticalTimePercentages() (climb, sink, level float64) {
+	total := s.ClimbTime + s.SinkTime + s.LevelTime
+	if total <= 0 {
+		return 0, 0, 0
+	}
+	percent := func(d time.Duration) float64 {
+		return d.Seconds() / total.Seconds() * 100
+	}
+	return percent(s.ClimbTime), percent(s.SinkTime), percent(s.LevelTime)
+}
+
+// CirclingTimePercent returns the share of circling time in percent
+func (s *Statistics) CirclingTimePercent() float64 {
+	total := s.CirclingTime + s.GlidingTime
+	if total <= 0 {
+		return 0
+	}
+	return s.CirclingTime.Seconds() / total.Seconds() * 100
+}
+
+// CalculateTrackDistance returns the length of the track in meters, summed fix to fix
+func (f *Flight) CalculateTrackDistance() float64 {
+	var distance float64
+	for i := 1; i < len(f.Fixes); i++ {
+		prev, curr := f.Fixes[i-1], f.Fixes[i]
+		distance += HaversineDistance(prev.Lat, prev.Lon, curr.Lat, curr.Lon)
+	}
+	return distance
+}
+
+// CalculateOpenDistance returns the straight-line distance from the first to the last
+// fix in meters, or 0 without fixes. Unlike the track distance it stays small for
+// flights returning to launch.
+func (f *Flight) CalculateOpenDistance() float64 {
+	if len(f.Fixes) == 0 {
+		return 0
+	}
+	first, last := f.Fixes[0], f.Fixes[len(f.Fixes)-1]
+	return HaversineDistance(first.Lat, first.Lon, last.Lat, last.Lon)
+}
+
+// MinGlideAltitudeLoss is the altitude loss in meters below which no glide ratio is computed
+const MinGlideAltitudeLoss = 1.0
+
+// CalculateGlideRatio returns the overall glide ratio of the flight: the straight-line
+// distance from the first to the last fix divided by the altitude lost between them.
+// It is 0 without fixes, with a net altitude gain, or when less than
+// MinGlideAltitudeLoss was lost.
+func (f *Flight) CalculateGlideRatio() float64 {
+	if len(f.Fixes) == 0 {
+		return 0
+	}
+
+	altitudeLoss := f.Fixes[0].AltWGS84 - f.Fixes[len(f.Fixes)-1].AltWGS84
+	if altitudeLoss < MinGlideAltitudeLoss {
+		return 0
+	}
+
+	return f.CalculateOpenDistance() / altitudeLoss
+}
+
+// CalculateTaskDistance returns the declared task distance in meters, or 0 without a task
+func (f *Flight) CalculateTaskDistance() float64 {
+	if f.Task == nil {
+		return 0
+	}
+	return f.Task.Distance()
+}
+
+// DefaultTurnpointRadius is the radius in meters of the turnpoint cylinders used to
+// check task completion, the usual radius for declared cross-country flights
+const DefaultTurnpointRadius = 400.0
+
+// TurnpointPass records when the flight reached a waypoint of the task route
+type TurnpointPass struct {
+	Waypoint Waypoint
+	Reached  bool
+	Time     time.Time // time of the first fix inside the cylinder, zero when not reached
+}
+
+// TaskCompletion is the result of flying a declared task
+type TaskCompletion struct {
+	Turnpoints []TurnpointPass // the route: start, each turnpoint and finish
+	Reached    int             // number of route waypoints reached in order
+	Completed  bool            // every waypoint was reached, in order
+	// AchievedDistance is the task distance flown in meters: the completed legs plus the
+	// progress toward the next waypoint on the leg being flown
+	AchievedDistance float64
+	TaskDistance     float64 // declared task distance in meters
+	Percentage       float64 // achieved share of the task distance, 0 to 100
+}
+
+// CalculateTaskCompletion checks the fixes against the declared task, or returns nil
+// without a task. Waypoints are reached in route order by a fix within radius meters
+// of them; a radius of 0 or less uses DefaultTurnpointRadius. After the last waypoint
+// reached, progress on the next leg is the leg length less the closest approach to
+// its end.
+func (f *Flight) CalculateTaskCompletion(radius float64) *TaskCompletion {
+	if f.Task == nil {
+		return nil
+	}
+	if radius <= 0 {
+		radius = DefaultTurnpointRadius
+	}
+
+	route := f.Task.Route()
+	legs := f.Task.Legs()
+	completion := &TaskCompletion{
+		Turnpoints:   make([]TurnpointPass, len(route)),
+		TaskDistance: f.Task.Distance(),
+	}
+	for i, wp := range route {
+		completion.Turnpoints[i].Waypoint = wp
+	}
+
+	// Closest approach to the next waypoint since the previous one was reached
+	closest := math.Inf(1)
+	for _, fix := range f.Fixes {
+		if completion.Reached == len(route) {
+			break
+		}
+		next := route[completion.Reached]
+		distance := HaversineDistance(fix.Lat, fix.Lon, next.Lat, next.Lon)
+		if distance <= radius {
+			completion.Turnpoints[completion.Reached].Reached = true
+			completion.Turnpoints[completion.Reached].Time = fix.Time
+			completion.Reached++
+			closest = math.Inf(1)
+			continue
+		}
+		closest = math.Min(closest, distance)
+	}
+
+	for _, leg := range legs[:max(completion.Reached-1, 0)] {
+		completion.AchievedDistance += leg
+	}
+	completion.Completed = completion.Reached == len(route)
+	if completion.Reached > 0 && !completion.Completed {
+		leg := legs[completion.Reached-1]
+		completion.AchievedDistance += math.Max(0, math.Min(leg, leg-closest))
+	}
+
+	switch {
+	case completion.Completed:
+		completion.Percentage = 100
+	case completion.TaskDistance > 0:
+		completion.Percentage = completion.AchievedDistance / completion.TaskDistance * 100
+	}
+
+	return completion
+}
+
+// HasWGS84Datum reports whether the flight's coordinates can be assumed to use the WGS84
+// datum. All calculations in this tool assume WGS84, which is also what IGC files are
+// required to use, so a missing HFDTM header is treated as WGS84.
+func (f *Flight) HasWGS84Datum() bool {
+	if f.GPSDatum == "" {
+		return true
+	}
+
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '_' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(f.GPSDatum))
+
+	return normalized == "WGS84" || normalized == "WGS1984"
+}
+
+// AsMap returns the statistics keyed by stable snake_case names, with altitudes, speeds
+// and vertical speeds converted to the given units and durations in seconds. Heights
+// such as climb gains and radii are in feet when altitudes are flight levels.
+func (s *Statistics) AsMap(altitudeUnit, speedUnit, climbUnit string) map[string]interface{} {
+	climbPercent, sinkPercent, levelPercent := s.VerticalTimePercentages()
+	var course interface{}
+	if s.HasCourse {
+		course = s.Course
+	}
+	var minAGL interface{}
+	if s.HasMinAGL {
+		minAGL = units.Height(s.MinAGL, altitudeUnit)
+	}
+	return map[string]interface{}{
+		"max_altitude":            units.Altitude(float64(s.MaxAltitude), altitudeUnit),
+		"min_altitude":            units.Altitude(float64(s.MinAltitude), altitudeUnit),
+		"max_altitude_baro":       units.Altitude(float64(s.MaxAltitudeBaro), altitudeUnit),
+		"min_altitude_baro":       units.Altitude(float64(s.MinAltitudeBaro), altitudeUnit),
+		"max_ground_speed":        units.Speed(s.MaxGroundSpeed, speedUnit),
+		"p95_ground_speed":        units.Speed(s.P95GroundSpeed, speedUnit),
+		"straight_line_speed":     units.Speed(s.StraightLineSpeed, speedUnit),
+		"track_distance_km":       s.TrackDistance / 1000,
+		"open_distance_km":        s.OpenDistance / 1000,
+		"glide_ratio":             s.GlideRatio,
+		"course":                  course,
+		"max_climb_rate":          units.Climb(s.MaxClimbRate, climbUnit),
+		"max_descent_rate":        units.Climb(s.MaxDescentRate, climbUnit),
+		"flight_duration_seconds": s.FlightDuration.Seconds(),
+		"moving_time_seconds":     s.MovingTime.Seconds(),
+		"max_turn_rate":           s.MaxTurnRate,
+		"biggest_climb_gain":      units.Height(s.BiggestClimbGain, altitudeUnit),
+		"total_climb":             units.Height(s.TotalClimb, altitudeUnit),
+		"min_agl":                 minAGL,
+		"circling_time_percent":   s.CirclingTimePercent(),
+		"circling_time_seconds":   s.CirclingTime.Seconds(),
+		"gliding_time_seconds":    s.GlidingTime.Seconds(),
+		"circling_distance_km":    s.CirclingDistance / 1000,
+		"gliding_distance_km":     s.GlidingDistance / 1000,
+		"climb_time_percent":      climbPercent,
+		"sink_time_percent":       sinkPercent,
+		"level_time_percent":      levelPercent,
+		"best_centering":          centeringMap(s.BestCentering, altitudeUnit),
+		"worst_centering":         centeringMap(s.WorstCentering, altitudeUnit),
+	}
+}
+
+// centeringMap returns the stable map representation of a thermal centering, or nil
+func centeringMap(c *ThermalCentering, altitudeUnit string) map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"start_time":    c.Thermal.StartTime,
+		"avg_radius":    units.Height(c.AvgRadius, altitudeUnit),
+		"radius_stddev": units.Height(c.RadiusStdDev, altitudeUnit),
+		"drift_speed":   c.DriftSpeed,
+		"drift_bearing": c.DriftBearing,
+	}
 }
 
 // CalculateMaxAltitude finds the maximum GPS altitude in the flight
@@ -74,52 +508,175 @@ func (f *Flight) CalculateMinAltitude() int {
 	return minAlt
 }
 
-// CalculateMaxGroundSpeed finds the maximum ground speed in km/h during the flight
-func (f *Flight) CalculateMaxGroundSpeed(minTimeWindowSeconds float64) float64 {
-	if len(f.Fixes) < 2 {
+// CalculateMaxAltitudeBaro finds the maximum pressure altitude in the flight
+func (f *Flight) CalculateMaxAltitudeBaro() int {
+	if len(f.Fixes) == 0 {
 		return 0
 	}
 
+	maxAlt := int(f.Fixes[0].AltBarometric)
+	for _, fix := range f.Fixes {
+		if int(fix.AltBarometric) > maxAlt {
+			maxAlt = int(fix.AltBarometric)
+		}
+	}
+	return maxAlt
+}
+
+// CalculateMinAltitudeBaro finds the minimum pressure altitude in the flight
+func (f *Flight) CalculateMinAltitudeBaro() int {
+	if len(f.Fixes) == 0 {
+		return 0
+	}
+
+	minAlt := int(f.Fixes[0].AltBarometric)
+	for _, fix := range f.Fixes {
+		if int(fix.AltBarometric) < minAlt {
+			minAlt = int(fix.AltBarometric)
+		}
+	}
+	return minAlt
+}
+
+// CalculateBoundingBox returns the extent of the fixes, or zeros when there are none
+func (f *Flight) CalculateBoundingBox() (minLat, minLon, maxLat, maxLon float64) {
+	if len(f.Fixes) == 0 {
+		return 0, 0, 0, 0
+	}
+
+	minLat, minLon = f.Fixes[0].Lat, f.Fixes[0].Lon
+	maxLat, maxLon = minLat, minLon
+	for _, fix := range f.Fixes[1:] {
+		minLat = math.Min(minLat, fix.Lat)
+		minLon = math.Min(minLon, fix.Lon)
+		maxLat = math.Max(maxLat, fix.Lat)
+		maxLon = math.Max(maxLon, fix.Lon)
+	}
+	return minLat, minLon, maxLat, maxLon
+}
+
+// CalculateMaxGroundSpeed finds the maximum ground speed in km/h during the flight,
+// measured over a sliding window as described in GroundSpeeds. It is 0 when the
+// recording is shorter than the window.
+func (f *Flight) CalculateMaxGroundSpeed(minTimeWindowSeconds float64) float64 {
 	maxSpeed := 0.0
+	for _, speed := range f.GroundSpeeds(minTimeWindowSeconds) {
+		maxSpeed = math.Max(maxSpeed, speed)
+	}
+	return maxSpeed
+}
+
+// CalculateP95GroundSpeed returns the 95th percentile of the windowed ground speeds in
+// km/h, a maximum speed that a few GPS glitches cannot dominate. It is 0 when the
+// recording is shorter than the window.
+func (f *Flight) CalculateP95GroundSpeed(minTimeWindowSeconds float64) float64 {
+	speeds := f.GroundSpeeds(minTimeWindowSeconds)
+	sort.Float64s(speeds)
+	return percentile(speeds, 95)
+}
 
+// GroundSpeeds returns the ground speeds in km/h measured over a sliding window to reject
+// GPS noise: for each fix the speed is the straight-line distance from the closest earlier
+// fix at least minTimeWindowSeconds (and MinTimeDiffSeconds) back, divided by the time
+// between them. Fixes less than a window after the first one have no speed.
+func (f *Flight) GroundSpeeds(minTimeWindowSeconds float64) []float64 {
+	window := math.Max(minTimeWindowSeconds, MinTimeDiffSeconds)
+
+	var speeds []float64
+	start := 0
 	for i := 1; i < len(f.Fixes); i++ {
-		prev := f.Fixes[i-1]
 		curr := f.Fixes[i]
 
-		distance := HaversineDistance(prev.Lat, prev.Lon, curr.Lat, curr.Lon)
-		timeDiff := curr.Time.Sub(prev.Time).Seconds()
+		// Restart the window after a clock reset, whose distances cannot be timed
+		if curr.Time.Before(f.Fixes[i-1].Time) {
+			start = i
+			continue
+		}
 
-		if timeDiff < MinTimeDiffSeconds {
+		// Move the window start to the latest fix that is still far enough back
+		for start+1 < i && curr.Time.Sub(f.Fixes[start+1].Time).Seconds() >= window {
+			start++
+		}
+
+		prev := f.Fixes[start]
+		timeDiff := curr.Time.Sub(prev.Time).Seconds()
+		if timeDiff < window {
 			continue
 		}
 
-		speedMS := distance / timeDiff
-		speedKMH := speedMS * 3.6 // Convert m/s to km/h
+		distance := HaversineDistance(prev.Lat, prev.Lon, curr.Lat, curr.Lon)
+		speeds = append(speeds, distance/timeDiff*3.6) // Convert m/s to km/h
+	}
+	return speeds
+}
 
-		// Apply windowing for GPS noise reduction
-		if timeDiff < minTimeWindowSeconds && i >= 5 {
-			for j := i - 1; j >= 0; j-- {
-				prevWindow := f.Fixes[j]
-				windowTimeDiff := curr.Time.Sub(prevWindow.Time).Seconds()
+// percentile returns the p-th percentile (0-100) of sorted values by the nearest-rank
+// method, or 0 for no values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
 
-				if windowTimeDiff >= minTimeWindowSeconds {
-					windowDistance := HaversineDistance(prevWindow.Lat, prevWindow.Lon, curr.Lat, curr.Lon)
-					windowSpeedMS := windowDistance / windowTimeDiff
-					windowSpeedKMH := windowSpeedMS * 3.6
+// FixSpeeds returns the instantaneous ground speed in km/h and vertical speed in m/s
+// from prev to curr, reporting false when curr is not after prev
+func FixSpeeds(prev, curr *igc.BRecord) (groundSpeed, verticalSpeed float64, ok bool) {
+	seconds := curr.Time.Sub(prev.Time).Seconds()
+	if seconds <= 0 {
+		return 0, 0, false
+	}
+	distance := HaversineDistance(prev.Lat, prev.Lon, curr.Lat, curr.Lon)
+	return distance / seconds * 3.6, (curr.AltWGS84 - prev.AltWGS84) / seconds, true
+}
 
-					if windowSpeedKMH < speedKMH {
-						speedKMH = windowSpeedKMH
-					}
-					break
-				}
-			}
-		}
+// Course returns the initial great-circle bearing in degrees from the first to the
+// last fix, the overall direction of the flight. It reports false when they are less
+// than MinBearingDistance apart, as for a flight landing where it took off.
+func (f *Flight) Course() (float64, bool) {
+	if len(f.Fixes) < 2 {
+		return 0, false
+	}
+	first, last := f.Fixes[0], f.Fixes[len(f.Fixes)-1]
+	if HaversineDistance(first.Lat, first.Lon, last.Lat, last.Lon) < MinBearingDistance {
+		return 0, false
+	}
+	return Bearing(first.Lat, first.Lon, last.Lat, last.Lon), true
+}
 
-		if speedKMH > maxSpeed {
-			maxSpeed = speedKMH
+// Headings returns the heading in degrees of each fix, the bearing of the leg from the
+// previous fix. It is NaN for the first fix and for fixes less than MinBearingDistance
+// from the previous one, whose bearing is dominated by GPS noise.
+func (f *Flight) Headings() []float64 {
+	headings := make([]float64, len(f.Fixes))
+	for i, fix := range f.Fixes {
+		headings[i] = math.NaN()
+		if i == 0 {
+			continue
+		}
+		prev := f.Fixes[i-1]
+		if HaversineDistance(prev.Lat, prev.Lon, fix.Lat, fix.Lon) >= MinBearingDistance {
+			headings[i] = Bearing(prev.Lat, prev.Lon, fix.Lat, fix.Lon)
 		}
 	}
-	return maxSpeed
+	return headings
+}
+
+// StraightLineSpeed returns the straight-line distance from the first to the last fix
+// divided by the flight duration in km/h, i.e. how far the flight got per hour. It is
+// 0 when the duration is zero.
+func (f *Flight) StraightLineSpeed() float64 {
+	if len(f.Fixes) < 2 {
+		return 0
+	}
+
+	hours := f.Fixes[len(f.Fixes)-1].Time.Sub(f.Fixes[0].Time).Hours()
+	if hours <= 0 {
+		return 0
+	}
+
+	return f.CalculateOpenDistance() / 1000 / hours
 }
 
 // CalculateVerticalSpeeds finds the maximum and minimum vertical speeds in m/s
@@ -155,23 +712,946 @@ func (f *Flight) CalculateVerticalSpeeds() (float64, float64) {
 	return maxVerticalSpeed, minVerticalSpeed
 }
 
+// TimeInMotion sums the fix intervals where the ground speed exceeds minSpeed (km/h).
+// Unlike the first-to-last fix duration it excludes ground idle before launch, after
+// landing and during mid-flight stops such as top-landings. A higher threshold ignores
+// more GPS drift while stationary but may also drop slow soaring in strong headwinds.
+func (f *Flight) TimeInMotion(minSpeed float64) time.Duration {
+	var moving time.Duration
+
+	for i := 1; i < len(f.Fixes); i++ {
+		prev := f.Fixes[i-1]
+		curr := f.Fixes[i]
+
+		interval := curr.Time.Sub(prev.Time)
+		if interval <= 0 {
+			continue
+		}
+
+		distance := HaversineDistance(prev.Lat, prev.Lon, curr.Lat, curr.Lon)
+		speedKMH := distance / interval.Seconds() * 3.6
+
+		if speedKMH > minSpeed {
+			moving += interval
+		}
+	}
+	return moving
+}
+
+// DetectTakeoffLanding returns the indices of the first and last fix of actual flight,
+// skipping ground time recorded before launch and after landing. Flight is detected
+// where the ground speed averaged over TakeoffWindowSeconds exceeds TakeoffSpeedKmh or
+// the vertical speed exceeds TakeoffVerticalSpeed. Without detected motion, or with
+// fewer than two fixes, the first and last fix are returned. Takeoff is the last fix
+// before the first moving interval and landing the first fix after the last one.
+func DetectTakeoffLanding(f *Flight) (takeoffIdx, landingIdx int) {
+	takeoffIdx, landingIdx = 0, len(f.Fixes)-1
+	if len(f.Fixes) < 2 {
+		return takeoffIdx, landingIdx
+	}
+
+	takeoff := -1
+	for i, j := 0, 0; i < len(f.Fixes); i++ {
+		for j < len(f.Fixes) && f.Fixes[j].Time.Sub(f.Fixes[i].Time).Seconds() < TakeoffWindowSeconds {
+			j++
+		}
+		if j == len(f.Fixes) {
+			break
+		}
+		if inMotion(f.Fixes[i], f.Fixes[j]) {
+			// Launch at the start of the first moving interval within the window
+			takeoff = i
+			for k := i; k < j; k++ {
+				if inMotion(f.Fixes[k], f.Fixes[k+1]) {
+					takeoff = k
+					break
+				}
+			}
+			break
+		}
+	}
+	if takeoff < 0 {
+		return takeoffIdx, landingIdx
+	}
+
+	landing := landingIdx
+	for i, j := len(f.Fixes)-1, len(f.Fixes)-1; i > takeoff; i-- {
+		for j >= 0 && f.Fixes[i].Time.Sub(f.Fixes[j].Time).Seconds() < TakeoffWindowSeconds {
+			j--
+		}
+		if j < 0 {
+			break
+		}
+		if inMotion(f.Fixes[j], f.Fixes[i]) {
+			// Land at the end of the last moving interval within the window
+			landing = i
+			for k := i; k > j; k-- {
+				if inMotion(f.Fixes[k-1], f.Fixes[k]) {
+					landing = k
+					break
+				}
+			}
+			break
+		}
+	}
+
+	return takeoff, landing
+}
+
+// inMotion reports whether the averaged ground or vertical speed between two fixes
+// reaches the takeoff detection thresholds
+func inMotion(from, to *igc.BRecord) bool {
+	seconds := to.Time.Sub(from.Time).Seconds()
+	if seconds <= 0 {
+		return false
+	}
+	speedKMH := HaversineDistance(from.Lat, from.Lon, to.Lat, to.Lon) / seconds * 3.6
+	verticalSpeed := math.Abs(to.AltWGS84-from.AltWGS84) / seconds
+	return speedKMH > TakeoffSpeedKmh || verticalSpeed > TakeoffVerticalSpeed
+}
+
+// CalculateTotalClimb sums the altitude gained over the flight. A gain is only counted
+// once the altitude has risen more than climbNoise meters above the lowest point since
+// the last counted gain, so GPS jitter while gliding does not inflate the total. Too low
+// a threshold counts noise as climb; too high a threshold misses small real climbs.
+func (f *Flight) CalculateTotalClimb(climbNoise float64) float64 {
+	if len(f.Fixes) == 0 {
+		return 0
+	}
+
+	var total float64
+	low := f.Fixes[0].AltWGS84
+	for _, fix := range f.Fixes[1:] {
+		switch {
+		case fix.AltWGS84 < low:
+			low = fix.AltWGS84
+		case fix.AltWGS84-low > climbNoise:
+			total += fix.AltWGS84 - low
+			low = fix.AltWGS84
+		}
+	}
+	return total
+}
+
+// VerticalTimeBreakdown attributes each fix interval to climbing, sinking or level flight.
+// The vertical speed of an interval is averaged over the preceding VerticalWindowSeconds
+// (or since the first fix early in the flight) to smooth out barometer and GPS jitter;
+// intervals whose averaged vertical speed is within ±levelThreshold m/s, or whose altitude
+// change over the window is within ±climbNoise meters, count as level.
+func (f *Flight) VerticalTimeBreakdown(levelThreshold, climbNoise float64) (climb, sink, level time.Duration) {
+	windowStart := 0
+
+	for i := 1; i < len(f.Fixes); i++ {
+		curr := f.Fixes[i]
+
+		interval := curr.Time.Sub(f.Fixes[i-1].Time)
+		if interval < 0 {
+			windowStart = i // restart the window after a clock reset
+		}
+		if interval <= 0 {
+			continue
+		}
+
+		// Advance the window start while the window stays at least VerticalWindowSeconds long
+		for windowStart+1 < i && curr.Time.Sub(f.Fixes[windowStart+1].Time).Seconds() >= VerticalWindowSeconds {
+			windowStart++
+		}
+
+		timeDiff := curr.Time.Sub(f.Fixes[windowStart].Time).Seconds()
+		altitudeChange := curr.AltWGS84 - f.Fixes[windowStart].AltWGS84
+		verticalSpeed := altitudeChange / timeDiff
+
+		switch {
+		case math.Abs(altitudeChange) <= climbNoise:
+			level += interval
+		case verticalSpeed > levelThreshold:
+			climb += interval
+		case verticalSpeed < -levelThreshold:
+			sink += interval
+		default:
+			level += interval
+		}
+	}
+	return climb, sink, level
+}
+
+// MaxTurnRate finds the highest heading change rate in degrees per second and the index
+// of the fix where it occurred, or -1 when no turn could be measured. The rate at a fix
+// is the bearing change between its incoming and outgoing legs, wrapped to ±180°, over
+// the time between the leg midpoints. Legs shorter than MinBearingDistance are ignored
+// because the bearing of a near-stationary leg is dominated by GPS noise.
+func (f *Flight) MaxTurnRate() (float64, int) {
+	maxRate := 0.0
+	maxIndex := -1
+
+	for i := 1; i < len(f.Fixes)-1; i++ {
+		prev := f.Fixes[i-1]
+		curr := f.Fixes[i]
+		next := f.Fixes[i+1]
+
+		if HaversineDistance(prev.Lat, prev.Lon, curr.Lat, curr.Lon) < MinBearingDistance ||
+			HaversineDistance(curr.Lat, curr.Lon, next.Lat, next.Lon) < MinBearingDistance {
+			continue
+		}
+
+		timeDiff := next.Time.Sub(prev.Time).Seconds() / 2
+		if timeDiff < MinTimeDiffSeconds {
+			continue
+		}
+
+		inbound := Bearing(prev.Lat, prev.Lon, curr.Lat, curr.Lon)
+		outbound := Bearing(curr.Lat, curr.Lon, next.Lat, next.Lon)
+		rate := math.Abs(BearingDifference(inbound, outbound)) / timeDiff
+
+		if rate > maxRate {
+			maxRate = rate
+			maxIndex = i
+		}
+	}
+
+	return maxRate, maxIndex
+}
+
+// CirclingSegments splits the flight into circling and gliding segments. A fix interval
+// is circling when the net heading change over the preceding CirclingWindowSeconds
+// averages at least CirclingMinTurnRate; taking the net change, with left and right
+// turns cancelling out, keeps zig-zags and S-turns as gliding. Legs shorter than
+// MinBearingDistance keep the previous heading. Circling shorter than
+// CirclingMinDuration is merged into the surrounding glide.
+func (f *Flight) CirclingSegments() []FlightSegment {
+	if len(f.Fixes) < 2 {
+		return nil
+	}
+
+	// turned[i] is the net heading change in degrees from the first fix to fix i
+	turned := make([]float64, len(f.Fixes))
+	prevHeading := math.NaN()
+	for i, heading := range f.Headings() {
+		if i > 0 {
+			turned[i] = turned[i-1]
+		}
+		if math.IsNaN(heading) {
+			continue
+		}
+		if !math.IsNaN(prevHeading) {
+			turned[i] += BearingDifference(prevHeading, heading)
+		}
+		prevHeading = heading
+	}
+
+	var segments []FlightSegment
+	windowStart := 0
+	segmentStart := 0
+	circling := false
+	for i := 1; i < len(f.Fixes); i++ {
+		curr := f.Fixes[i]
+		if curr.Time.Before(f.Fixes[i-1].Time) {
+			windowStart = i // restart the window after a clock reset
+		}
+
+		// Advance the window start while the window stays at least CirclingWindowSeconds long
+		for windowStart+1 < i && curr.Time.Sub(f.Fixes[windowStart+1].Time).Seconds() >= CirclingWindowSeconds {
+			windowStart++
+		}
+
+		intervalCircling := false
+		if timeDiff := curr.Time.Sub(f.Fixes[windowStart].Time).Seconds(); timeDiff >= MinTimeDiffSeconds {
+			intervalCircling = math.Abs(turned[i]-turned[windowStart])/timeDiff >= CirclingMinTurnRate
+		}
+
+		if i > 1 && intervalCircling != circling {
+			segments = append(segments, f.newSegment(circling, segmentStart, i-1))
+			segmentStart = i - 1
+		}
+		circling = intervalCircling
+	}
+	segments = append(segments, f.newSegment(circling, segmentStart, len(f.Fixes)-1))
+
+	// Merge short circling into the glides around it
+	var merged []FlightSegment
+	for _, segment := range segments {
+		if segment.Circling && segment.Duration < CirclingMinDuration {
+			segment.Circling = false
+		}
+		if n := len(merged); n > 0 && merged[n-1].Circling == segment.Circling {
+			merged[n-1] = f.newSegment(segment.Circling, merged[n-1].StartIndex, segment.EndIndex)
+			continue
+		}
+		merged = append(merged, segment)
+	}
+	return merged
+}
+
+// newSegment builds a FlightSegment over the fixes between start and end inclusive
+func (f *Flight) newSegment(circling bool, start, end int) FlightSegment {
+	segment := FlightSegment{
+		Circling:   circling,
+		StartIndex: start,
+		EndIndex:   end,
+		StartTime:  f.Fixes[start].Time,
+		EndTime:    f.Fixes[end].Time,
+	}
+	for i := start + 1; i <= end; i++ {
+		prev, curr := f.Fixes[i-1], f.Fixes[i]
+		if interval := curr.Time.Sub(prev.Time); interval > 0 {
+			segment.Duration += interval
+		}
+		segment.Distance += HaversineDistance(prev.Lat, prev.Lon, curr.Lat, curr.Lon)
+	}
+	return segment
+}
+
+// CirclingBreakdown sums the time and the track distance in meters of the circling and
+// gliding segments of the flight
+func (f *Flight) CirclingBreakdown() (circlingTime, glidingTime time.Duration, circlingDistance, glidingDistance float64) {
+	for _, segment := range f.CirclingSegments() {
+		if segment.Circling {
+			circlingTime += segment.Duration
+			circlingDistance += segment.Distance
+		} else {
+			glidingTime += segment.Duration
+			glidingDistance += segment.Distance
+		}
+	}
+	return circlingTime, glidingTime, circlingDistance, glidingDistance
+}
+
+// MinAGL finds the lowest height above ground in meters from which the flight climbed
+// back by at least MinAGLRecovery, the lowest save, and the index of its fix, or -1
+// when no such fix has ground elevation data. Heights are the fix altitudes less the
+// ground elevation from p, so they are only as accurate as the altitude source and the
+// elevation data; GPS altitude on the WGS84 ellipsoid may differ from elevations above
+// sea level by tens of meters.
+func (f *Flight) MinAGL(p ElevationProvider) (float64, int) {
+	minAGL := 0.0
+	minIndex := -1
+
+	// Walk backward keeping the highest altitude reached after each fix
+	highestAfter := math.Inf(-1)
+	for i := len(f.Fixes) - 1; i >= 0; i-- {
+		fix := f.Fixes[i]
+		if highestAfter-fix.AltWGS84 >= MinAGLRecovery {
+			if ground, ok := p.Elevation(fix.Lat, fix.Lon); ok {
+				if agl := fix.AltWGS84 - ground; minIndex < 0 || agl < minAGL {
+					minAGL = agl
+					minIndex = i
+				}
+			}
+		}
+		highestAfter = math.Max(highestAfter, fix.AltWGS84)
+	}
+
+	return minAGL, minIndex
+}
+
+// DetectThermals finds the climbing segments of the flight. A fix is considered climbing
+// when the climb rate averaged over the preceding ThermalWindowSeconds is at least
+// ThermalMinClimbRate; consecutive climbing fixes (including the start of their window)
+// form a thermal, and thermals shorter than ThermalMinDuration are discarded.
+func (f *Flight) DetectThermals() []Thermal {
+	var thermals []Thermal
+
+	start, end := -1, -1
+	windowStart := 0
+
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		thermal := f.newThermal(start, end)
+		if thermal.Duration() >= ThermalMinDuration && thermal.Gain > 0 {
+			thermals = append(thermals, thermal)
+		}
+		start, end = -1, -1
+	}
+
+	for i := 1; i < len(f.Fixes); i++ {
+		curr := f.Fixes[i]
+
+		// Advance the window start while the window stays at least ThermalWindowSeconds long
+		for windowStart+1 < i && curr.Time.Sub(f.Fixes[windowStart+1].Time).Seconds() >= ThermalWindowSeconds {
+			windowStart++
+		}
+
+		timeDiff := curr.Time.Sub(f.Fixes[windowStart].Time).Seconds()
+		if timeDiff < ThermalWindowSeconds {
+			continue
+		}
+
+		climbRate := (curr.AltWGS84 - f.Fixes[windowStart].AltWGS84) / timeDiff
+		if climbRate < ThermalMinClimbRate {
+			flush()
+			continue
+		}
+
+		if start < 0 || windowStart > end {
+			flush()
+			start = windowStart
+		}
+		end = i
+	}
+	flush()
+
+	return thermals
+}
+
+// newThermal builds a Thermal from the climbing fixes between start and end inclusive,
+// trimmed to run from the lowest fix to the highest fix that follows it
+func (f *Flight) newThermal(start, end int) Thermal {
+	low := start
+	for i := start; i <= end; i++ {
+		if f.Fixes[i].AltWGS84 < f.Fixes[low].AltWGS84 {
+			low = i
+		}
+	}
+	high := low
+	for i := low; i <= end; i++ {
+		if f.Fixes[i].AltWGS84 > f.Fixes[high].AltWGS84 {
+			high = i
+		}
+	}
+	start, end = low, high
+
+	first := f.Fixes[start]
+	last := f.Fixes[end]
+
+	thermal := Thermal{
+		StartIndex: start,
+		EndIndex:   end,
+		StartTime:  first.Time,
+		EndTime:    last.Time,
+		Gain:       last.AltWGS84 - first.AltWGS84,
+	}
+
+	if seconds := thermal.Duration().Seconds(); seconds > 0 {
+		thermal.AvgClimbRate = thermal.Gain / seconds
+	}
+
+	for _, fix := range f.Fixes[start : end+1] {
+		thermal.Lat += fix.Lat
+		thermal.Lon += fix.Lon
+	}
+	count := float64(end - start + 1)
+	thermal.Lat /= count
+	thermal.Lon /= count
+
+	return thermal
+}
+
+// ThermalCentering describes how well the circles of a thermal were centered once the
+// wind drift is removed. A well-centered climb keeps a steady radius around the drifting
+// core, so a small RadiusStdDev relative to AvgRadius means good centering.
+type ThermalCentering struct {
+	Thermal      Thermal
+	AvgRadius    float64 // mean distance in meters from the drift-corrected circle center
+	RadiusStdDev float64 // standard deviation of that distance in meters
+	DriftSpeed   float64 // estimated wind drift of the thermal in m/s
+	DriftBearing float64 // direction the thermal drifted towards, in degrees
+}
+
+// Variation returns the radius standard deviation relative to the average radius, which
+// compares centering between tight and wide circles
+func (c ThermalCentering) Variation() float64 {
+	if c.AvgRadius == 0 {
+		return 0
+	}
+	return c.RadiusStdDev / c.AvgRadius
+}
+
+// ThermalCentering estimates how well-centered the circles of a thermal were. The fixes
+// are projected onto a local plane and the wind drift is estimated from the displacement
+// over the whole turns flown: after an exact number of full circles the glider is back at
+// the same point of its circle, so any displacement is drift. Subtracting the drift leaves
+// the circles around a fixed core, whose center is the mean position and whose radius is
+// measured per fix.
+//
+// This is an approximation: it assumes constant wind and a single core during the climb,
+// and sparse fixes cut corners on each circle, underestimating the radius and smoothing
+// out variance, while also making the end of the last full turn less precise. It returns
+// false with fewer than MinCenteringFixes fixes or when no full turn was flown.
+func (f *Flight) ThermalCentering(thermal Thermal) (ThermalCentering, bool) {
+	if thermal.StartIndex < 0 || thermal.EndIndex >= len(f.Fixes) || thermal.EndIndex-thermal.StartIndex+1 < MinCenteringFixes {
+		return ThermalCentering{}, false
+	}
+	fixes := f.Fixes[thermal.StartIndex : thermal.EndIndex+1]
+
+	// Find the last fix completing a whole number of turns since the first fix: the leg
+	// leaving it has the heading of the first leg again
+	turnsEnd, fullTurns := 0, 0
+	var turned float64
+	prevBearing := -1.0
+	for i := 1; i < len(fixes); i++ {
+		prev, curr := fixes[i-1], fixes[i]
+		if HaversineDistance(prev.Lat, prev.Lon, curr.Lat, curr.Lon) < MinBearingDistance {
+			continue
+		}
+		bearing := Bearing(prev.Lat, prev.Lon, curr.Lat, curr.Lon)
+		if prevBearing >= 0 {
+			turned += BearingDifference(prevBearing, bearing)
+			if turns := int(math.Abs(turned) / 360); turns > fullTurns {
+				fullTurns = turns
+				turnsEnd = i - 1
+			}
+		}
+		prevBearing = bearing
+	}
+	if turnsEnd == 0 {
+		return ThermalCentering{}, false
+	}
+	fixes = fixes[:turnsEnd+1]
+
+	// Project onto a local plane in meters, with time in seconds since the first fix
+	origin := fixes[0]
+	cosLat := math.Cos(origin.Lat * DegreesToRadians)
+	project := func(i int) (t, x, y float64) {
+		fix := fixes[i]
+		return fix.Time.Sub(origin.Time).Seconds(),
+			(fix.Lon - origin.Lon) * DegreesToRadians * EarthRadiusMeters * cosLat,
+			(fix.Lat - origin.Lat) * DegreesToRadians * EarthRadiusMeters
+	}
+
+	duration, endX, endY := project(len(fixes) - 1)
+	if duration <= 0 {
+		return ThermalCentering{}, false
+	}
+	driftX, driftY := endX/duration, endY/duration
+
+	// Drift-corrected positions circle around a fixed center; the last fix repeats the
+	// first point of the circle and is left out of the averages
+	n := float64(len(fixes) - 1)
+	xs := make([]float64, len(fixes)-1)
+	ys := make([]float64, len(fixes)-1)
+	var centerX, centerY float64
+	for i := range xs {
+		t, x, y := project(i)
+		xs[i] = x - driftX*t
+		ys[i] = y - driftY*t
+		centerX += xs[i] / n
+		centerY += ys[i] / n
+	}
+
+	radii := make([]float64, len(xs))
+	var avgRadius float64
+	for i := range xs {
+		radii[i] = math.Hypot(xs[i]-centerX, ys[i]-centerY)
+		avgRadius += radii[i] / n
+	}
+
+	var variance float64
+	for _, radius := range radii {
+		variance += (radius - avgRadius) * (radius - avgRadius) / n
+	}
+
+	return ThermalCentering{
+		Thermal:      thermal,
+		AvgRadius:    avgRadius,
+		RadiusStdDev: math.Sqrt(variance),
+		DriftSpeed:   math.Hypot(driftX, driftY),
+		DriftBearing: math.Mod(math.Atan2(driftX, driftY)/DegreesToRadians+360, 360),
+	}, true
+}
+
+// ThermalCenterings returns the centering estimate of every detected thermal with
+// enough fixes
+func (f *Flight) ThermalCenterings() []ThermalCentering {
+	var centerings []ThermalCentering
+	for _, thermal := range f.DetectThermals() {
+		if centering, ok := f.ThermalCentering(thermal); ok {
+			centerings = append(centerings, centering)
+		}
+	}
+	return centerings
+}
+
+// BestAndWorstCentered returns the thermals with the lowest and highest radius
+// variation, or false when there are none
+func BestAndWorstCentered(centerings []ThermalCentering) (best, worst ThermalCentering, ok bool) {
+	if len(centerings) == 0 {
+		return ThermalCentering{}, ThermalCentering{}, false
+	}
+	best, worst = centerings[0], centerings[0]
+	for _, centering := range centerings[1:] {
+		if centering.Variation() < best.Variation() {
+			best = centering
+		}
+		if centering.Variation() > worst.Variation() {
+			worst = centering
+		}
+	}
+	return best, worst, true
+}
+
+// BiggestClimb returns the largest altitude gain in meters achieved in a single thermal
+// together with that thermal, or 0 and an empty Thermal when no thermals are detected
+func (f *Flight) BiggestClimb() (float64, Thermal) {
+	var biggest Thermal
+	for _, thermal := range f.DetectThermals() {
+		if thermal.Gain > biggest.Gain {
+			biggest = thermal
+		}
+	}
+	return biggest.Gain, biggest
+}
+
 // GetStatistics calculates all flight statistics
-func (f *Flight) GetStatistics(speedWindow float64) *Statistics {
-	maxClimbRate, minVerticalSpeed := f.CalculateVerticalSpeeds()
+func (f *Flight) GetStatistics(opts StatsOptions) *Statistics {
+	// Rates come from the smoothed altitudes, heights from the raw ones
+	maxClimbRate, minVerticalSpeed := f.SmoothAltitude(opts.SmoothWindow).CalculateVerticalSpeeds()
+
+	// Sort the windowed ground speeds once for both the maximum and the percentile
+	groundSpeeds := f.GroundSpeeds(opts.SpeedWindow)
+	sort.Float64s(groundSpeeds)
+	maxGroundSpeed := 0.0
+	if len(groundSpeeds) > 0 {
+		maxGroundSpeed = groundSpeeds[len(groundSpeeds)-1]
+	}
+
+	course, hasCourse := f.Course()
+	stats := &Statistics{
+		MaxAltitude:       f.CalculateMaxAltitude(),
+		MinAltitude:       f.CalculateMinAltitude(),
+		MaxAltitudeBaro:   f.CalculateMaxAltitudeBaro(),
+		MinAltitudeBaro:   f.CalculateMinAltitudeBaro(),
+		MaxGroundSpeed:    maxGroundSpeed,
+		P95GroundSpeed:    percentile(groundSpeeds, 95),
+		TrackDistance:     f.CalculateTrackDistance(),
+		OpenDistance:      f.CalculateOpenDistance(),
+		GlideRatio:        f.CalculateGlideRatio(),
+		StraightLineSpeed: f.StraightLineSpeed(),
+		Course:            course,
+		HasCourse:         hasCourse,
+		MaxClimbRate:      maxClimbRate,
+		MaxDescentRate:    math.Abs(minVerticalSpeed),
+		FlightDuration:    f.Duration(),
+		MovingTime:        f.TimeInMotion(MovingSpeedKmh),
+	}
+
+	if best, worst, ok := BestAndWorstCentered(f.ThermalCenterings()); ok {
+		stats.BestCentering = &best
+		stats.WorstCentering = &worst
+	}
+
+	stats.TotalClimb = f.CalculateTotalClimb(opts.ClimbNoise)
+	stats.ClimbTime, stats.SinkTime, stats.LevelTime = f.VerticalTimeBreakdown(opts.LevelThreshold, opts.ClimbNoise)
+	stats.CirclingTime, stats.GlidingTime, stats.CirclingDistance, stats.GlidingDistance = f.CirclingBreakdown()
+
+	if rate, index := f.MaxTurnRate(); index >= 0 {
+		stats.MaxTurnRate = rate
+		stats.MaxTurnRateTime = f.Fixes[index].Time
+		stats.MaxTurnRateLat = f.Fixes[index].Lat
+		stats.MaxTurnRateLon = f.Fixes[index].Lon
+	}
+
+	if opts.Elevation != nil {
+		if agl, index := f.MinAGL(opts.Elevation); index >= 0 {
+			stats.MinAGL = agl
+			stats.MinAGLTime = f.Fixes[index].Time
+			stats.MinAGLLat = f.Fixes[index].Lat
+			stats.MinAGLLon = f.Fixes[index].Lon
+			stats.HasMinAGL = true
+		}
+	}
+
+	if gain, thermal := f.BiggestClimb(); gain > 0 {
+		stats.BiggestClimbGain = gain
+		stats.BiggestClimbTime = thermal.StartTime
+		stats.BiggestClimbLat = thermal.Lat
+		stats.BiggestClimbLon = thermal.Lon
+	}
+
+	return stats
+}
+
+// Anonymization levels accepted by Anonymize
+const (
+	AnonymizeNone   = ""       // keep all header fields
+	AnonymizeBasic  = "basic"  // remove pilot, crew, glider ID and competition ID
+	AnonymizeStrict = "strict" // additionally remove glider type and flight recorder details
+)
+
+// ValidateAnonymizeLevel checks that level is one of the known anonymization levels
+func ValidateAnonymizeLevel(level string) error {
+	switch level {
+	case AnonymizeNone, AnonymizeBasic, AnonymizeStrict:
+		return nil
+	default:
+		return fmt.Errorf("invalid anonymize level %q: must be %s or %s", level, AnonymizeBasic, AnonymizeStrict)
+	}
+}
+
+// Anonymize returns a copy of the flight with identifying header fields blanked so the
+// track can be shared publicly. Every output renders from the returned copy, so the
+// removed fields are absent from GeoJSON metadata, logbook entries and JSON alike.
+//
+//   - basic: Pilot, Crew, GliderID, CompetitionID and Comments, which some loggers
+//     use to repeat the pilot headers
+//   - strict: as basic, plus GliderType, FlightRecorderType, FirmwareVersion,
+//     HardwareVersion, GPSReceiver and PressureAltSensor, which together can
+//     fingerprint a pilot's equipment
+//
+// Fixes, the date and the task declaration are kept, as are file names. Unknown
+// levels are treated as basic; use ValidateAnonymizeLevel to reject them first.
+func (f *Flight) Anonymize(level string) *Flight {
+	anonymized := *f
+	if level == AnonymizeNone {
+		return &anonymized
+	}
+
+	anonymized.Pilot = ""
+	anonymized.Crew = ""
+	anonymized.GliderID = ""
+	anonymized.CompetitionID = ""
+	anonymized.Comments = nil
+
+	if level == AnonymizeStrict {
+		anonymized.GliderType = ""
+		anonymized.FlightRecorderType = ""
+		anonymized.FirmwareVersion = ""
+		anonymized.HardwareVersion = ""
+		anonymized.GPSReceiver = ""
+		anonymized.PressureAltSensor = ""
+	}
+
+	return &anonymized
+}
+
+// Altitude sources for statistics
+const (
+	AltSourceGPS  = "gps"  // GPS altitude above the WGS84 ellipsoid
+	AltSourceBaro = "baro" // pressure altitude, optionally corrected with the day's QNH
+)
+
+// StandardPressure is the ISA sea level pressure in hPa that pressure altitude is recorded against
+const StandardPressure = 1013.25
+
+// MetersPerHPa approximates the altitude change per hPa of pressure near sea level (about 27 ft)
+const MetersPerHPa = 8.23
+
+// ValidateAltitudeSource checks that source is one of the known altitude sources
+func ValidateAltitudeSource(source string) error {
+	switch source {
+	case AltSourceGPS, AltSourceBaro:
+		return nil
+	default:
+		return fmt.Errorf("invalid altitude source %q: must be %s or %s", source, AltSourceGPS, AltSourceBaro)
+	}
+}
+
+// QNHCorrection returns the offset in meters to add to a pressure altitude to get the
+// altitude above mean sea level for the given QNH in hPa, or 0 when qnh is 0 (not set)
+func QNHCorrection(qnh float64) float64 {
+	if qnh == 0 {
+		return 0
+	}
+	return (qnh - StandardPressure) * MetersPerHPa
+}
+
+// WithAltitudeSource returns a copy of the flight whose fixes carry the altitude of the
+// given source in AltWGS84, which every statistic reads. With AltSourceBaro the pressure
+// altitude is used, corrected by QNHCorrection(qnh); the correction is a linear
+// approximation applied uniformly to all fixes, and is also applied to AltBarometric.
+// The original flight is not modified.
+func (f *Flight) WithAltitudeSource(source string, qnh float64) *Flight {
+	converted := *f
+	if source != AltSourceBaro {
+		return &converted
+	}
+
+	correction := QNHCorrection(qnh)
+	converted.Fixes = make([]*igc.BRecord, len(f.Fixes))
+	for i, fix := range f.Fixes {
+		fixCopy := *fix
+		fixCopy.AltBarometric += correction
+		fixCopy.AltWGS84 = fixCopy.AltBarometric
+		converted.Fixes[i] = &fixCopy
+	}
+
+	return &converted
+}
+
+// SmoothAltitude returns a copy of the flight with each fix's GPS altitude replaced by
+// its SmoothedAltitudes value. Unlike outlier rejection it also flattens genuine short
+// altitude changes, so only rates should be computed from the smoothed flight; heights
+// such as the maximum altitude come from the original. A window of zero or less
+// returns an unsmoothed copy.
+func (f *Flight) SmoothAltitude(window time.Duration) *Flight {
+	smoothed := *f
+	if window <= 0 {
+		return &smoothed
+	}
+
+	altitudes := SmoothedAltitudes(f.Fixes, window)
+	smoothed.Fixes = make([]*igc.BRecord, len(f.Fixes))
+	for i, fix := range f.Fixes {
+		fixCopy := *fix
+		fixCopy.AltWGS84 = altitudes[i]
+		smoothed.Fixes[i] = &fixCopy
+	}
+
+	return &smoothed
+}
+
+// SmoothedAltitudes returns the moving average of the GPS altitudes of fixes, each the
+// mean of the fixes within window centered on it. Fixes must be in time order. A
+// window of zero or less returns the altitudes unchanged.
+func SmoothedAltitudes(fixes []*igc.BRecord, window time.Duration) []float64 {
+	altitudes := make([]float64, len(fixes))
+	if window <= 0 {
+		for i, fix := range fixes {
+			altitudes[i] = fix.AltWGS84
+		}
+		return altitudes
+	}
+
+	half := window / 2
+
+	// Sliding window [start, end) over fixes within half of the window on either side
+	start, end := 0, 0
+	sum := 0.0
+	for i, fix := range fixes {
+		for end < len(fixes) && fixes[end].Time.Sub(fix.Time) <= half {
+			sum += fixes[end].AltWGS84
+			end++
+		}
+		for fix.Time.Sub(fixes[start].Time) > half {
+			sum -= fixes[start].AltWGS84
+			start++
+		}
+		altitudes[i] = sum / float64(end-start)
+	}
 
-	var duration time.Duration
-	if len(f.Fixes) >= 2 {
-		duration = f.Fixes[len(f.Fixes)-1].Time.Sub(f.Fixes[0].Time)
+	return altitudes
+}
+
+// Duration returns the time from the first to the last fix, clamped to zero when the
+// logger clock ran backward so that the last fix is before the first
+func (f *Flight) Duration() time.Duration {
+	start, end := f.TimeRange()
+	return max(end.Sub(start), 0)
+}
+
+// ClockResets returns the number of fixes timed before the previous fix, after a logger
+// clock reset. Speed and vertical calculations skip the intervals that go backward.
+func (f *Flight) ClockResets() int {
+	resets := 0
+	for i := 1; i < len(f.Fixes); i++ {
+		if f.Fixes[i].Time.Before(f.Fixes[i-1].Time) {
+			resets++
+		}
+	}
+	return resets
+}
+
+// TimeWarnings describes the logger clock problems of the flight: backward jumps and a
+// last fix before the first, which clamps the duration to zero
+func (f *Flight) TimeWarnings() []string {
+	var warnings []string
+	if resets := f.ClockResets(); resets > 0 {
+		warnings = append(warnings, fmt.Sprintf("logger clock jumps backward %d times; those intervals are skipped", resets))
+	}
+	if start, end := f.TimeRange(); end.Before(start) {
+		warnings = append(warnings, fmt.Sprintf("last fix at %s is before the first at %s; duration clamped to zero",
+			end.Format("15:04:05"), start.Format("15:04:05")))
+	}
+	return warnings
+}
+
+// TimeRange returns the times of the first and last fix, or zero times without fixes
+func (f *Flight) TimeRange() (start, end time.Time) {
+	if len(f.Fixes) == 0 {
+		return time.Time{}, time.Time{}
+	}
+	return f.Fixes[0].Time, f.Fixes[len(f.Fixes)-1].Time
+}
+
+// OverlapsInTime reports whether both flights were in progress at a common moment
+func (f *Flight) OverlapsInTime(other *Flight) bool {
+	if len(f.Fixes) == 0 || len(other.Fixes) == 0 {
+		return false
+	}
+	start, end := f.TimeRange()
+	otherStart, otherEnd := other.TimeRange()
+	return !start.After(otherEnd) && !otherStart.After(end)
+}
+
+// StalledRun is a run of consecutive fixes repeating exactly the same position, as
+// logged by some recorders while the GPS has lost lock
+type StalledRun struct {
+	Start int // index of the first fix of the run, the last genuine position
+	End   int // index of the last repeated fix
+}
+
+// Repeats returns the number of fixes repeating the first fix of the run
+func (r StalledRun) Repeats() int {
+	return r.End - r.Start
+}
+
+// samePosition reports whether two fixes have identical latitude, longitude and GPS altitude
+func samePosition(a, b *igc.BRecord) bool {
+	return a.Lat == b.Lat && a.Lon == b.Lon && a.AltWGS84 == b.AltWGS84
+}
+
+// StalledRuns returns the runs of consecutive fixes with identical coordinates. Real
+// GPS positions jitter even when stationary, so exact repeats indicate a stuck logger.
+func (f *Flight) StalledRuns() []StalledRun {
+	var runs []StalledRun
+	for i := 1; i < len(f.Fixes); i++ {
+		if !samePosition(f.Fixes[i-1], f.Fixes[i]) {
+			continue
+		}
+		if len(runs) > 0 && runs[len(runs)-1].End == i-1 {
+			runs[len(runs)-1].End = i
+		} else {
+			runs = append(runs, StalledRun{Start: i - 1, End: i})
+		}
+	}
+	return runs
+}
+
+// CountStalledFixes returns the number of fixes repeating the coordinates of the fix before them
+func (f *Flight) CountStalledFixes() int {
+	count := 0
+	for _, run := range f.StalledRuns() {
+		count += run.Repeats()
+	}
+	return count
+}
+
+// CollapseStalled returns a copy of the flight keeping only the first fix of each stalled
+// run, so repeated positions do not inflate fix counts or add zero-speed segments
+func (f *Flight) CollapseStalled() *Flight {
+	collapsed := *f
+	collapsed.Fixes = make([]*igc.BRecord, 0, len(f.Fixes))
+	for i, fix := range f.Fixes {
+		if i > 0 && samePosition(f.Fixes[i-1], fix) {
+			continue
+		}
+		collapsed.Fixes = append(collapsed.Fixes, fix)
+	}
+	return &collapsed
+}
+
+// Reverse returns a copy of the flight with the fixes in reverse order. Fix times are
+// re-based so the reversed track starts at the original first fix time and durations
+// stay positive, which makes climbs appear as sinks and vice versa.
+func (f *Flight) Reverse() *Flight {
+	reversed := *f
+	reversed.Fixes = make([]*igc.BRecord, len(f.Fixes))
+
+	if len(f.Fixes) == 0 {
+		return &reversed
 	}
 
-	return &Statistics{
-		MaxAltitude:    f.CalculateMaxAltitude(),
-		MinAltitude:    f.CalculateMinAltitude(),
-		MaxGroundSpeed: f.CalculateMaxGroundSpeed(speedWindow),
-		MaxClimbRate:   maxClimbRate,
-		MaxDescentRate: math.Abs(minVerticalSpeed),
-		FlightDuration: duration,
+	start := f.Fixes[0].Time
+	end := f.Fixes[len(f.Fixes)-1].Time
+
+	for i, fix := range f.Fixes {
+		fixCopy := *fix
+		fixCopy.Time = start.Add(end.Sub(fix.Time))
+		reversed.Fixes[len(f.Fixes)-1-i] = &fixCopy
 	}
+
+	return &reversed
 }
 
 // HaversineDistance calculates the distance between two points in meters
@@ -189,3 +1669,59 @@ func HaversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 
 	return EarthRadiusMeters * c
 }
+
+// RingDistance returns the distance in meters from a point to the nearest edge of a ring
+// of longitude/latitude points, inside or outside it. The ring is projected onto a local
+// plane in meters around the point, which is accurate at landing field and airspace
+// penetration scales.
+func RingDistance(lat, lon float64, ring orb.Ring) float64 {
+	const metersPerDegree = EarthRadiusMeters * DegreesToRadians
+	cosLat := math.Cos(lat * DegreesToRadians)
+	project := func(p orb.Point) (float64, float64) {
+		return (p[0] - lon) * metersPerDegree * cosLat, (p[1] - lat) * metersPerDegree
+	}
+
+	nearest := math.Inf(1)
+	for i := 1; i < len(ring); i++ {
+		ax, ay := project(ring[i-1])
+		bx, by := project(ring[i])
+		nearest = math.Min(nearest, distanceToSegment(ax, ay, bx, by))
+	}
+	return nearest
+}
+
+// distanceToSegment returns the distance from the origin to the segment from a to b
+func distanceToSegment(ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if lengthSquared := dx*dx + dy*dy; lengthSquared > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSquared))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+// Bearing calculates the initial great-circle bearing from the first to the second
+// point in degrees, in the range [0, 360)
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * DegreesToRadians
+	lat2Rad := lat2 * DegreesToRadians
+	dlon := (lon2 - lon1) * DegreesToRadians
+
+	y := math.Sin(dlon) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(dlon)
+
+	bearing := math.Atan2(y, x) / DegreesToRadians
+	return math.Mod(bearing+360, 360)
+}
+
+// BearingDifference returns the signed change from one bearing to another in degrees,
+// wrapped to (-180, 180] so that turning from 350° to 10° is +20° rather than -340°
+func BearingDifference(from, to float64) float64 {
+	diff := math.Mod(to-from, 360)
+	if diff > 180 {
+		diff -= 360
+	} else if diff <= -180 {
+		diff += 360
+	}
+	return diff
+}
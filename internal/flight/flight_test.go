@@ -2,9 +2,11 @@ package flight
 
 import (
 	"math"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/paulmach/orb"
 	"github.com/twpayne/go-igc"
 )
 
@@ -73,6 +75,30 @@ func TestHaversineDistance(t *testing.T) {
 	}
 }
 
+func TestRingDistance(t *testing.T) {
+	// A square of about 1.1 km a side, one thousandth of a degree of latitude being 111 m
+	ring := orb.Ring{{6.0, 45.0}, {6.01, 45.0}, {6.01, 45.01}, {6.0, 45.01}, {6.0, 45.0}}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		expected float64
+	}{
+		{name: "inside near the south edge", lat: 45.001, lon: 6.005, expected: 111.2},
+		{name: "outside to the north", lat: 45.012, lon: 6.005, expected: 222.4},
+		{name: "outside past a corner", lat: 44.999, lon: 5.999, expected: 136.2},
+		{name: "on the boundary", lat: 45.0, lon: 6.005, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if distance := RingDistance(tt.lat, tt.lon, ring); math.Abs(distance-tt.expected) > 0.5 {
+				t.Errorf("expected %.1f m, got %.1f m", tt.expected, distance)
+			}
+		})
+	}
+}
+
 func TestFlightCalculateMaxAltitude(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -175,6 +201,233 @@ func TestFlightCalculateMinAltitude(t *testing.T) {
 	}
 }
 
+func TestFlightCalculateBaroAltitudes(t *testing.T) {
+	tests := []struct {
+		name        string
+		fixes       []*igc.BRecord
+		expectedMax int
+		expectedMin int
+	}{
+		{
+			name:        "empty fixes",
+			fixes:       []*igc.BRecord{},
+			expectedMax: 0,
+			expectedMin: 0,
+		},
+		{
+			name: "baro independent of GPS",
+			fixes: []*igc.BRecord{
+				{AltWGS84: 1500, AltBarometric: 1450},
+				{AltWGS84: 2100, AltBarometric: 1980},
+				{AltWGS84: 1200, AltBarometric: 1230},
+			},
+			expectedMax: 1980,
+			expectedMin: 1230,
+		},
+		{
+			name: "no pressure sensor",
+			fixes: []*igc.BRecord{
+				{AltWGS84: 1500},
+				{AltWGS84: 1800},
+			},
+			expectedMax: 0,
+			expectedMin: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flight := &Flight{Fixes: tt.fixes}
+			if result := flight.CalculateMaxAltitudeBaro(); result != tt.expectedMax {
+				t.Errorf("expected max %d, got %d", tt.expectedMax, result)
+			}
+			if result := flight.CalculateMinAltitudeBaro(); result != tt.expectedMin {
+				t.Errorf("expected min %d, got %d", tt.expectedMin, result)
+			}
+		})
+	}
+}
+
+func TestTaskLegs(t *testing.T) {
+	task := &Task{
+		Takeoff:    Waypoint{Name: "TAKEOFF"},
+		Start:      Waypoint{Name: "START", Lat: 45.0, Lon: 6.0},
+		Turnpoints: []Waypoint{{Name: "TP1", Lat: 45.1, Lon: 6.0}, {Name: "TP2", Lat: 45.1, Lon: 6.1}},
+		Finish:     Waypoint{Name: "FINISH", Lat: 45.0, Lon: 6.0},
+		Landing:    Waypoint{Name: "LANDING"},
+	}
+
+	route := task.Route()
+	if len(route) != 4 || route[0].Name != "START" || route[3].Name != "FINISH" {
+		t.Errorf("expected route START, TP1, TP2, FINISH, got %v", route)
+	}
+
+	legs := task.Legs()
+	if len(legs) != 3 {
+		t.Fatalf("expected 3 legs, got %d", len(legs))
+	}
+	total := 0.0
+	for i, leg := range legs {
+		expected := HaversineDistance(route[i].Lat, route[i].Lon, route[i+1].Lat, route[i+1].Lon)
+		if leg != expected {
+			t.Errorf("leg %d: expected %f, got %f", i, expected, leg)
+		}
+		total += leg
+	}
+	// The takeoff and landing are not part of the task distance
+	if task.Distance() != total {
+		t.Errorf("expected task distance %f, got %f", total, task.Distance())
+	}
+	if legs[0] < 11000 || legs[0] > 11200 {
+		t.Errorf("expected the first leg to be about 11.1 km, got %f", legs[0])
+	}
+
+	// A task without turnpoints is a single leg from start to finish
+	task.Turnpoints = nil
+	if legs := task.Legs(); len(legs) != 1 || legs[0] != 0 {
+		t.Errorf("expected a single empty leg, got %v", legs)
+	}
+}
+
+func TestFlightCalculateTaskCompletion(t *testing.T) {
+	task := &Task{
+		Start:      Waypoint{Name: "START", Lat: 45.0, Lon: 6.0},
+		Turnpoints: []Waypoint{{Name: "TP1", Lat: 45.1, Lon: 6.0}},
+		Finish:     Waypoint{Name: "FINISH", Lat: 45.1, Lon: 6.1},
+	}
+	legs := task.Legs()
+	baseTime := time.Date(2023, 7, 30, 11, 0, 0, 0, time.UTC)
+
+	// track flies through the points, one fix a minute
+	track := func(points ...[2]float64) []*igc.BRecord {
+		fixes := make([]*igc.BRecord, len(points))
+		for i, p := range points {
+			fixes[i] = &igc.BRecord{Time: baseTime.Add(time.Duration(i) * time.Minute), Lat: p[0], Lon: p[1]}
+		}
+		return fixes
+	}
+
+	tests := []struct {
+		name             string
+		fixes            []*igc.BRecord
+		radius           float64
+		expectedReached  int
+		expectedAchieved float64
+		expectedPercent  float64
+	}{
+		{
+			name:             "completed",
+			fixes:            track([2]float64{45.0, 6.0}, [2]float64{45.05, 6.0}, [2]float64{45.1, 6.0}, [2]float64{45.1, 6.1}),
+			expectedReached:  3,
+			expectedAchieved: legs[0] + legs[1],
+			expectedPercent:  100,
+		},
+		{
+			name:             "halfway to the turnpoint",
+			fixes:            track([2]float64{45.0, 6.0}, [2]float64{45.05, 6.0}, [2]float64{45.0, 6.0}),
+			expectedReached:  1,
+			expectedAchieved: legs[0] - HaversineDistance(45.05, 6.0, 45.1, 6.0),
+		},
+		{
+			name:            "turnpoint before the start",
+			fixes:           track([2]float64{45.1, 6.0}, [2]float64{45.1, 6.1}),
+			expectedReached: 0,
+		},
+		{
+			name:             "missed by less than a large radius",
+			fixes:            track([2]float64{45.0, 6.0}, [2]float64{45.095, 6.0}, [2]float64{45.1, 6.1}),
+			radius:           1000,
+			expectedReached:  3,
+			expectedAchieved: legs[0] + legs[1],
+			expectedPercent:  100,
+		},
+		{
+			name:             "missed with the default radius",
+			fixes:            track([2]float64{45.0, 6.0}, [2]float64{45.095, 6.0}, [2]float64{45.1, 6.1}),
+			expectedReached:  1,
+			expectedAchieved: legs[0] - HaversineDistance(45.095, 6.0, 45.1, 6.0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Task: task, Fixes: tt.fixes}
+			completion := f.CalculateTaskCompletion(tt.radius)
+
+			if completion.Reached != tt.expectedReached {
+				t.Errorf("expected %d waypoints reached, got %d", tt.expectedReached, completion.Reached)
+			}
+			if completion.Completed != (tt.expectedReached == 3) {
+				t.Errorf("expected completed %v, got %v", tt.expectedReached == 3, completion.Completed)
+			}
+			if math.Abs(completion.AchievedDistance-tt.expectedAchieved) > 1 {
+				t.Errorf("expected achieved distance %.0f, got %.0f", tt.expectedAchieved, completion.AchievedDistance)
+			}
+			expectedPercent := tt.expectedPercent
+			if expectedPercent == 0 {
+				expectedPercent = tt.expectedAchieved / task.Distance() * 100
+			}
+			if math.Abs(completion.Percentage-expectedPercent) > 0.01 {
+				t.Errorf("expected %.2f%%, got %.2f%%", expectedPercent, completion.Percentage)
+			}
+			for i, pass := range completion.Turnpoints {
+				if pass.Reached != (i < tt.expectedReached) {
+					t.Errorf("waypoint %d: expected reached %v, got %v", i, i < tt.expectedReached, pass.Reached)
+				}
+				if pass.Reached && pass.Time.IsZero() {
+					t.Errorf("waypoint %d: expected a pass time", i)
+				}
+			}
+		})
+	}
+
+	if (&Flight{}).CalculateTaskCompletion(0) != nil {
+		t.Errorf("expected no completion without a task")
+	}
+}
+
+func TestFlightCalculateBoundingBox(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixes    []*igc.BRecord
+		expected [4]float64 // minLat, minLon, maxLat, maxLon
+	}{
+		{name: "empty fixes", fixes: []*igc.BRecord{}},
+		{
+			name:     "single fix",
+			fixes:    []*igc.BRecord{{Lat: 45.814, Lon: 6.246}},
+			expected: [4]float64{45.814, 6.246, 45.814, 6.246},
+		},
+		{
+			name: "multiple fixes",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246},
+				{Lat: 45.900, Lon: 6.100},
+				{Lat: 45.700, Lon: 6.300},
+			},
+			expected: [4]float64{45.700, 6.100, 45.900, 6.300},
+		},
+		{
+			name: "southern and western hemispheres",
+			fixes: []*igc.BRecord{
+				{Lat: -33.9, Lon: -70.5},
+				{Lat: -33.5, Lon: -70.9},
+			},
+			expected: [4]float64{-33.9, -70.9, -33.5, -70.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flight := &Flight{Fixes: tt.fixes}
+			minLat, minLon, maxLat, maxLon := flight.CalculateBoundingBox()
+			if result := [4]float64{minLat, minLon, maxLat, maxLon}; result != tt.expected {
+				t.Errorf("expected bounding box %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestFlightCalculateMaxGroundSpeed(t *testing.T) {
 	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
 
@@ -246,6 +499,111 @@ func TestFlightCalculateMaxGroundSpeed(t *testing.T) {
 	}
 }
 
+func TestFlightCalculateMaxGroundSpeedNoise(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	const metersPerDegree = 111195.0
+
+	// Flying north at 10 m/s (36 km/h) with a fix every 500 ms, positions alternating
+	// every second between 5 m ahead of and 5 m behind the true track
+	jittery := &Flight{}
+	for i := 0; i <= 240; i++ {
+		jitter := 5.0
+		if (i/2)%2 == 1 {
+			jitter = -5.0
+		}
+		north := float64(i)*5 + jitter
+		jittery.Fixes = append(jittery.Fixes, &igc.BRecord{
+			Lat: 45.8 + north/metersPerDegree, Lon: 6.2, Time: baseTime.Add(time.Duration(i) * 500 * time.Millisecond),
+		})
+	}
+
+	tests := []struct {
+		name      string
+		window    float64
+		expected  float64
+		tolerance float64
+	}{
+		// Over 5 s the 10 m of jitter adds at most 2 m/s
+		{name: "5 second window", window: 5, expected: 36, tolerance: 7.5},
+		{name: "10 second window", window: 10, expected: 36, tolerance: 3.7},
+		// Without smoothing, fix to fix speeds over 1 s reach 20 m/s
+		{name: "1 second window", window: 1, expected: 72, tolerance: 1},
+		// Windows shorter than MinTimeDiffSeconds do not compare sub-second fixes
+		{name: "sub-second window", window: 0.5, expected: 72, tolerance: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := jittery.CalculateMaxGroundSpeed(tt.window)
+			if math.Abs(result-tt.expected) > tt.tolerance {
+				t.Errorf("expected speed %f ± %f km/h, got %f km/h", tt.expected, tt.tolerance, result)
+			}
+		})
+	}
+
+	// A recording shorter than the window has no speed
+	short := &Flight{Fixes: jittery.Fixes[:5]}
+	if speed := short.CalculateMaxGroundSpeed(5); speed != 0 {
+		t.Errorf("expected 0 km/h for a recording shorter than the window, got %f", speed)
+	}
+}
+
+func TestFlightCalculateP95GroundSpeed(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	const metersPerDegree = 111195.0
+
+	// Flying north at 10 m/s (36 km/h) for 5 minutes with one fix 500 m off track
+	f := &Flight{}
+	for i := 0; i <= 300; i++ {
+		north := float64(i) * 10
+		if i == 150 {
+			north += 500
+		}
+		f.Fixes = append(f.Fixes, &igc.BRecord{
+			Lat: 45.8 + north/metersPerDegree, Lon: 6.2, Time: baseTime.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	if maxSpeed := f.CalculateMaxGroundSpeed(5); maxSpeed < 300 {
+		t.Errorf("expected the glitch to dominate the max speed, got %f km/h", maxSpeed)
+	}
+	if p95 := f.CalculateP95GroundSpeed(5); math.Abs(p95-36) > 0.5 {
+		t.Errorf("expected 95th percentile speed of 36 km/h, got %f km/h", p95)
+	}
+
+	stats := f.GetStatistics(DefaultStatsOptions())
+	if stats.P95GroundSpeed != f.CalculateP95GroundSpeed(5) || stats.MaxGroundSpeed != f.CalculateMaxGroundSpeed(5) {
+		t.Errorf("statistics disagree with the ground speed methods: max %f, p95 %f", stats.MaxGroundSpeed, stats.P95GroundSpeed)
+	}
+
+	if p95 := (&Flight{}).CalculateP95GroundSpeed(5); p95 != 0 {
+		t.Errorf("expected 0 km/h without fixes, got %f", p95)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name     string
+		sorted   []float64
+		p        float64
+		expected float64
+	}{
+		{"empty", nil, 95, 0},
+		{"single value", []float64{7}, 95, 7},
+		{"nearest rank", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 95, 10},
+		{"median", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 50, 5},
+		{"zero percentile", []float64{3, 4}, 0, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := percentile(tt.sorted, tt.p); result != tt.expected {
+				t.Errorf("expected %f, got %f", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestFlightCalculateVerticalSpeeds(t *testing.T) {
 	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
 
@@ -338,7 +696,7 @@ func TestFlightGetStatistics(t *testing.T) {
 		},
 	}
 
-	stats := flight.GetStatistics(5.0)
+	stats := flight.GetStatistics(DefaultStatsOptions())
 
 	if stats == nil {
 		t.Fatal("expected non-nil statistics")
@@ -357,6 +715,10 @@ func TestFlightGetStatistics(t *testing.T) {
 		t.Errorf("expected flight duration 30s, got %v", stats.FlightDuration)
 	}
 
+	if stats.TrackDistance != flight.CalculateTrackDistance() || stats.TrackDistance < stats.OpenDistance {
+		t.Errorf("expected track distance %f of at least open distance %f, got %f", flight.CalculateTrackDistance(), stats.OpenDistance, stats.TrackDistance)
+	}
+
 	// Speed and vertical speeds should be positive
 	if stats.MaxGroundSpeed < 0 {
 		t.Errorf("expected positive ground speed, got %f", stats.MaxGroundSpeed)
@@ -392,8 +754,1471 @@ func TestFlightEmptyFixes(t *testing.T) {
 		t.Errorf("expected 0 vertical speeds for empty fixes, got climb=%f, descent=%f", maxClimb, maxDescent)
 	}
 
-	stats := flight.GetStatistics(5.0)
+	stats := flight.GetStatistics(DefaultStatsOptions())
 	if stats.FlightDuration != 0 {
 		t.Errorf("expected 0 duration for empty fixes, got %v", stats.FlightDuration)
 	}
 }
+
+func TestFlightTimeInMotion(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		fixes    []*igc.BRecord
+		minSpeed float64
+		expected time.Duration
+	}{
+		{
+			name:     "empty fixes",
+			fixes:    []*igc.BRecord{},
+			minSpeed: 5.0,
+			expected: 0,
+		},
+		{
+			name: "stationary on ground",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246, Time: baseTime},
+				{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(60 * time.Second)},
+				{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(120 * time.Second)},
+			},
+			minSpeed: 5.0,
+			expected: 0,
+		},
+		{
+			name: "ground idle then flight then top-landing",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246, Time: baseTime},
+				{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(60 * time.Second)},  // idle
+				{Lat: 45.815, Lon: 6.247, Time: baseTime.Add(70 * time.Second)},  // ~50 km/h
+				{Lat: 45.816, Lon: 6.248, Time: baseTime.Add(80 * time.Second)},  // ~50 km/h
+				{Lat: 45.816, Lon: 6.248, Time: baseTime.Add(200 * time.Second)}, // top-landed
+				{Lat: 45.817, Lon: 6.249, Time: baseTime.Add(210 * time.Second)}, // relaunch
+			},
+			minSpeed: 5.0,
+			expected: 30 * time.Second,
+		},
+		{
+			name: "threshold above flight speed",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246, Time: baseTime},
+				{Lat: 45.815, Lon: 6.247, Time: baseTime.Add(10 * time.Second)}, // ~50 km/h
+			},
+			minSpeed: 100.0,
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flight := &Flight{Fixes: tt.fixes}
+			result := flight.TimeInMotion(tt.minSpeed)
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDetectTakeoffLanding(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	// track builds fixes 10 seconds apart, moving 0.001 degrees of latitude (~40 km/h)
+	// at every fix where moving is true
+	track := func(moving ...bool) []*igc.BRecord {
+		fixes := make([]*igc.BRecord, len(moving))
+		lat := 45.814
+		for i, move := range moving {
+			if move {
+				lat += 0.001
+			}
+			fixes[i] = &igc.BRecord{Lat: lat, Lon: 6.246, AltWGS84: 1000, Time: baseTime.Add(time.Duration(i) * 10 * time.Second)}
+		}
+		return fixes
+	}
+
+	tests := []struct {
+		name            string
+		fixes           []*igc.BRecord
+		expectedTakeoff int
+		expectedLanding int
+	}{
+		{
+			name:            "empty fixes",
+			fixes:           []*igc.BRecord{},
+			expectedTakeoff: 0,
+			expectedLanding: -1,
+		},
+		{
+			name:            "ground time before and after",
+			fixes:           track(false, false, false, false, false, false, true, true, true, true, true, true, false, false, false, false, false, false),
+			expectedTakeoff: 5,
+			expectedLanding: 11,
+		},
+		{
+			name:            "no ground time",
+			fixes:           track(false, true, true, true, true, true),
+			expectedTakeoff: 0,
+			expectedLanding: 5,
+		},
+		{
+			name:            "never moving",
+			fixes:           track(false, false, false, false, false, false),
+			expectedTakeoff: 0,
+			expectedLanding: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeoff, landing := DetectTakeoffLanding(&Flight{Fixes: tt.fixes})
+			if takeoff != tt.expectedTakeoff || landing != tt.expectedLanding {
+				t.Errorf("expected takeoff %d and landing %d, got %d and %d", tt.expectedTakeoff, tt.expectedLanding, takeoff, landing)
+			}
+		})
+	}
+
+	t.Run("climbing in place", func(t *testing.T) {
+		fixes := track(false, false, false, false, false, false, false, false)
+		for i := 3; i < len(fixes); i++ {
+			fixes[i].AltWGS84 = 1000 + float64(i-2)*20 // 2 m/s
+		}
+		if takeoff, _ := DetectTakeoffLanding(&Flight{Fixes: fixes}); takeoff != 2 {
+			t.Errorf("expected takeoff 2, got %d", takeoff)
+		}
+	})
+}
+
+func TestFlightReverse(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	original := &Flight{
+		Pilot: "TestPilot",
+		Fixes: []*igc.BRecord{
+			{AltWGS84: 1500, Time: baseTime, Lat: 45.814, Lon: 6.246},
+			{AltWGS84: 1650, Time: baseTime.Add(10 * time.Second), Lat: 45.815, Lon: 6.247},
+			{AltWGS84: 1600, Time: baseTime.Add(15 * time.Second), Lat: 45.816, Lon: 6.248},
+			{AltWGS84: 1500, Time: baseTime.Add(20 * time.Second), Lat: 45.818, Lon: 6.249},
+		},
+	}
+
+	reversed := original.Reverse()
+
+	if reversed.Pilot != original.Pilot {
+		t.Errorf("expected pilot %s, got %s", original.Pilot, reversed.Pilot)
+	}
+	if len(reversed.Fixes) != len(original.Fixes) {
+		t.Fatalf("expected %d fixes, got %d", len(original.Fixes), len(reversed.Fixes))
+	}
+
+	// The original flight must not be modified
+	if !original.Fixes[0].Time.Equal(baseTime) || original.Fixes[0].AltWGS84 != 1500 {
+		t.Errorf("original flight was modified")
+	}
+
+	// Times stay increasing and the total duration is preserved
+	for i := 1; i < len(reversed.Fixes); i++ {
+		if !reversed.Fixes[i].Time.After(reversed.Fixes[i-1].Time) {
+			t.Errorf("expected increasing times, fix %d at %v is not after %v", i, reversed.Fixes[i].Time, reversed.Fixes[i-1].Time)
+		}
+	}
+	if !reversed.Fixes[0].Time.Equal(baseTime) {
+		t.Errorf("expected reversed flight to start at %v, got %v", baseTime, reversed.Fixes[0].Time)
+	}
+	if got := reversed.GetStatistics(DefaultStatsOptions()).FlightDuration; got != 20*time.Second {
+		t.Errorf("expected duration 20s, got %v", got)
+	}
+
+	// Distances are preserved
+	trackDistance := func(f *Flight) float64 {
+		total := 0.0
+		for i := 1; i < len(f.Fixes); i++ {
+			total += HaversineDistance(f.Fixes[i-1].Lat, f.Fixes[i-1].Lon, f.Fixes[i].Lat, f.Fixes[i].Lon)
+		}
+		return total
+	}
+	if math.Abs(trackDistance(original)-trackDistance(reversed)) > 0.001 {
+		t.Errorf("expected distance %f, got %f", trackDistance(original), trackDistance(reversed))
+	}
+
+	// Climb becomes sink
+	origClimb, origSink := original.CalculateVerticalSpeeds()
+	revClimb, revSink := reversed.CalculateVerticalSpeeds()
+	if math.Abs(revClimb-math.Abs(origSink)) > 0.001 {
+		t.Errorf("expected reversed max climb %f, got %f", math.Abs(origSink), revClimb)
+	}
+	if math.Abs(math.Abs(revSink)-origClimb) > 0.001 {
+		t.Errorf("expected reversed max descent %f, got %f", origClimb, math.Abs(revSink))
+	}
+
+	// Empty flights reverse to empty flights
+	if empty := (&Flight{}).Reverse(); len(empty.Fixes) != 0 {
+		t.Errorf("expected no fixes, got %d", len(empty.Fixes))
+	}
+}
+
+func TestBearing(t *testing.T) {
+	tests := []struct {
+		name       string
+		lat1, lon1 float64
+		lat2, lon2 float64
+		expected   float64
+		tolerance  float64
+	}{
+		{name: "north", lat1: 45.0, lon1: 6.0, lat2: 46.0, lon2: 6.0, expected: 0, tolerance: 0.1},
+		{name: "east", lat1: 0.0, lon1: 6.0, lat2: 0.0, lon2: 7.0, expected: 90, tolerance: 0.1},
+		{name: "south", lat1: 46.0, lon1: 6.0, lat2: 45.0, lon2: 6.0, expected: 180, tolerance: 0.1},
+		{name: "west", lat1: 0.0, lon1: 7.0, lat2: 0.0, lon2: 6.0, expected: 270, tolerance: 0.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Bearing(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(result-tt.expected) > tt.tolerance {
+				t.Errorf("expected bearing %f ± %f, got %f", tt.expected, tt.tolerance, result)
+			}
+		})
+	}
+}
+
+func TestBearingDifference(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to float64
+		expected float64
+	}{
+		{name: "no change", from: 90, to: 90, expected: 0},
+		{name: "right turn", from: 90, to: 120, expected: 30},
+		{name: "left turn", from: 120, to: 90, expected: -30},
+		{name: "wrap through north clockwise", from: 350, to: 10, expected: 20},
+		{name: "wrap through north counter-clockwise", from: 10, to: 350, expected: -20},
+		{name: "reversal", from: 0, to: 180, expected: 180},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := BearingDifference(tt.from, tt.to)
+			if math.Abs(result-tt.expected) > 0.0001 {
+				t.Errorf("expected %f, got %f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFixSpeeds(t *testing.T) {
+	baseTime := time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC)
+	prev := &igc.BRecord{Time: baseTime, Lat: 45.0, Lon: 6.0, AltWGS84: 1000}
+
+	tests := []struct {
+		name             string
+		curr             *igc.BRecord
+		expectedGround   float64
+		expectedVertical float64
+		ok               bool
+	}{
+		{
+			name:             "climbing north",
+			curr:             &igc.BRecord{Time: baseTime.Add(10 * time.Second), Lat: 45.001, Lon: 6.0, AltWGS84: 1020},
+			expectedGround:   111.195 / 10 * 3.6,
+			expectedVertical: 2,
+			ok:               true,
+		},
+		{
+			name:             "sinking in place",
+			curr:             &igc.BRecord{Time: baseTime.Add(4 * time.Second), Lat: 45.0, Lon: 6.0, AltWGS84: 990},
+			expectedVertical: -2.5,
+			ok:               true,
+		},
+		{
+			name: "same timestamp",
+			curr: &igc.BRecord{Time: baseTime, Lat: 45.001, Lon: 6.0, AltWGS84: 1000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ground, vertical, ok := FixSpeeds(prev, tt.curr)
+			if ok != tt.ok {
+				t.Fatalf("expected ok %v, got %v", tt.ok, ok)
+			}
+			if math.Abs(ground-tt.expectedGround) > 0.1 {
+				t.Errorf("expected ground speed %.2f km/h, got %.2f km/h", tt.expectedGround, ground)
+			}
+			if math.Abs(vertical-tt.expectedVertical) > 0.01 {
+				t.Errorf("expected vertical speed %.2f m/s, got %.2f m/s", tt.expectedVertical, vertical)
+			}
+		})
+	}
+}
+
+func TestFlightCourse(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		fixes     []*igc.BRecord
+		expected  float64
+		hasCourse bool
+	}{
+		{name: "no fixes", fixes: nil},
+		{
+			name: "north-east",
+			fixes: []*igc.BRecord{
+				{Lat: 0.0, Lon: 6.0, Time: baseTime},
+				{Lat: 0.5, Lon: 6.2, Time: baseTime.Add(time.Minute)},
+				{Lat: 1.0, Lon: 7.0, Time: baseTime.Add(2 * time.Minute)},
+			},
+			expected:  45,
+			hasCourse: true,
+		},
+		{
+			name: "back at takeoff",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246, Time: baseTime},
+				{Lat: 45.914, Lon: 6.246, Time: baseTime.Add(time.Minute)},
+				{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(2 * time.Minute)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Fixes: tt.fixes}
+			course, ok := f.Course()
+			if ok != tt.hasCourse {
+				t.Fatalf("expected hasCourse %v, got %v", tt.hasCourse, ok)
+			}
+			if ok && math.Abs(course-tt.expected) > 0.1 {
+				t.Errorf("expected course %.1f, got %.1f", tt.expected, course)
+			}
+		})
+	}
+}
+
+func TestFlightHeadings(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	f := &Flight{Fixes: []*igc.BRecord{
+		{Lat: 0.0, Lon: 6.0, Time: baseTime},
+		{Lat: 0.001, Lon: 6.0, Time: baseTime.Add(10 * time.Second)},
+		{Lat: 0.001, Lon: 6.0, Time: baseTime.Add(20 * time.Second)},
+		{Lat: 0.001, Lon: 6.001, Time: baseTime.Add(30 * time.Second)},
+		{Lat: 0.0, Lon: 6.001, Time: baseTime.Add(40 * time.Second)},
+	}}
+	expected := []float64{math.NaN(), 0, math.NaN(), 90, 180}
+
+	headings := f.Headings()
+	if len(headings) != len(expected) {
+		t.Fatalf("expected %d headings, got %d", len(expected), len(headings))
+	}
+	for i, heading := range headings {
+		if math.IsNaN(expected[i]) {
+			if !math.IsNaN(heading) {
+				t.Errorf("fix %d: expected no heading, got %.1f", i, heading)
+			}
+			continue
+		}
+		if math.Abs(heading-expected[i]) > 0.1 {
+			t.Errorf("fix %d: expected heading %.1f, got %.1f", i, expected[i], heading)
+		}
+	}
+}
+
+func TestFlightMaxTurnRate(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		fixes         []*igc.BRecord
+		expectedRate  float64
+		expectedIndex int
+		tolerance     float64
+	}{
+		{
+			name:          "empty fixes",
+			fixes:         []*igc.BRecord{},
+			expectedRate:  0,
+			expectedIndex: -1,
+		},
+		{
+			name: "straight line",
+			fixes: []*igc.BRecord{
+				{Lat: 45.000, Lon: 6.0, Time: baseTime},
+				{Lat: 45.001, Lon: 6.0, Time: baseTime.Add(10 * time.Second)},
+				{Lat: 45.002, Lon: 6.0, Time: baseTime.Add(20 * time.Second)},
+			},
+			expectedRate:  0,
+			expectedIndex: -1,
+			tolerance:     0.01,
+		},
+		{
+			name: "right angle turn across north",
+			fixes: []*igc.BRecord{
+				{Lat: 45.000, Lon: 6.001, Time: baseTime},                       // heading west
+				{Lat: 45.000, Lon: 6.000, Time: baseTime.Add(10 * time.Second)}, // turn to north
+				{Lat: 45.001, Lon: 6.000, Time: baseTime.Add(20 * time.Second)},
+				{Lat: 45.002, Lon: 6.000, Time: baseTime.Add(30 * time.Second)},
+			},
+			expectedRate:  9.0, // 90° over 10s
+			expectedIndex: 1,
+			tolerance:     0.1,
+		},
+		{
+			name: "stationary jitter ignored",
+			fixes: []*igc.BRecord{
+				{Lat: 45.0, Lon: 6.0, Time: baseTime},
+				{Lat: 45.000001, Lon: 6.0, Time: baseTime.Add(1 * time.Second)},
+				{Lat: 45.0, Lon: 6.000001, Time: baseTime.Add(2 * time.Second)},
+			},
+			expectedRate:  0,
+			expectedIndex: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flight := &Flight{Fixes: tt.fixes}
+			rate, index := flight.MaxTurnRate()
+			if tt.expectedIndex >= 0 && index != tt.expectedIndex {
+				t.Errorf("expected index %d, got %d", tt.expectedIndex, index)
+			}
+			if tt.expectedIndex < 0 && rate > tt.tolerance {
+				t.Errorf("expected no measurable turn, got %f at index %d", rate, index)
+			}
+			if math.Abs(rate-tt.expectedRate) > tt.tolerance {
+				t.Errorf("expected rate %f ± %f, got %f", tt.expectedRate, tt.tolerance, rate)
+			}
+		})
+	}
+}
+
+func TestStatisticsAsMap(t *testing.T) {
+	stats := &Statistics{
+		MaxAltitude:    1000,
+		MinAltitude:    500,
+		MaxGroundSpeed: 100,
+		MaxClimbRate:   2,
+		MaxDescentRate: 3,
+		FlightDuration: 90 * time.Minute,
+		MovingTime:     80 * time.Minute,
+		MaxTurnRate:    25,
+	}
+
+	metric := stats.AsMap("m", "kmh", "ms")
+	expectedKeys := []string{
+		"max_altitude", "min_altitude", "max_ground_speed", "max_climb_rate",
+		"max_descent_rate", "flight_duration_seconds", "moving_time_seconds", "max_turn_rate",
+		"track_distance_km", "open_distance_km", "course", "circling_time_percent",
+	}
+	for _, key := range expectedKeys {
+		if _, ok := metric[key]; !ok {
+			t.Errorf("expected key %s in map: %v", key, metric)
+		}
+	}
+
+	if metric["max_altitude"] != 1000.0 {
+		t.Errorf("expected max_altitude 1000, got %v", metric["max_altitude"])
+	}
+	if metric["flight_duration_seconds"] != 5400.0 {
+		t.Errorf("expected flight_duration_seconds 5400, got %v", metric["flight_duration_seconds"])
+	}
+
+	imperial := stats.AsMap("ft", "mph", "fpm")
+	if alt := imperial["max_altitude"].(float64); math.Abs(alt-3280.84) > 0.01 {
+		t.Errorf("expected max_altitude 3280.84 ft, got %f", alt)
+	}
+	if speed := imperial["max_ground_speed"].(float64); math.Abs(speed-62.1371) > 0.001 {
+		t.Errorf("expected max_ground_speed 62.1371 mph, got %f", speed)
+	}
+}
+
+func TestFlightHasWGS84Datum(t *testing.T) {
+	tests := []struct {
+		datum    string
+		expected bool
+	}{
+		{datum: "", expected: true},
+		{datum: "WGS84", expected: true},
+		{datum: "WGS-1984", expected: true},
+		{datum: "wgs 84", expected: true},
+		{datum: "ED50", expected: false},
+		{datum: "NAD27", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.datum, func(t *testing.T) {
+			flight := &Flight{GPSDatum: tt.datum}
+			if result := flight.HasWGS84Datum(); result != tt.expected {
+				t.Errorf("expected %v for datum %q, got %v", tt.expected, tt.datum, result)
+			}
+		})
+	}
+}
+
+func TestFlightCalculateTotalClimb(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	altitudes := []float64{1000, 1002, 1001, 1003, 1050, 1040, 1100}
+	fixes := make([]*igc.BRecord, len(altitudes))
+	for i, alt := range altitudes {
+		fixes[i] = &igc.BRecord{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(time.Duration(i) * time.Second), AltWGS84: alt}
+	}
+
+	tests := []struct {
+		name       string
+		fixes      []*igc.BRecord
+		climbNoise float64
+		expected   float64
+	}{
+		{name: "empty fixes", fixes: []*igc.BRecord{}, climbNoise: DefaultClimbNoise, expected: 0},
+		{name: "jitter ignored", fixes: fixes, climbNoise: 3, expected: 110},
+		{name: "no noise gate", fixes: fixes, climbNoise: 0, expected: 111},
+		{name: "gate above all climbs", fixes: fixes, climbNoise: 100, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flight := &Flight{Fixes: tt.fixes}
+			if result := flight.CalculateTotalClimb(tt.climbNoise); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFlightVerticalTimeBreakdown(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	profile := []verticalSegment{{rate: 2, seconds: 100}, {rate: 0, seconds: 100}, {rate: -1.5, seconds: 100}}
+
+	tests := []struct {
+		name           string
+		fixes          []*igc.BRecord
+		levelThreshold float64
+		climbNoise     float64
+		expectedClimb  time.Duration
+		expectedSink   time.Duration
+		expectedLevel  time.Duration
+	}{
+		{
+			name:           "empty fixes",
+			fixes:          []*igc.BRecord{},
+			levelThreshold: DefaultLevelThreshold,
+		},
+		{
+			// The trailing window attributes the first seconds after a change to the previous phase
+			name:           "climb, level and sink",
+			fixes:          buildVerticalProfile(baseTime, 1500, profile),
+			levelThreshold: DefaultLevelThreshold,
+			expectedClimb:  107 * time.Second,
+			expectedSink:   97 * time.Second,
+			expectedLevel:  96 * time.Second,
+		},
+		{
+			name:           "threshold above all vertical speeds",
+			fixes:          buildVerticalProfile(baseTime, 1500, profile),
+			levelThreshold: 3,
+			expectedLevel:  300 * time.Second,
+		},
+		{
+			name:           "noise gate above window altitude changes",
+			fixes:          buildVerticalProfile(baseTime, 1500, profile),
+			levelThreshold: DefaultLevelThreshold,
+			climbNoise:     25,
+			expectedLevel:  300 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flight := &Flight{Fixes: tt.fixes}
+			climb, sink, level := flight.VerticalTimeBreakdown(tt.levelThreshold, tt.climbNoise)
+
+			if climb != tt.expectedClimb {
+				t.Errorf("expected climb %v, got %v", tt.expectedClimb, climb)
+			}
+			if sink != tt.expectedSink {
+				t.Errorf("expected sink %v, got %v", tt.expectedSink, sink)
+			}
+			if level != tt.expectedLevel {
+				t.Errorf("expected level %v, got %v", tt.expectedLevel, level)
+			}
+		})
+	}
+}
+
+func TestStatisticsVerticalTimePercentages(t *testing.T) {
+	stats := &Statistics{ClimbTime: 30 * time.Second, SinkTime: 60 * time.Second, LevelTime: 10 * time.Second}
+	climb, sink, level := stats.VerticalTimePercentages()
+	if climb != 30 || sink != 60 || level != 10 {
+		t.Errorf("expected 30/60/10, got %v/%v/%v", climb, sink, level)
+	}
+
+	empty := &Statistics{}
+	if climb, sink, level := empty.VerticalTimePercentages(); climb != 0 || sink != 0 || level != 0 {
+		t.Errorf("expected zero percentages without airtime, got %v/%v/%v", climb, sink, level)
+	}
+}
+
+// verticalSegment is a constant climb rate in m/s held for a number of seconds
+type verticalSegment struct {
+	rate    float64
+	seconds int
+}
+
+// buildVerticalProfile creates one fix per second following the given vertical segments
+func buildVerticalProfile(baseTime time.Time, startAlt float64, segments []verticalSegment) []*igc.BRecord {
+	fixes := []*igc.BRecord{{Lat: 45.814, Lon: 6.246, Time: baseTime, AltWGS84: startAlt}}
+	alt := startAlt
+	elapsed := 0
+	for _, segment := range segments {
+		for i := 0; i < segment.seconds; i++ {
+			alt += segment.rate
+			elapsed++
+			fixes = append(fixes, &igc.BRecord{
+				Lat:      45.814 + float64(elapsed)*0.0001,
+				Lon:      6.246,
+				Time:     baseTime.Add(time.Duration(elapsed) * time.Second),
+				AltWGS84: alt,
+			})
+		}
+	}
+	return fixes
+}
+
+// flatGround is an ElevationProvider with the same elevation everywhere south of a latitude
+type flatGround struct {
+	elevation float64
+	northEdge float64
+}
+
+func (g flatGround) Elevation(lat, lon float64) (float64, bool) {
+	return g.elevation, lat < g.northEdge
+}
+
+func TestFlightMinAGL(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		segments      []verticalSegment
+		ground        flatGround
+		expectedAGL   float64
+		expectedIndex int
+	}{
+		{
+			name:          "glide to landing has no save",
+			segments:      []verticalSegment{{rate: -1, seconds: 300}},
+			ground:        flatGround{elevation: 500, northEdge: 90},
+			expectedIndex: -1,
+		},
+		{
+			name:          "low save",
+			segments:      []verticalSegment{{rate: -2, seconds: 200}, {rate: 2, seconds: 100}, {rate: -3, seconds: 200}},
+			ground:        flatGround{elevation: 500, northEdge: 90},
+			expectedAGL:   100,
+			expectedIndex: 200,
+		},
+		{
+			name:          "shallow bump is not a save",
+			segments:      []verticalSegment{{rate: -2, seconds: 200}, {rate: 1, seconds: 40}, {rate: -1, seconds: 100}},
+			ground:        flatGround{elevation: 500, northEdge: 90},
+			expectedIndex: -1,
+		},
+		{
+			name:          "no elevation data",
+			segments:      []verticalSegment{{rate: -2, seconds: 200}, {rate: 2, seconds: 100}, {rate: -3, seconds: 200}},
+			ground:        flatGround{elevation: 500, northEdge: 0},
+			expectedIndex: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Fixes: buildVerticalProfile(baseTime, 1000, tt.segments)}
+			agl, index := f.MinAGL(tt.ground)
+			if index != tt.expectedIndex {
+				t.Fatalf("expected index %d, got %d", tt.expectedIndex, index)
+			}
+			if index >= 0 && math.Abs(agl-tt.expectedAGL) > 0.01 {
+				t.Errorf("expected %.1f m above ground, got %.1f", tt.expectedAGL, agl)
+			}
+		})
+	}
+}
+
+func TestFlightDetectThermals(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		segments      []verticalSegment
+		expectedCount int
+		expectedGains []float64
+	}{
+		{
+			name:          "steady glide",
+			segments:      []verticalSegment{{rate: -1, seconds: 300}},
+			expectedCount: 0,
+		},
+		{
+			name:          "short bump is ignored",
+			segments:      []verticalSegment{{rate: -1, seconds: 60}, {rate: 2, seconds: 15}, {rate: -1, seconds: 60}},
+			expectedCount: 0,
+		},
+		{
+			name: "two thermals",
+			segments: []verticalSegment{
+				{rate: -1, seconds: 60}, {rate: 2, seconds: 120},
+				{rate: -1, seconds: 120}, {rate: 3, seconds: 100},
+				{rate: -1, seconds: 60},
+			},
+			expectedCount: 2,
+			expectedGains: []float64{240, 300},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flight := &Flight{Fixes: buildVerticalProfile(baseTime, 1500, tt.segments)}
+			thermals := flight.DetectThermals()
+
+			if len(thermals) != tt.expectedCount {
+				t.Fatalf("expected %d thermals, got %d: %+v", tt.expectedCount, len(thermals), thermals)
+			}
+
+			for i, expectedGain := range tt.expectedGains {
+				if math.Abs(thermals[i].Gain-expectedGain) > 0.001 {
+					t.Errorf("expected thermal %d gain %f, got %f", i, expectedGain, thermals[i].Gain)
+				}
+				if thermals[i].AvgClimbRate <= 0 {
+					t.Errorf("expected positive average climb rate, got %f", thermals[i].AvgClimbRate)
+				}
+			}
+		})
+	}
+}
+
+func TestFlightBiggestClimb(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	flight := &Flight{Fixes: buildVerticalProfile(baseTime, 1500, []verticalSegment{
+		{rate: -1, seconds: 60}, {rate: 2, seconds: 120},
+		{rate: -1, seconds: 120}, {rate: 3, seconds: 100},
+	})}
+
+	gain, thermal := flight.BiggestClimb()
+	if math.Abs(gain-300) > 0.001 {
+		t.Errorf("expected biggest climb 300m, got %f", gain)
+	}
+	expectedStart := baseTime.Add(300 * time.Second)
+	if !thermal.StartTime.Equal(expectedStart) {
+		t.Errorf("expected thermal to start at %v, got %v", expectedStart, thermal.StartTime)
+	}
+
+	stats := flight.GetStatistics(DefaultStatsOptions())
+	if math.Abs(stats.BiggestClimbGain-300) > 0.001 {
+		t.Errorf("expected BiggestClimbGain 300, got %f", stats.BiggestClimbGain)
+	}
+
+	glide := &Flight{Fixes: buildVerticalProfile(baseTime, 1500, []verticalSegment{{rate: -1, seconds: 300}})}
+	if gain, _ := glide.BiggestClimb(); gain != 0 {
+		t.Errorf("expected 0 without thermals, got %f", gain)
+	}
+}
+
+func TestFlightAnonymize(t *testing.T) {
+	original := &Flight{
+		Pilot:              "John Doe",
+		Crew:               "Jane Doe",
+		GliderType:         "Ozone Enzo 3",
+		GliderID:           "D-1234",
+		CompetitionID:      "42",
+		FlightRecorderType: "XCTrack",
+		FirmwareVersion:    "0.9.11",
+		HardwareVersion:    "SM-G991B",
+		GPSDatum:           "WGS-1984",
+		Comments:           []Comment{{Source: "XCT", Text: "PILOT John Doe"}},
+		Fixes:              []*igc.BRecord{{Lat: 45.814, Lon: 6.246}},
+	}
+
+	tests := []struct {
+		name             string
+		level            string
+		expectPilot      string
+		expectGliderType string
+		expectRecorder   string
+	}{
+		{"none", AnonymizeNone, "John Doe", "Ozone Enzo 3", "XCTrack"},
+		{"basic", AnonymizeBasic, "", "Ozone Enzo 3", "XCTrack"},
+		{"strict", AnonymizeStrict, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anonymized := original.Anonymize(tt.level)
+
+			if anonymized.Pilot != tt.expectPilot {
+				t.Errorf("expected pilot %q, got %q", tt.expectPilot, anonymized.Pilot)
+			}
+			if anonymized.GliderType != tt.expectGliderType {
+				t.Errorf("expected glider type %q, got %q", tt.expectGliderType, anonymized.GliderType)
+			}
+			if anonymized.FlightRecorderType != tt.expectRecorder {
+				t.Errorf("expected recorder type %q, got %q", tt.expectRecorder, anonymized.FlightRecorderType)
+			}
+			if tt.level != AnonymizeNone && (anonymized.Crew != "" || anonymized.GliderID != "" || anonymized.CompetitionID != "" || anonymized.Comments != nil) {
+				t.Errorf("expected crew, glider ID, competition ID and comments to be removed, got %q, %q, %q, %v",
+					anonymized.Crew, anonymized.GliderID, anonymized.CompetitionID, anonymized.Comments)
+			}
+			if anonymized.GPSDatum != original.GPSDatum || len(anonymized.Fixes) != len(original.Fixes) {
+				t.Errorf("expected datum and fixes to be kept")
+			}
+		})
+	}
+
+	if original.Pilot != "John Doe" {
+		t.Errorf("expected original flight to be unchanged, got pilot %q", original.Pilot)
+	}
+}
+
+func TestValidateAnonymizeLevel(t *testing.T) {
+	for _, level := range []string{AnonymizeNone, AnonymizeBasic, AnonymizeStrict} {
+		if err := ValidateAnonymizeLevel(level); err != nil {
+			t.Errorf("expected level %q to be valid, got %v", level, err)
+		}
+	}
+	if err := ValidateAnonymizeLevel("full"); err == nil {
+		t.Errorf("expected error for unknown level")
+	}
+}
+
+func TestFlightOverlapsInTime(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	newFlight := func(start, end time.Duration) *Flight {
+		return &Flight{Fixes: []*igc.BRecord{
+			{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(start)},
+			{Lat: 45.815, Lon: 6.247, Time: baseTime.Add(end)},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		a        *Flight
+		b        *Flight
+		expected bool
+	}{
+		{"overlapping", newFlight(0, time.Hour), newFlight(30*time.Minute, 2*time.Hour), true},
+		{"contained", newFlight(0, 3*time.Hour), newFlight(time.Hour, 2*time.Hour), true},
+		{"touching", newFlight(0, time.Hour), newFlight(time.Hour, 2*time.Hour), true},
+		{"disjoint", newFlight(0, time.Hour), newFlight(2*time.Hour, 3*time.Hour), false},
+		{"no fixes", newFlight(0, time.Hour), &Flight{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.a.OverlapsInTime(tt.b); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+			if result := tt.b.OverlapsInTime(tt.a); result != tt.expected {
+				t.Errorf("expected %v in reverse order, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+// buildCircling creates one fix per second circling with the given period around a core
+// drifting at (driftEast, driftNorth) m/s, with the radius of each fix given by radius
+func buildCircling(baseTime time.Time, seconds int, period float64, driftEast, driftNorth float64, radius func(second int) float64) []*igc.BRecord {
+	const lat0, lon0 = 45.8, 6.2
+	cosLat := math.Cos(lat0 * DegreesToRadians)
+
+	fixes := make([]*igc.BRecord, 0, seconds+1)
+	for i := 0; i <= seconds; i++ {
+		angle := 2 * math.Pi * float64(i) / period
+		x := driftEast*float64(i) + radius(i)*math.Sin(angle)
+		y := driftNorth*float64(i) + radius(i)*math.Cos(angle)
+		fixes = append(fixes, &igc.BRecord{
+			Lat:      lat0 + y/EarthRadiusMeters/DegreesToRadians,
+			Lon:      lon0 + x/(EarthRadiusMeters*cosLat)/DegreesToRadians,
+			Time:     baseTime.Add(time.Duration(i) * time.Second),
+			AltWGS84: 1500 + float64(i),
+		})
+	}
+	return fixes
+}
+
+type turnSegment struct {
+	rate    float64 // heading change in degrees per second, positive to the right
+	seconds int
+}
+
+// buildTurns creates one fix per second flying at 10 m/s with the given turn segments
+func buildTurns(baseTime time.Time, segments []turnSegment) []*igc.BRecord {
+	const speed = 10.0
+	lat, lon := 45.8, 6.2
+	heading := 0.0
+	fixes := []*igc.BRecord{{Lat: lat, Lon: lon, Time: baseTime, AltWGS84: 1500}}
+	elapsed := 0
+	for _, segment := range segments {
+		for i := 0; i < segment.seconds; i++ {
+			heading += segment.rate
+			elapsed++
+			lat += speed * math.Cos(heading*DegreesToRadians) / EarthRadiusMeters / DegreesToRadians
+			lon += speed * math.Sin(heading*DegreesToRadians) / (EarthRadiusMeters * math.Cos(lat*DegreesToRadians)) / DegreesToRadians
+			fixes = append(fixes, &igc.BRecord{
+				Lat:      lat,
+				Lon:      lon,
+				Time:     baseTime.Add(time.Duration(elapsed) * time.Second),
+				AltWGS84: 1500,
+			})
+		}
+	}
+	return fixes
+}
+
+func TestFlightCirclingSegments(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		segments         []turnSegment
+		expectedCircling []bool
+		circlingSeconds  float64 // expected circling time, within the averaging window
+	}{
+		{
+			name:             "straight glide",
+			segments:         []turnSegment{{rate: 0, seconds: 300}},
+			expectedCircling: []bool{false},
+		},
+		{
+			name: "s-turns are not circling",
+			segments: []turnSegment{
+				{rate: 15, seconds: 6}, {rate: -15, seconds: 12}, {rate: 15, seconds: 12},
+				{rate: -15, seconds: 12}, {rate: 15, seconds: 12}, {rate: -15, seconds: 6},
+			},
+			expectedCircling: []bool{false},
+		},
+		{
+			name:             "short turn is a course correction",
+			segments:         []turnSegment{{rate: 0, seconds: 60}, {rate: 15, seconds: 12}, {rate: 0, seconds: 60}},
+			expectedCircling: []bool{false},
+		},
+		{
+			name:             "glide, thermal, glide",
+			segments:         []turnSegment{{rate: 0, seconds: 120}, {rate: -18, seconds: 120}, {rate: 0, seconds: 120}},
+			expectedCircling: []bool{false, true, false},
+			circlingSeconds:  120,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Fixes: buildTurns(baseTime, tt.segments)}
+			segments := f.CirclingSegments()
+			if len(segments) != len(tt.expectedCircling) {
+				t.Fatalf("expected %d segments, got %d: %+v", len(tt.expectedCircling), len(segments), segments)
+			}
+			for i, segment := range segments {
+				if segment.Circling != tt.expectedCircling[i] {
+					t.Errorf("segment %d: expected circling %v, got %v", i, tt.expectedCircling[i], segment.Circling)
+				}
+			}
+			if segments[0].StartIndex != 0 || segments[len(segments)-1].EndIndex != len(f.Fixes)-1 {
+				t.Errorf("expected segments to cover the whole flight, got %+v", segments)
+			}
+
+			circlingTime, glidingTime, circlingDistance, glidingDistance := f.CirclingBreakdown()
+			if circlingTime+glidingTime != f.Duration() {
+				t.Errorf("expected circling and gliding time to add up to %v, got %v", f.Duration(), circlingTime+glidingTime)
+			}
+			if math.Abs(circlingDistance+glidingDistance-f.CalculateTrackDistance()) > 0.01 {
+				t.Errorf("expected circling and gliding distance to add up to the track distance")
+			}
+			if math.Abs(circlingTime.Seconds()-tt.circlingSeconds) > CirclingWindowSeconds {
+				t.Errorf("expected about %.0fs circling, got %v", tt.circlingSeconds, circlingTime)
+			}
+		})
+	}
+}
+
+func TestFlightThermalCentering(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	steady := &Flight{Fixes: buildCircling(baseTime, 100, 25, 2, 1, func(int) float64 { return 50 })}
+	centering, ok := steady.ThermalCentering(Thermal{StartIndex: 0, EndIndex: 100})
+	if !ok {
+		t.Fatalf("expected a centering estimate")
+	}
+	if math.Abs(centering.AvgRadius-50) > 2 {
+		t.Errorf("expected average radius about 50m, got %.1f", centering.AvgRadius)
+	}
+	if centering.RadiusStdDev > 2 {
+		t.Errorf("expected small radius deviation for steady circles, got %.1f", centering.RadiusStdDev)
+	}
+	if math.Abs(centering.DriftSpeed-math.Hypot(2, 1)) > 0.1 {
+		t.Errorf("expected drift speed about %.2f m/s, got %.2f", math.Hypot(2, 1), centering.DriftSpeed)
+	}
+	if expected := math.Atan2(2, 1) / DegreesToRadians; math.Abs(centering.DriftBearing-expected) > 2 {
+		t.Errorf("expected drift bearing about %.0f°, got %.0f°", expected, centering.DriftBearing)
+	}
+
+	// Alternating tight and wide circles, as when searching for the core
+	wobbly := &Flight{Fixes: buildCircling(baseTime, 100, 25, 2, 1, func(second int) float64 {
+		if (second/25)%2 == 0 {
+			return 30
+		}
+		return 70
+	})}
+	wobblyCentering, ok := wobbly.ThermalCentering(Thermal{StartIndex: 0, EndIndex: 100})
+	if !ok {
+		t.Fatalf("expected a centering estimate")
+	}
+	if wobblyCentering.Variation() <= centering.Variation() {
+		t.Errorf("expected poorly centered circles to vary more, got %.2f vs %.2f", wobblyCentering.Variation(), centering.Variation())
+	}
+
+	best, worst, ok := BestAndWorstCentered([]ThermalCentering{wobblyCentering, centering})
+	if !ok || best.AvgRadius != centering.AvgRadius || worst.AvgRadius != wobblyCentering.AvgRadius {
+		t.Errorf("expected steady thermal as best and wobbly as worst")
+	}
+
+	if _, ok := steady.ThermalCentering(Thermal{StartIndex: 0, EndIndex: MinCenteringFixes - 2}); ok {
+		t.Errorf("expected no estimate with too few fixes")
+	}
+	straight := &Flight{Fixes: buildVerticalProfile(baseTime, 1500, []verticalSegment{{rate: 1, seconds: 100}})}
+	if _, ok := straight.ThermalCentering(Thermal{StartIndex: 0, EndIndex: 100}); ok {
+		t.Errorf("expected no estimate without a full turn")
+	}
+	if _, _, ok := BestAndWorstCentered(nil); ok {
+		t.Errorf("expected no best and worst without thermals")
+	}
+}
+
+func TestFlightCalculateTrackDistance(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		fixes     []*igc.BRecord
+		expected  float64
+		tolerance float64
+	}{
+		{name: "empty fixes", fixes: []*igc.BRecord{}, expected: 0, tolerance: 0},
+		{
+			name:      "single fix",
+			fixes:     []*igc.BRecord{{Lat: 45.814, Lon: 6.246, Time: baseTime}},
+			expected:  0,
+			tolerance: 0,
+		},
+		{
+			name: "out and back",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246, Time: baseTime},
+				{Lat: 45.914, Lon: 6.246, Time: baseTime.Add(time.Minute)},
+				{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(2 * time.Minute)},
+			},
+			expected:  22239, // twice 0.1 degree of latitude
+			tolerance: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Fixes: tt.fixes}
+			distance := f.CalculateTrackDistance()
+			if math.Abs(distance-tt.expected) > tt.tolerance {
+				t.Errorf("expected %.0f m, got %.0f m", tt.expected, distance)
+			}
+		})
+	}
+}
+
+func TestFlightStalledFixes(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	fix := func(second int, lat, lon, alt float64) *igc.BRecord {
+		return &igc.BRecord{Lat: lat, Lon: lon, AltWGS84: alt, Time: baseTime.Add(time.Duration(second) * time.Second)}
+	}
+
+	tests := []struct {
+		name          string
+		fixes         []*igc.BRecord
+		expectedRuns  []StalledRun
+		expectedCount int
+	}{
+		{name: "empty fixes", fixes: []*igc.BRecord{}, expectedRuns: nil, expectedCount: 0},
+		{
+			name:          "moving track",
+			fixes:         []*igc.BRecord{fix(0, 45.8, 6.2, 1000), fix(1, 45.8001, 6.2, 1000), fix(2, 45.8002, 6.2, 1001)},
+			expectedRuns:  nil,
+			expectedCount: 0,
+		},
+		{
+			name: "altitude change is not stalled",
+			fixes: []*igc.BRecord{
+				fix(0, 45.8, 6.2, 1000), fix(1, 45.8, 6.2, 1001),
+			},
+			expectedRuns:  nil,
+			expectedCount: 0,
+		},
+		{
+			name: "two runs",
+			fixes: []*igc.BRecord{
+				fix(0, 45.8, 6.2, 1000),
+				fix(1, 45.8001, 6.2, 1000), fix(2, 45.8001, 6.2, 1000), fix(3, 45.8001, 6.2, 1000),
+				fix(4, 45.8002, 6.2, 1000),
+				fix(5, 45.8003, 6.2, 1000), fix(6, 45.8003, 6.2, 1000),
+			},
+			expectedRuns:  []StalledRun{{Start: 1, End: 3}, {Start: 5, End: 6}},
+			expectedCount: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Fixes: tt.fixes}
+
+			runs := f.StalledRuns()
+			if !reflect.DeepEqual(runs, tt.expectedRuns) {
+				t.Errorf("expected runs %v, got %v", tt.expectedRuns, runs)
+			}
+			if count := f.CountStalledFixes(); count != tt.expectedCount {
+				t.Errorf("expected %d stalled fixes, got %d", tt.expectedCount, count)
+			}
+
+			collapsed := f.CollapseStalled()
+			if len(collapsed.Fixes) != len(tt.fixes)-tt.expectedCount {
+				t.Errorf("expected %d fixes after collapsing, got %d", len(tt.fixes)-tt.expectedCount, len(collapsed.Fixes))
+			}
+			if collapsed.CountStalledFixes() != 0 {
+				t.Errorf("expected no stalled fixes after collapsing")
+			}
+			if len(f.Fixes) != len(tt.fixes) {
+				t.Errorf("collapsing modified the original flight")
+			}
+		})
+	}
+}
+
+func TestFlightWithAltitudeSource(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	fixes := []*igc.BRecord{
+		{Lat: 45.814, Lon: 6.246, Time: baseTime, AltWGS84: 1050, AltBarometric: 1000},
+		{Lat: 45.815, Lon: 6.246, Time: baseTime.Add(time.Second), AltWGS84: 1150, AltBarometric: 1100},
+	}
+
+	tests := []struct {
+		name        string
+		source      string
+		qnh         float64
+		expectedAlt []float64
+	}{
+		{name: "gps", source: AltSourceGPS, qnh: 0, expectedAlt: []float64{1050, 1150}},
+		{name: "gps ignores qnh", source: AltSourceGPS, qnh: 1023.25, expectedAlt: []float64{1050, 1150}},
+		{name: "baro without qnh", source: AltSourceBaro, qnh: 0, expectedAlt: []float64{1000, 1100}},
+		{name: "baro with standard qnh", source: AltSourceBaro, qnh: StandardPressure, expectedAlt: []float64{1000, 1100}},
+		{name: "baro with high pressure", source: AltSourceBaro, qnh: 1023.25, expectedAlt: []float64{1082.3, 1182.3}},
+		{name: "baro with low pressure", source: AltSourceBaro, qnh: 1003.25, expectedAlt: []float64{917.7, 1017.7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Fixes: fixes}
+			converted := f.WithAltitudeSource(tt.source, tt.qnh)
+
+			for i, fix := range converted.Fixes {
+				if math.Abs(fix.AltWGS84-tt.expectedAlt[i]) > 0.01 {
+					t.Errorf("fix %d: expected altitude %.2f, got %.2f", i, tt.expectedAlt[i], fix.AltWGS84)
+				}
+			}
+			if fixes[0].AltWGS84 != 1050 || fixes[0].AltBarometric != 1000 {
+				t.Errorf("original fixes were modified")
+			}
+		})
+	}
+}
+
+func TestValidateAltitudeSource(t *testing.T) {
+	for _, source := range []string{AltSourceGPS, AltSourceBaro} {
+		if err := ValidateAltitudeSource(source); err != nil {
+			t.Errorf("expected %q to be valid, got %v", source, err)
+		}
+	}
+	if err := ValidateAltitudeSource("radar"); err == nil {
+		t.Errorf("expected error for unknown altitude source")
+	}
+}
+
+func TestFlightStraightLineSpeed(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		fixes     []*igc.BRecord
+		expected  float64
+		tolerance float64
+	}{
+		{name: "empty fixes", fixes: []*igc.BRecord{}, expected: 0, tolerance: 0},
+		{
+			name: "zero duration",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246, Time: baseTime},
+				{Lat: 45.914, Lon: 6.246, Time: baseTime},
+			},
+			expected:  0,
+			tolerance: 0,
+		},
+		{
+			name: "out and back",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246, Time: baseTime},
+				{Lat: 45.914, Lon: 6.246, Time: baseTime.Add(30 * time.Minute)},
+				{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(time.Hour)},
+			},
+			expected:  0,
+			tolerance: 0.01,
+		},
+		{
+			name: "11 km in half an hour",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246, Time: baseTime},
+				{Lat: 45.864, Lon: 6.300, Time: baseTime.Add(10 * time.Minute)},
+				{Lat: 45.914, Lon: 6.246, Time: baseTime.Add(30 * time.Minute)},
+			},
+			expected:  22.24,
+			tolerance: 0.05,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Fixes: tt.fixes}
+			speed := f.StraightLineSpeed()
+			if math.Abs(speed-tt.expected) > tt.tolerance {
+				t.Errorf("expected %.2f km/h, got %.2f km/h", tt.expected, speed)
+			}
+		})
+	}
+}
+
+func TestFlightSmoothAltitude(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	altitudes := []float64{1000, 1010, 990, 1000, 1060, 1000}
+	fixes := make([]*igc.BRecord, len(altitudes))
+	for i, alt := range altitudes {
+		fixes[i] = &igc.BRecord{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(time.Duration(i) * time.Second), AltWGS84: alt}
+	}
+
+	tests := []struct {
+		name     string
+		window   time.Duration
+		expected []float64
+	}{
+		{name: "disabled", window: 0, expected: altitudes},
+		{name: "shorter than sampling", window: 500 * time.Millisecond, expected: altitudes},
+		{name: "three fix window", window: 2 * time.Second, expected: []float64{1005, 1000, 1000, 1016.67, 1020, 1030}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Fixes: fixes}
+			smoothed := f.SmoothAltitude(tt.window)
+
+			if len(smoothed.Fixes) != len(fixes) {
+				t.Fatalf("expected %d fixes, got %d", len(fixes), len(smoothed.Fixes))
+			}
+			for i, fix := range smoothed.Fixes {
+				if math.Abs(fix.AltWGS84-tt.expected[i]) > 0.01 {
+					t.Errorf("fix %d: expected altitude %.2f, got %.2f", i, tt.expected[i], fix.AltWGS84)
+				}
+			}
+			if fixes[4].AltWGS84 != 1060 {
+				t.Errorf("original fixes were modified")
+			}
+		})
+	}
+}
+
+func TestSmoothedAltitudes(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	makeFixes := func(altitudes ...float64) []*igc.BRecord {
+		fixes := make([]*igc.BRecord, len(altitudes))
+		for i, alt := range altitudes {
+			fixes[i] = &igc.BRecord{Time: baseTime.Add(time.Duration(i) * time.Second), AltWGS84: alt}
+		}
+		return fixes
+	}
+
+	tests := []struct {
+		name     string
+		fixes    []*igc.BRecord
+		window   time.Duration
+		expected []float64
+	}{
+		{name: "no fixes", fixes: nil, window: 5 * time.Second, expected: []float64{}},
+		{name: "disabled", fixes: makeFixes(1000, 1020, 1000), window: 0, expected: []float64{1000, 1020, 1000}},
+		{name: "spike spread over window", fixes: makeFixes(1000, 1000, 1030, 1000, 1000), window: 2 * time.Second, expected: []float64{1000, 1010, 1010, 1010, 1000}},
+		{name: "steady climb kept", fixes: makeFixes(1000, 1002, 1004, 1006, 1008), window: 2 * time.Second, expected: []float64{1001, 1002, 1004, 1006, 1007}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			altitudes := SmoothedAltitudes(tt.fixes, tt.window)
+			if len(altitudes) != len(tt.expected) {
+				t.Fatalf("expected %d altitudes, got %d", len(tt.expected), len(altitudes))
+			}
+			for i, alt := range altitudes {
+				if math.Abs(alt-tt.expected[i]) > 0.01 {
+					t.Errorf("altitude %d: expected %.2f, got %.2f", i, tt.expected[i], alt)
+				}
+			}
+		})
+	}
+}
+
+func TestFlightGetStatisticsSmoothWindow(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	// A steady 1 m/s climb with a 20 m GPS spike in the middle
+	fixes := make([]*igc.BRecord, 21)
+	for i := range fixes {
+		alt := 1000 + float64(i)
+		if i == 10 {
+			alt += 20
+		}
+		fixes[i] = &igc.BRecord{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(time.Duration(i) * time.Second), AltWGS84: alt}
+	}
+	f := &Flight{Fixes: fixes}
+
+	raw := f.GetStatistics(DefaultStatsOptions())
+	opts := DefaultStatsOptions()
+	opts.SmoothWindow = 10 * time.Second
+	smoothed := f.GetStatistics(opts)
+
+	if raw.MaxClimbRate != 21 || raw.MaxDescentRate != 19 {
+		t.Errorf("expected raw climb 21 m/s and descent 19 m/s, got %.2f and %.2f", raw.MaxClimbRate, raw.MaxDescentRate)
+	}
+	if smoothed.MaxClimbRate >= 5 || smoothed.MaxDescentRate >= 5 {
+		t.Errorf("expected smoothed rates below 5 m/s, got climb %.2f and descent %.2f", smoothed.MaxClimbRate, smoothed.MaxDescentRate)
+	}
+	if smoothed.MaxAltitude != raw.MaxAltitude || smoothed.MaxAltitude != 1030 {
+		t.Errorf("expected the raw maximum altitude 1030 m, got %d", smoothed.MaxAltitude)
+	}
+	if fixes[10].AltWGS84 != 1030 {
+		t.Errorf("original fixes were modified")
+	}
+}
+
+func TestFlightCalculateOpenDistance(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		fixes     []*igc.BRecord
+		expected  float64
+		tolerance float64
+	}{
+		{name: "empty fixes", fixes: []*igc.BRecord{}, expected: 0, tolerance: 0},
+		{
+			name: "return to launch",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246, Time: baseTime},
+				{Lat: 45.914, Lon: 6.246, Time: baseTime.Add(time.Minute)},
+				{Lat: 45.814, Lon: 6.246, Time: baseTime.Add(2 * time.Minute)},
+			},
+			expected:  0,
+			tolerance: 0.01,
+		},
+		{
+			name: "open flight",
+			fixes: []*igc.BRecord{
+				{Lat: 45.814, Lon: 6.246, Time: baseTime},
+				{Lat: 45.864, Lon: 6.300, Time: baseTime.Add(time.Minute)},
+				{Lat: 45.914, Lon: 6.246, Time: baseTime.Add(2 * time.Minute)},
+			},
+			expected:  11120,
+			tolerance: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Fixes: tt.fixes}
+			distance := f.CalculateOpenDistance()
+			if math.Abs(distance-tt.expected) > tt.tolerance {
+				t.Errorf("expected %.0f m, got %.0f m", tt.expected, distance)
+			}
+		})
+	}
+}
+
+func TestFlightCalculateGlideRatio(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	glide := func(startAlt, endAlt float64) []*igc.BRecord {
+		return []*igc.BRecord{
+			{Lat: 45.814, Lon: 6.246, Time: baseTime, AltWGS84: startAlt},
+			{Lat: 45.914, Lon: 6.246, Time: baseTime.Add(10 * time.Minute), AltWGS84: endAlt},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		fixes     []*igc.BRecord
+		expected  float64
+		tolerance float64
+	}{
+		{name: "empty fixes", fixes: []*igc.BRecord{}, expected: 0, tolerance: 0},
+		{name: "glide", fixes: glide(2000, 1000), expected: 11.12, tolerance: 0.01},
+		{name: "net altitude gain", fixes: glide(1000, 2000), expected: 0, tolerance: 0},
+		{name: "no altitude change", fixes: glide(1000, 1000), expected: 0, tolerance: 0},
+		{name: "altitude loss below threshold", fixes: glide(1000, 999.5), expected: 0, tolerance: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flight{Fixes: tt.fixes}
+			ratio := f.CalculateGlideRatio()
+			if math.Abs(ratio-tt.expected) > tt.tolerance {
+				t.Errorf("expected glide ratio %.2f, got %.2f", tt.expected, ratio)
+			}
+		})
+	}
+}
+
+func TestFlightClockResets(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	// The clock jumps back 10 seconds between the second and third fix
+	reset := &Flight{Fixes: []*igc.BRecord{
+		{Lat: 45.800, Lon: 6.2, Time: baseTime},
+		{Lat: 45.801, Lon: 6.2, Time: baseTime.Add(10 * time.Second)},
+		{Lat: 45.802, Lon: 6.2, Time: baseTime},
+		{Lat: 45.803, Lon: 6.2, Time: baseTime.Add(10 * time.Second)},
+	}}
+	if resets := reset.ClockResets(); resets != 1 {
+		t.Errorf("expected 1 clock reset, got %d", resets)
+	}
+	// Each 111 m leg takes 10 s (40 km/h); the backward interval must not produce a speed
+	if speed := reset.CalculateMaxGroundSpeed(5); speed > 45 {
+		t.Errorf("expected the backward interval to be skipped, got %f km/h", speed)
+	}
+	if warnings := reset.TimeWarnings(); len(warnings) != 1 {
+		t.Errorf("expected 1 warning, got %v", warnings)
+	}
+
+	// A last fix before the first clamps the duration to zero with a warning
+	backward := &Flight{Fixes: []*igc.BRecord{
+		{Lat: 45.800, Lon: 6.2, Time: baseTime.Add(time.Minute)},
+		{Lat: 45.801, Lon: 6.2, Time: baseTime},
+	}}
+	if duration := backward.Duration(); duration != 0 {
+		t.Errorf("expected duration clamped to zero, got %s", duration)
+	}
+	if stats := backward.GetStatistics(DefaultStatsOptions()); stats.FlightDuration != 0 {
+		t.Errorf("expected statistics duration clamped to zero, got %s", stats.FlightDuration)
+	}
+	if warnings := backward.TimeWarnings(); len(warnings) != 2 {
+		t.Errorf("expected clock reset and clamped duration warnings, got %v", warnings)
+	}
+
+	if warnings := (&Flight{}).TimeWarnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings without fixes, got %v", warnings)
+	}
+}
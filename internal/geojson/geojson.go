@@ -1,15 +1,29 @@
 package geojson
 
 import (
-	"encoding/json"
 	"fmt"
+	"math"
+	"time"
 
 	"igc-tool/internal/flight"
+	"igc-tool/internal/units"
+	"igc-tool/internal/utils"
+
+	"github.com/twpayne/go-igc"
 )
 
+// metadataStatsOptions returns the thresholds used for the statistics embedded in the
+// metadata, with ground speeds computed over speedWindow seconds
+func metadataStatsOptions(speedWindow float64) flight.StatsOptions {
+	opts := flight.DefaultStatsOptions()
+	opts.SpeedWindow = speedWindow
+	return opts
+}
+
 // GeoJSONFeature represents a GeoJSON feature
 type GeoJSONFeature struct {
 	Type       string                 `json:"type"`
+	BBox       []float64              `json:"bbox,omitempty"`
 	Geometry   GeoJSONGeometry        `json:"geometry"`
 	Properties map[string]interface{} `json:"properties"`
 }
@@ -23,32 +37,179 @@ type GeoJSONGeometry struct {
 // GeoJSONFeatureCollection represents a GeoJSON feature collection
 type GeoJSONFeatureCollection struct {
 	Type     string           `json:"type"`
+	BBox     []float64        `json:"bbox,omitempty"`
 	Features []GeoJSONFeature `json:"features"`
 }
 
+// LayerOptions selects the optional content of the output. Thermals and Markers add
+// features next to the track LineStrings, which turns the output into a FeatureCollection.
+type LayerOptions struct {
+	Thermals   bool // a Point feature per detected thermal, styled by climb rate
+	Markers    bool // Point features for takeoff and landing
+	CoordTimes bool // a "coordTimes" track property, always set for gaggles
+}
+
+// any reports whether any extra feature layer is selected
+func (o LayerOptions) any() bool {
+	return o.Thermals || o.Markers
+}
+
+// thermalClass is a climb rate band of the thermal layer, styled with the simplestyle
+// "marker-color" and "marker-size" properties understood by geojson.io and Mapbox
+type thermalClass struct {
+	minRate float64 // average climb rate in m/s from which the band applies
+	color   string
+	size    string
+}
+
+// thermalClasses are the climb rate bands from weakest to strongest
+var thermalClasses = []thermalClass{
+	{minRate: 0, color: "#ffd92f", size: "small"},  // yellow
+	{minRate: 1, color: "#fc8d62", size: "medium"}, // orange
+	{minRate: 2, color: "#e31a1c", size: "large"},  // red
+}
+
+// gaggleColors is the palette cycled through to tell flights apart in a gaggle view
+var gaggleColors = []string{"#e41a1c", "#377eb8", "#4daf4a", "#984ea3", "#ff7f00", "#a65628", "#f781bf", "#999999"}
+
 // RenderToGeoJSON converts a flight track to GeoJSON format
-func RenderToGeoJSON(flight *flight.Flight, pretty bool, includeMetadata bool) ([]byte, error) {
+// Each nesting level is indented with indent, or the output is compact when it is empty.
+// The metadata statistics compute ground speeds over speedWindow seconds, as the stats
+// command does. A positive smoothWindow smooths the coordinate altitudes with flight.SmoothAltitude;
+// metadata statistics are always computed from the original altitudes.
+// The track is a single Feature, or the first feature of a FeatureCollection when
+// layers selects extra features. With layers.CoordTimes the track carries the
+// "coordTimes" property described in RenderGaggleToGeoJSON.
+func RenderToGeoJSON(flight *flight.Flight, indent string, includeMetadata bool, speedWindow float64, smoothWindow time.Duration, layers LayerOptions) ([]byte, error) {
+	feature, times, err := buildFeature(flight, includeMetadata, speedWindow, smoothWindow)
+	if err != nil {
+		return nil, err
+	}
+	if layers.CoordTimes {
+		feature.Properties["coordTimes"] = coordTimes(times)
+	}
+
+	var output interface{} = feature
+	if layers.any() {
+		collection := GeoJSONFeatureCollection{
+			Type:     "FeatureCollection",
+			BBox:     feature.BBox,
+			Features: append([]GeoJSONFeature{feature}, layerFeatures(flight, layers)...),
+		}
+		output = collection
+	}
+
+	// Marshal to JSON
+	result, err := utils.MarshalJSON(output, indent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal GeoJSON: %w", err)
+	}
+
+	return result, nil
+}
+
+// RenderGaggleToGeoJSON converts several flights flown together into a FeatureCollection
+// for time-synchronized playback. Each feature carries a "coordTimes" property with the
+// UTC time of every coordinate (the convention used by togeojson and Mapbox), plus a
+// "flight_index" and "color" so viewers can animate and tell the tracks apart.
+// speedWindow and smoothWindow are applied to each track as in RenderToGeoJSON. The features selected
+// by layers follow each track and carry its "flight_index".
+func RenderGaggleToGeoJSON(flights []*flight.Flight, indent string, includeMetadata bool, speedWindow float64, smoothWindow time.Duration, layers LayerOptions) ([]byte, error) {
+	collection := GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]GeoJSONFeature, 0, len(flights)),
+	}
+
+	for i, f := range flights {
+		feature, times, err := buildFeature(f, includeMetadata, speedWindow, smoothWindow)
+		if err != nil {
+			return nil, fmt.Errorf("flight %d: %w", i, err)
+		}
+
+		feature.Properties["coordTimes"] = coordTimes(times)
+		feature.Properties["flight_index"] = i
+		feature.Properties["color"] = gaggleColors[i%len(gaggleColors)]
+
+		collection.Features = append(collection.Features, feature)
+		collection.BBox = unionBBox(collection.BBox, feature.BBox)
+
+		for _, extra := range layerFeatures(f, layers) {
+			extra.Properties["flight_index"] = i
+			collection.Features = append(collection.Features, extra)
+		}
+	}
+
+	result, err := utils.MarshalJSON(collection, indent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal GeoJSON: %w", err)
+	}
+
+	return result, nil
+}
+
+// unionBBox returns the bbox covering both [west, south, east, north] boxes, either of
+// which may be nil
+func unionBBox(a, b []float64) []float64 {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return []float64{math.Min(a[0], b[0]), math.Min(a[1], b[1]), math.Max(a[2], b[2]), math.Max(a[3], b[3])}
+}
+
+// coordTimes formats the time of each track coordinate as RFC3339 in UTC
+func coordTimes(times []time.Time) []string {
+	formatted := make([]string, len(times))
+	for i, t := range times {
+		formatted[i] = t.UTC().Format(time.RFC3339)
+	}
+	return formatted
+}
+
+// buildFeature creates the LineString feature of a flight track and returns it with the
+// time of each coordinate
+func buildFeature(flight *flight.Flight, includeMetadata bool, speedWindow float64, smoothWindow time.Duration) (GeoJSONFeature, []time.Time, error) {
 	if len(flight.Fixes) == 0 {
-		return nil, fmt.Errorf("no GPS fixes found in flight data")
+		return GeoJSONFeature{}, nil, fmt.Errorf("no GPS fixes found in flight data")
+	}
+
+	fixes := flight.SmoothAltitude(smoothWindow).Fixes
+
+	// The track is 3D when the logger recorded any GPS altitude, so that fixes at
+	// exactly 0 m keep their altitude and all coordinates have the same dimension
+	hasAltitude := false
+	for _, fix := range fixes {
+		if fix.Valid() && fix.AltWGS84 != 0 {
+			hasAltitude = true
+			break
+		}
 	}
 
 	// Extract coordinates from B records
 	var coordinates [][]float64
-	for _, fix := range flight.Fixes {
+	var times []time.Time
+	for _, fix := range fixes {
 		if fix.Valid() {
 			// GeoJSON coordinates are [longitude, latitude, altitude]
 			coord := []float64{fix.Lon, fix.Lat}
-			if fix.AltWGS84 != 0 {
+			if hasAltitude {
 				coord = append(coord, fix.AltWGS84)
 			}
 			coordinates = append(coordinates, coord)
+			times = append(times, fix.Time)
 		}
 	}
 
 	if len(coordinates) == 0 {
-		return nil, fmt.Errorf("no valid GPS fixes found in flight data")
+		return GeoJSONFeature{}, nil, fmt.Errorf("no valid GPS fixes found in flight data")
 	}
 
+	// The extent in GeoJSON bbox order, [west, south, east, north]
+	minLat, minLon, maxLat, maxLon := flight.CalculateBoundingBox()
+	bbox := []float64{minLon, minLat, maxLon, maxLat}
+
 	// Create LineString geometry
 	geometry := GeoJSONGeometry{
 		Type:        "LineString",
@@ -76,36 +237,99 @@ func RenderToGeoJSON(flight *flight.Flight, pretty bool, includeMetadata bool) (
 		}
 
 		// Add flight statistics
-		stats := flight.GetStatistics(3.0) // Use 3 second speed window as default
-		properties["max_altitude"] = stats.MaxAltitude
-		properties["min_altitude"] = stats.MinAltitude
-		properties["max_ground_speed"] = stats.MaxGroundSpeed
-		properties["max_climb_rate"] = stats.MaxClimbRate
-		properties["max_descent_rate"] = stats.MaxDescentRate
-		properties["flight_duration_seconds"] = stats.FlightDuration.Seconds()
+		stats := flight.GetStatistics(metadataStatsOptions(speedWindow))
+		for key, value := range stats.AsMap(units.AltitudeMeters, units.SpeedKmh, units.ClimbMs) {
+			properties[key] = value
+		}
 		properties["total_fixes"] = len(coordinates)
+		properties["bbox"] = bbox
 	}
 
 	// Create feature
 	feature := GeoJSONFeature{
 		Type:       "Feature",
+		BBox:       bbox,
 		Geometry:   geometry,
 		Properties: properties,
 	}
 
-	// Marshal to JSON
-	var result []byte
-	var err error
+	return feature, times, nil
+}
 
-	if pretty {
-		result, err = json.MarshalIndent(feature, "", "  ")
-	} else {
-		result, err = json.Marshal(feature)
+// layerFeatures returns the extra features of a flight selected by layers
+func layerFeatures(f *flight.Flight, layers LayerOptions) []GeoJSONFeature {
+	var features []GeoJSONFeature
+	if layers.Markers {
+		features = append(features, markerFeatures(f)...)
+	}
+	if layers.Thermals {
+		features = append(features, thermalFeatures(f)...)
 	}
+	return features
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal GeoJSON: %w", err)
+// thermalFeatures returns a Point feature at the centroid of each thermal of the flight,
+// at the altitude where the climb ended, with its gain, climb rate and times
+func thermalFeatures(f *flight.Flight) []GeoJSONFeature {
+	thermals := f.DetectThermals()
+	features := make([]GeoJSONFeature, 0, len(thermals))
+
+	for _, thermal := range thermals {
+		class := thermalClasses[0]
+		for _, c := range thermalClasses {
+			if thermal.AvgClimbRate >= c.minRate {
+				class = c
+			}
+		}
+
+		features = append(features, GeoJSONFeature{
+			Type: "Feature",
+			Geometry: GeoJSONGeometry{
+				Type:        "Point",
+				Coordinates: []float64{thermal.Lon, thermal.Lat, f.Fixes[thermal.EndIndex].AltWGS84},
+			},
+			Properties: map[string]interface{}{
+				"feature_type":     "thermal",
+				"gain":             thermal.Gain,
+				"climb_rate":       thermal.AvgClimbRate,
+				"start_time":       thermal.StartTime.UTC().Format(time.RFC3339),
+				"end_time":         thermal.EndTime.UTC().Format(time.RFC3339),
+				"duration_seconds": thermal.EndTime.Sub(thermal.StartTime).Seconds(),
+				"marker-color":     class.color,
+				"marker-size":      class.size,
+			},
+		})
 	}
 
-	return result, nil
+	return features
+}
+
+// markerFeatures returns Point features for the takeoff and landing of the flight, as
+// found by flight.DetectTakeoffLanding, with their time and altitude
+func markerFeatures(f *flight.Flight) []GeoJSONFeature {
+	if len(f.Fixes) == 0 {
+		return nil
+	}
+	takeoff, landing := flight.DetectTakeoffLanding(f)
+
+	marker := func(markerType string, fix *igc.BRecord, color string) GeoJSONFeature {
+		return GeoJSONFeature{
+			Type: "Feature",
+			Geometry: GeoJSONGeometry{
+				Type:        "Point",
+				Coordinates: []float64{fix.Lon, fix.Lat, fix.AltWGS84},
+			},
+			Properties: map[string]interface{}{
+				"feature_type": markerType,
+				"time":         fix.Time.UTC().Format(time.RFC3339),
+				"altitude":     fix.AltWGS84,
+				"marker-color": color,
+			},
+		}
+	}
+
+	return []GeoJSONFeature{
+		marker("takeoff", f.Fixes[takeoff], "#1a9641"), // green
+		marker("landing", f.Fixes[landing], "#2c7bb6"), // blue
+	}
 }
@@ -0,0 +1,339 @@
+package geojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestRenderGaggleToGeoJSON(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	flights := []*flight.Flight{
+		{Pilot: "Pilot A", Fixes: []*igc.BRecord{
+			{Lat: 45.814, Lon: 6.246, Time: baseTime, AltWGS84: 1500},
+			{Lat: 45.815, Lon: 6.247, Time: baseTime.Add(time.Second), AltWGS84: 1510},
+		}},
+		{Pilot: "Pilot B", Fixes: []*igc.BRecord{
+			{Lat: 45.820, Lon: 6.250, Time: baseTime.Add(2 * time.Second), AltWGS84: 1600},
+		}},
+	}
+
+	data, err := RenderGaggleToGeoJSON(flights, "", false, 5, 0, LayerOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var collection struct {
+		Type     string    `json:"type"`
+		BBox     []float64 `json:"bbox"`
+		Features []struct {
+			Geometry struct {
+				Coordinates [][]float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties struct {
+				CoordTimes  []string `json:"coordTimes"`
+				FlightIndex int      `json:"flight_index"`
+				Color       string   `json:"color"`
+			} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatalf("failed to unmarshal GeoJSON: %v", err)
+	}
+
+	if collection.Type != "FeatureCollection" {
+		t.Errorf("expected FeatureCollection, got %s", collection.Type)
+	}
+	// The collection bbox covers both tracks
+	if bbox := fmt.Sprint(collection.BBox); bbox != "[6.246 45.814 6.25 45.82]" {
+		t.Errorf("expected bbox [6.246 45.814 6.25 45.82], got %s", bbox)
+	}
+	if len(collection.Features) != 2 {
+		t.Fatalf("expected 2 features, got %d", len(collection.Features))
+	}
+
+	first := collection.Features[0]
+	if len(first.Properties.CoordTimes) != len(first.Geometry.Coordinates) {
+		t.Errorf("expected one time per coordinate, got %d times for %d coordinates",
+			len(first.Properties.CoordTimes), len(first.Geometry.Coordinates))
+	}
+	if first.Properties.CoordTimes[1] != "2025-07-18T12:00:01Z" {
+		t.Errorf("expected second coordinate time 2025-07-18T12:00:01Z, got %s", first.Properties.CoordTimes[1])
+	}
+
+	for i, feature := range collection.Features {
+		if feature.Properties.FlightIndex != i {
+			t.Errorf("expected flight index %d, got %d", i, feature.Properties.FlightIndex)
+		}
+	}
+	if collection.Features[0].Properties.Color == collection.Features[1].Properties.Color {
+		t.Errorf("expected distinct colors, got %s for both", collection.Features[0].Properties.Color)
+	}
+
+	if _, err := RenderGaggleToGeoJSON([]*flight.Flight{flights[0], {}}, "", false, 5, 0, LayerOptions{}); err == nil {
+		t.Errorf("expected error for flight without fixes")
+	}
+}
+
+// climbingFlight returns a flight gliding for a minute, climbing at 1.5 m/s for two
+// minutes and gliding again, so it has a single thermal
+func climbingFlight() *flight.Flight {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	f := &flight.Flight{}
+	alt := 1500.0
+	for i := 0; i <= 240; i += 5 {
+		switch {
+		case i <= 60 || i > 180:
+			alt -= 5
+		default:
+			alt += 7.5
+		}
+		f.Fixes = append(f.Fixes, &igc.BRecord{
+			Lat: 45.8 + float64(i)*0.00001, Lon: 6.2, Time: baseTime.Add(time.Duration(i) * time.Second), AltWGS84: alt,
+		})
+	}
+	return f
+}
+
+func TestRenderToGeoJSONThermals(t *testing.T) {
+	f := climbingFlight()
+
+	// The track alone stays a single Feature
+	data, err := RenderToGeoJSON(f, "", false, 5, 0, LayerOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var feature GeoJSONFeature
+	if err := json.Unmarshal(data, &feature); err != nil || feature.Type != "Feature" {
+		t.Fatalf("expected a single Feature, got %s (%v)", data, err)
+	}
+
+	data, err = RenderToGeoJSON(f, "", false, 5, 0, LayerOptions{Thermals: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatalf("failed to unmarshal GeoJSON: %v", err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+		t.Fatalf("expected a FeatureCollection of track and thermal, got %s with %d features", collection.Type, len(collection.Features))
+	}
+
+	thermal := collection.Features[1]
+	var point []float64
+	if err := json.Unmarshal(thermal.Geometry.Coordinates, &point); err != nil || thermal.Geometry.Type != "Point" || len(point) != 3 {
+		t.Errorf("expected a 3D Point, got %s %s", thermal.Geometry.Type, thermal.Geometry.Coordinates)
+	}
+	if thermal.Properties["feature_type"] != "thermal" {
+		t.Errorf("expected feature_type thermal, got %v", thermal.Properties["feature_type"])
+	}
+	if rate := thermal.Properties["climb_rate"].(float64); rate < 1 || rate >= 2 {
+		t.Errorf("expected climb rate between 1 and 2 m/s, got %v", rate)
+	}
+	if thermal.Properties["marker-color"] != "#fc8d62" || thermal.Properties["marker-size"] != "medium" {
+		t.Errorf("expected medium orange marker, got %v %v", thermal.Properties["marker-color"], thermal.Properties["marker-size"])
+	}
+}
+
+func TestRenderToGeoJSONMarkers(t *testing.T) {
+	f := climbingFlight()
+
+	data, err := RenderToGeoJSON(f, "", false, 5, 0, LayerOptions{Markers: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var collection struct {
+		Type     string           `json:"type"`
+		Features []GeoJSONFeature `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatalf("failed to unmarshal GeoJSON: %v", err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 3 {
+		t.Fatalf("expected a FeatureCollection of track, takeoff and landing, got %s with %d features", collection.Type, len(collection.Features))
+	}
+
+	takeoff, landing := flight.DetectTakeoffLanding(f)
+	tests := []struct {
+		featureType string
+		fix         int
+	}{
+		{"takeoff", takeoff},
+		{"landing", landing},
+	}
+	for i, tt := range tests {
+		feature := collection.Features[i+1]
+		fix := f.Fixes[tt.fix]
+		if feature.Geometry.Type != "Point" {
+			t.Errorf("%s: expected Point geometry, got %s", tt.featureType, feature.Geometry.Type)
+		}
+		if feature.Properties["feature_type"] != tt.featureType {
+			t.Errorf("expected feature_type %s, got %v", tt.featureType, feature.Properties["feature_type"])
+		}
+		if feature.Properties["time"] != fix.Time.UTC().Format("2006-01-02T15:04:05Z07:00") {
+			t.Errorf("%s: expected time %v, got %v", tt.featureType, fix.Time, feature.Properties["time"])
+		}
+		if feature.Properties["altitude"] != fix.AltWGS84 {
+			t.Errorf("%s: expected altitude %v, got %v", tt.featureType, fix.AltWGS84, feature.Properties["altitude"])
+		}
+	}
+}
+
+func TestRenderToGeoJSONCoordTimes(t *testing.T) {
+	f := climbingFlight()
+
+	tests := []struct {
+		name       string
+		coordTimes bool
+	}{
+		{"default", false},
+		{"coord times", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := RenderToGeoJSON(f, "", false, 5, 0, LayerOptions{CoordTimes: tt.coordTimes})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var feature struct {
+				Geometry struct {
+					Coordinates [][]float64 `json:"coordinates"`
+				} `json:"geometry"`
+				Properties struct {
+					CoordTimes []string `json:"coordTimes"`
+				} `json:"properties"`
+			}
+			if err := json.Unmarshal(data, &feature); err != nil {
+				t.Fatalf("failed to unmarshal GeoJSON: %v", err)
+			}
+
+			if !tt.coordTimes {
+				if feature.Properties.CoordTimes != nil {
+					t.Errorf("expected no coordTimes, got %d", len(feature.Properties.CoordTimes))
+				}
+				return
+			}
+			if len(feature.Properties.CoordTimes) != len(feature.Geometry.Coordinates) {
+				t.Fatalf("expected %d coordTimes, got %d", len(feature.Geometry.Coordinates), len(feature.Properties.CoordTimes))
+			}
+			for _, want := range []int{0, len(f.Fixes) - 1} {
+				expected := f.Fixes[want].Time.UTC().Format("2006-01-02T15:04:05Z07:00")
+				if got := feature.Properties.CoordTimes[want]; got != expected {
+					t.Errorf("coordTimes[%d] = %s, expected %s", want, got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderToGeoJSONCoordinateDimension(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		altitudes []float64
+		dimension int
+	}{
+		{"sea level fix keeps its altitude", []float64{12, 0, 8}, 3},
+		{"starting at sea level", []float64{0, 5, 10}, 3},
+		{"no altitude recorded", []float64{0, 0, 0}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &flight.Flight{}
+			for i, alt := range tt.altitudes {
+				f.Fixes = append(f.Fixes, &igc.BRecord{
+					Lat: 43.5 + float64(i)*0.001, Lon: 7.0, Time: baseTime.Add(time.Duration(i) * time.Second), AltWGS84: alt,
+				})
+			}
+
+			data, err := RenderToGeoJSON(f, "", false, 5, 0, LayerOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var feature struct {
+				Geometry struct {
+					Coordinates [][]float64 `json:"coordinates"`
+				} `json:"geometry"`
+			}
+			if err := json.Unmarshal(data, &feature); err != nil {
+				t.Fatalf("failed to unmarshal GeoJSON: %v", err)
+			}
+
+			for i, coord := range feature.Geometry.Coordinates {
+				if len(coord) != tt.dimension {
+					t.Errorf("coordinate %d: expected %d values, got %v", i, tt.dimension, coord)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderToGeoJSONMetadataBoundingBox(t *testing.T) {
+	f := climbingFlight()
+
+	data, err := RenderToGeoJSON(f, "", true, 5, 0, LayerOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var feature struct {
+		Properties struct {
+			BBox []float64 `json:"bbox"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &feature); err != nil {
+		t.Fatalf("failed to unmarshal GeoJSON: %v", err)
+	}
+
+	minLat, minLon, maxLat, maxLon := f.CalculateBoundingBox()
+	expected := fmt.Sprint([]float64{minLon, minLat, maxLon, maxLat})
+	if bbox := fmt.Sprint(feature.Properties.BBox); bbox != expected {
+		t.Errorf("expected bbox %s, got %s", expected, bbox)
+	}
+}
+
+func TestRenderToGeoJSONBBoxMember(t *testing.T) {
+	f := climbingFlight()
+	minLat, minLon, maxLat, maxLon := f.CalculateBoundingBox()
+	expected := fmt.Sprint([]float64{minLon, minLat, maxLon, maxLat})
+
+	tests := []struct {
+		name   string
+		layers LayerOptions
+	}{
+		{"feature", LayerOptions{}},
+		{"feature collection", LayerOptions{Markers: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := RenderToGeoJSON(f, "", false, 5, 0, tt.layers)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var output struct {
+				BBox []float64 `json:"bbox"`
+			}
+			if err := json.Unmarshal(data, &output); err != nil {
+				t.Fatalf("failed to unmarshal GeoJSON: %v", err)
+			}
+			if bbox := fmt.Sprint(output.BBox); bbox != expected {
+				t.Errorf("expected bbox %s, got %s", expected, bbox)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package inspect
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"igc-tool/internal/flight"
+)
+
+// Header fields that can be inspected
+const (
+	FieldPilot        = "pilot"
+	FieldGliderType   = "glider-type"
+	FieldGliderID     = "glider-id"
+	FieldRecorderType = "recorder-type"
+)
+
+// Fields lists the header fields that can be inspected
+var Fields = []string{FieldPilot, FieldGliderType, FieldGliderID, FieldRecorderType}
+
+// ValueCount is a distinct header value and the number of flights it appears in
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// ValidateField checks that field is one of the supported header fields
+func ValidateField(field string) error {
+	_, err := HeaderValue(&flight.Flight{}, field)
+	return err
+}
+
+// HeaderValue returns the value of the named header field of a flight
+func HeaderValue(f *flight.Flight, field string) (string, error) {
+	switch field {
+	case FieldPilot:
+		return f.Pilot, nil
+	case FieldGliderType:
+		return f.GliderType, nil
+	case FieldGliderID:
+		return f.GliderID, nil
+	case FieldRecorderType:
+		return f.FlightRecorderType, nil
+	default:
+		return "", fmt.Errorf("unsupported field %q: must be one of %s", field, strings.Join(Fields, ", "))
+	}
+}
+
+// CountValues counts the distinct values, sorted by decreasing frequency and then
+// alphabetically. Values are compared exactly, so "John Doe" and "J. Doe" are
+// reported separately, which is the point when looking for inconsistencies.
+func CountValues(values []string) []ValueCount {
+	counts := make(map[string]int)
+	for _, value := range values {
+		counts[value]++
+	}
+
+	result := make([]ValueCount, 0, len(counts))
+	for value, count := range counts {
+		result = append(result, ValueCount{Value: value, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Value < result[j].Value
+	})
+
+	return result
+}
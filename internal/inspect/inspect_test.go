@@ -0,0 +1,78 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+
+	"igc-tool/internal/flight"
+)
+
+func TestHeaderValue(t *testing.T) {
+	f := &flight.Flight{
+		Pilot:              "John Doe",
+		GliderType:         "Ozone Enzo 3",
+		GliderID:           "D-1234",
+		FlightRecorderType: "XCTrack",
+	}
+
+	tests := []struct {
+		field       string
+		expected    string
+		expectError bool
+	}{
+		{field: FieldPilot, expected: "John Doe"},
+		{field: FieldGliderType, expected: "Ozone Enzo 3"},
+		{field: FieldGliderID, expected: "D-1234"},
+		{field: FieldRecorderType, expected: "XCTrack"},
+		{field: "crew", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			value, err := HeaderValue(f, tt.field)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if value != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, value)
+			}
+		})
+	}
+}
+
+func TestCountValues(t *testing.T) {
+	values := []string{"John Doe", "J. Doe", "John Doe", "", "Alice", "John Doe", "J. Doe"}
+
+	expected := []ValueCount{
+		{Value: "John Doe", Count: 3},
+		{Value: "J. Doe", Count: 2},
+		{Value: "", Count: 1},
+		{Value: "Alice", Count: 1},
+	}
+
+	result := CountValues(values)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+
+	if result := CountValues(nil); len(result) != 0 {
+		t.Errorf("expected no values, got %v", result)
+	}
+}
+
+func TestValidateField(t *testing.T) {
+	for _, field := range Fields {
+		if err := ValidateField(field); err != nil {
+			t.Errorf("expected field %q to be valid, got %v", field, err)
+		}
+	}
+	if err := ValidateField("crew"); err == nil {
+		t.Errorf("expected error for unsupported field")
+	}
+}
@@ -0,0 +1,115 @@
+package logbook
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"igc-tool/internal/units"
+)
+
+// TemplateFuncs are the functions available in logbook templates, in addition to the
+// text/template builtins such as printf. Functions taking a value take it last, so
+// they can be used in pipelines: {{.TrackDistance | number 1}}.
+var TemplateFuncs = map[string]interface{}{
+	// add and sub return an int when both operands are integers, a float64 otherwise
+	"add": func(a, b interface{}) (interface{}, error) { return arithmetic(a, b, 1) },
+	"sub": func(a, b interface{}) (interface{}, error) { return arithmetic(a, b, -1) },
+	// number formats a value with a fixed number of decimals
+	"number": func(decimals int, value interface{}) (string, error) {
+		f, err := toFloat(value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(f, 'f', decimals, 64), nil
+	},
+	// pad and padLeft pad a value with spaces to width characters, aligned left or right
+	"pad":     func(width int, value interface{}) string { return pad(width, fmt.Sprint(value), false) },
+	"padLeft": func(width int, value interface{}) string { return pad(width, fmt.Sprint(value), true) },
+	// altitude formats an altitude with its unit symbol, e.g. 1500m, or FL049 for flight
+	// levels: {{.MaxAltitude | altitude .AltitudeUnit}}
+	"altitude": func(symbol string, value interface{}) (string, error) {
+		v, ok := toInt(value)
+		if !ok {
+			return "", fmt.Errorf("invalid altitude %v: expected an integer", value)
+		}
+		if symbol == units.AltitudeSymbol(units.AltitudeFL) {
+			return units.FormatAltitudeValue(int(v), units.AltitudeFL), nil
+		}
+		return fmt.Sprintf("%d%s", v, symbol), nil
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// date reformats a YYYY-MM-DD date with a Go time layout, e.g. "02.01.2006"
+	"date": func(layout, date string) (string, error) {
+		t, err := time.Parse(DateLayout, date)
+		if err != nil {
+			return "", fmt.Errorf("invalid date %q: expected YYYY-MM-DD", date)
+		}
+		return t.Format(layout), nil
+	},
+}
+
+// arithmetic returns a + sign*b
+func arithmetic(a, b interface{}, sign int) (interface{}, error) {
+	x, ok1 := toInt(a)
+	y, ok2 := toInt(b)
+	if ok1 && ok2 {
+		return x + int64(sign)*y, nil
+	}
+
+	fx, err := toFloat(a)
+	if err != nil {
+		return nil, err
+	}
+	fy, err := toFloat(b)
+	if err != nil {
+		return nil, err
+	}
+	return fx + float64(sign)*fy, nil
+}
+
+// toInt converts an integer of any kind to int64
+func toInt(value interface{}) (int64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// toFloat converts a number, or a string holding one, to float64
+func toFloat(value interface{}) (float64, error) {
+	if i, ok := toInt(value); ok {
+		return float64(i), nil
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		if f, err := strconv.ParseFloat(v.String(), 64); err == nil {
+			return f, nil
+		}
+	}
+	return math.NaN(), fmt.Errorf("%v is not a number", value)
+}
+
+// pad pads s with spaces to width characters, on the left when right-aligning
+func pad(width int, s string, alignRight bool) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s
+	}
+	if alignRight {
+		return strings.Repeat(" ", n) + s
+	}
+	return s + strings.Repeat(" ", n)
+}
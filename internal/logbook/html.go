@@ -0,0 +1,50 @@
+package logbook
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// DefaultHTMLTemplate is the built-in --format html layout: a styled table of the
+// flights followed by the aggregate summary
+//
+//go:embed logbook.html
+var DefaultHTMLTemplate string
+
+// HTMLOptions controls the rendering of WriteHTML
+type HTMLOptions struct {
+	Template string // html/template text, DefaultHTMLTemplate when empty
+	// BOM prepends a UTF-8 byte order mark, as for CSV, for tools that do not read the
+	// charset declared in the page
+	BOM bool
+}
+
+// WriteHTML renders the logbook with the html/template text of the options, or with
+// DefaultHTMLTemplate when it is empty, and TemplateFuncs available. Unlike text
+// templates, values are escaped for their HTML context, so pilot and site names cannot
+// inject markup.
+func WriteHTML(w io.Writer, data *TemplateData, opts HTMLOptions) error {
+	templateText := opts.Template
+	if templateText == "" {
+		templateText = DefaultHTMLTemplate
+	}
+
+	tmpl, err := template.New("logbook").Option("missingkey=error").Funcs(template.FuncMap(TemplateFuncs)).Parse(templateText)
+	if err != nil {
+		return fmt.Errorf("failed to parse HTML template: %w", err)
+	}
+
+	if opts.BOM {
+		if _, err := io.WriteString(w, UTF8BOM); err != nil {
+			return fmt.Errorf("failed to write BOM: %w", err)
+		}
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to execute HTML template: %w", DescribeTemplateError(err))
+	}
+
+	return nil
+}
@@ -1,9 +1,15 @@
 package logbook
 
 import (
+	"encoding/csv"
 	"fmt"
+	"io"
 	"math"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"igc-tool/internal/config"
@@ -15,125 +21,408 @@ import (
 
 // Data represents the data structure used for logbook template rendering
 type Data struct {
-	Date               string
-	TakeoffLat         float64
-	TakeoffLon         float64
-	TakeoffPosition    string
-	TakeoffSite        string
-	LandingLat         float64
-	LandingLon         float64
-	LandingPosition    string
-	LandingSite        string
-	TakeoffAlt         int
-	LandingAlt         int
-	AltitudeDiff       int
-	MaxAltitude        int
-	MinAltitude        int
-	MaxGroundSpeed     int
-	MaxClimbRate       float64
-	MaxDescentRate     float64
-	FlightDuration     string
-	TakeoffTime        string
-	LandingTime        string
-	Pilot              string
-	Crew               string
-	GliderType         string
-	GliderID           string
-	CompetitionID      string
-	FlightRecorderType string
-	Filename           string
+	Date               string  `json:"date"`
+	TakeoffLat         float64 `json:"takeoff_lat"`
+	TakeoffLon         float64 `json:"takeoff_lon"`
+	TakeoffPosition    string  `json:"takeoff_position"`
+	TakeoffSite        string  `json:"takeoff_site"`
+	LandingLat         float64 `json:"landing_lat"`
+	LandingLon         float64 `json:"landing_lon"`
+	LandingPosition    string  `json:"landing_position"`
+	LandingSite        string  `json:"landing_site"`
+	TakeoffAlt         int     `json:"takeoff_alt"`
+	LandingAlt         int     `json:"landing_alt"`
+	AltitudeDiff       int     `json:"altitude_diff"`
+	MaxAltitude        int     `json:"max_altitude"`
+	MinAltitude        int     `json:"min_altitude"`
+	MaxAltitudeBaro    int     `json:"max_altitude_baro"` // pressure altitude, 0 without a pressure sensor
+	MinAltitudeBaro    int     `json:"min_altitude_baro"`
+	MaxGroundSpeed     int     `json:"max_ground_speed"`
+	StraightLineSpeed  float64 `json:"straight_line_speed"` // straight-line takeoff to landing distance per hour of flight
+	MaxClimbRate       float64 `json:"max_climb_rate"`      // one decimal in m/s, whole ft/min
+	MaxDescentRate     float64 `json:"max_descent_rate"`
+	MaxTurnRate        float64 `json:"max_turn_rate"`      // degrees per second
+	BiggestClimbGain   int     `json:"biggest_climb_gain"` // largest altitude gain in a single thermal
+	BiggestClimbTime   string  `json:"biggest_climb_time"`
+	TotalClimb         int     `json:"total_climb"`    // sum of altitude gains, see flight.CalculateTotalClimb
+	TaskDistance       float64 `json:"task_distance"`  // declared task distance, 0 without a declaration
+	TaskAchieved       float64 `json:"task_achieved"`  // task distance flown, see flight.CalculateTaskCompletion
+	TaskPercent        float64 `json:"task_percent"`   // share of the task distance flown, 0 without a declaration
+	TaskCompleted      bool    `json:"task_completed"` // every declared waypoint was reached in order
+	TrackDistance      float64 `json:"track_distance"` // length of the track
+	OpenDistance       float64 `json:"open_distance"`  // straight-line distance from takeoff to landing
+	GlideRatio         float64 `json:"glide_ratio"`    // open distance per meter of altitude lost, 0 without a net loss
+	FlightDuration     string  `json:"flight_duration"`
+	MovingTime         string  `json:"moving_time"`
+	ClimbPercent       float64 `json:"climb_percent"` // share of airtime spent climbing, see flight.VerticalTimeBreakdown
+	SinkPercent        float64 `json:"sink_percent"`
+	LevelPercent       float64 `json:"level_percent"`
+	TakeoffTime        string  `json:"takeoff_time"`
+	LandingTime        string  `json:"landing_time"`
+	Pilot              string  `json:"pilot"`
+	Crew               string  `json:"crew"`
+	GliderType         string  `json:"glider_type"`
+	GliderID           string  `json:"glider_id"`
+	CompetitionID      string  `json:"competition_id"`
+	FlightRecorderType string  `json:"flight_recorder_type"`
+	Filename           string  `json:"filename"`
 	// Unit symbols for formatting
-	AltitudeUnit      string
-	SpeedUnit         string
-	VerticalSpeedUnit string // Unit for climb/descent rates
+	AltitudeUnit      string `json:"altitude_unit"`
+	HeightUnit        string `json:"height_unit"` // Unit for AltitudeDiff, BiggestClimbGain and TotalClimb, ft with flight levels
+	SpeedUnit         string `json:"speed_unit"`
+	VerticalSpeedUnit string `json:"vertical_speed_unit"` // Unit for climb/descent rates
+	DistanceUnit      string `json:"distance_unit"`
+	// InsufficientData is set when the flight has fewer fixes than Options.MinFixes.
+	// Statistics-derived fields (altitude extremes, speeds, rates, moving time,
+	// vertical profile, biggest climb) are then left at zero and should not be shown.
+	InsufficientData bool `json:"insufficient_data"`
+
+	// Raw values behind the formatted fields, used for sorting and aggregation
+	takeoff  time.Time
+	duration time.Duration // takeoff to landing, FlightDuration before formatting
 }
 
 // TemplateData represents the complete data structure for template rendering
 // including individual flights and aggregated statistics
 type TemplateData struct {
-	Flights        []*Data
-	TotalTime      string
-	TotalFlights   int
-	FirstDate      string
-	LastDate       string
-	TotalDistance  float64
-	AvgFlightTime  string
-	MaxFlightTime  string
-	MinFlightTime  string
-	MaxAltitude    int
-	AvgMaxAltitude int
-	UniquePilots   []string
-	UniqueGliders  []string
-	UniqueSites    []string
+	Flights           []*Data  `json:"flights"`
+	TotalTime         string   `json:"total_time"`
+	TotalFlights      int      `json:"total_flights"`
+	FirstDate         string   `json:"first_date"`
+	LastDate          string   `json:"last_date"`
+	TotalDistance     float64  `json:"total_distance"`      // sum of track distances
+	TotalOpenDistance float64  `json:"total_open_distance"` // sum of straight-line takeoff to landing distances
+	AvgDistance       float64  `json:"avg_distance"`        // average track distance per flight
+	MaxDistance       float64  `json:"max_distance"`        // longest single track distance
+	AvgFlightTime     string   `json:"avg_flight_time"`
+	MaxFlightTime     string   `json:"max_flight_time"`
+	MinFlightTime     string   `json:"min_flight_time"`
+	MaxAltitude       int      `json:"max_altitude"`
+	AvgMaxAltitude    int      `json:"avg_max_altitude"`
+	UniquePilots      []string `json:"unique_pilots"`  // sorted, the order of ByPilot in templates
+	UniqueGliders     []string `json:"unique_gliders"` // sorted, the order of ByGlider in templates
+	UniqueSites       []string `json:"unique_sites"`
+	// ByPilot holds the totals of each named pilot
+	ByPilot map[string]*PilotSummary `json:"by_pilot"`
+	// ByGlider holds the totals of each glider type
+	ByGlider map[string]*GliderSummary `json:"by_glider"`
 	// Unit symbols for formatting
-	AltitudeUnit      string
-	SpeedUnit         string
-	VerticalSpeedUnit string
+	AltitudeUnit      string `json:"altitude_unit"`
+	SpeedUnit         string `json:"speed_unit"`
+	VerticalSpeedUnit string `json:"vertical_speed_unit"`
+	DistanceUnit      string `json:"distance_unit"`
+
+	// Raw durations behind the formatted flight times, used for the aggregates
+	totalTime     time.Duration
+	avgFlightTime time.Duration
+	maxFlightTime time.Duration
+	minFlightTime time.Duration
 }
 
+// PilotSummary holds the logbook totals of a single pilot
+type PilotSummary struct {
+	Flights       int     `json:"flights"`
+	TotalTime     string  `json:"total_time"`
+	TotalDistance float64 `json:"total_distance"` // sum of track distances
+	MaxAltitude   int     `json:"max_altitude"`   // highest altitude of flights with sufficient data
+
+	duration time.Duration
+}
+
+// GliderSummary holds the logbook totals of a single glider type
+type GliderSummary struct {
+	Flights   int    `json:"flights"`
+	TotalTime string `json:"total_time"`
+
+	duration time.Duration
+}
+
+// Special --format values selecting a built-in output instead of a template
+const (
+	FormatCSV        = "csv"         // individual flights as CSV
+	FormatJSON       = "json"        // flights and aggregates as a JSON object, see JSONSchema
+	FormatJSONSchema = "json-schema" // JSON Schema of the logbook data, no files needed
+	FormatHTML       = "html"        // styled HTML page, see WriteHTML
+)
+
+// CSVOptions controls the CSV dialect used when writing logbook entries
+type CSVOptions struct {
+	Delimiter    rune // field separator, e.g. ';' for European spreadsheets
+	DecimalComma bool // write decimals as "1,5" instead of "1.5"
+	// BOM prepends a UTF-8 byte order mark so Excel on Windows detects the encoding.
+	// It is opt-in because many non-Excel CSV parsers treat it as part of the first header.
+	BOM bool
+}
+
+// Output formats of --stats-only
+const (
+	StatsFormatKeyValue = "kv"   // one key=value line per aggregate
+	StatsFormatJSON     = "json" // a flat JSON object
+)
+
+// Aggregate is a named value of the logbook summary
+type Aggregate struct {
+	Key   string
+	Value interface{}
+}
+
+// DateLayout is the format of logbook dates and of the --since and --until filters
+const DateLayout = "2006-01-02"
+
+// DateFilter selects flights by date; a zero bound leaves that side of the range open
+type DateFilter struct {
+	Since time.Time // first included date
+	Until time.Time // last included date
+}
+
+// ParseDateFilter parses the YYYY-MM-DD since and until dates, either of which may be
+// empty, into a DateFilter
+func ParseDateFilter(since, until string) (DateFilter, error) {
+	var filter DateFilter
+	var err error
+
+	if since != "" {
+		if filter.Since, err = time.Parse(DateLayout, since); err != nil {
+			return DateFilter{}, fmt.Errorf("invalid since date %q: expected YYYY-MM-DD", since)
+		}
+	}
+	if until != "" {
+		if filter.Until, err = time.Parse(DateLayout, until); err != nil {
+			return DateFilter{}, fmt.Errorf("invalid until date %q: expected YYYY-MM-DD", until)
+		}
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return DateFilter{}, fmt.Errorf("until date %s is before since date %s", until, since)
+	}
+
+	return filter, nil
+}
+
+// Includes reports whether a flight on the given date passes the filter. Both bounds are
+// inclusive; flights without a date only pass a filter without bounds.
+func (d DateFilter) Includes(date time.Time) bool {
+	if d.Since.IsZero() && d.Until.IsZero() {
+		return true
+	}
+	if date.IsZero() {
+		return false
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if !d.Since.IsZero() && day.Before(d.Since) {
+		return false
+	}
+	if !d.Until.IsZero() && day.After(d.Until) {
+		return false
+	}
+	return true
+}
+
+// FilterMinDuration returns the flights lasting at least minDuration from takeoff to
+// landing, and the number of flights dropped. A zero minDuration keeps every flight.
+func FilterMinDuration(flights []*Data, minDuration time.Duration) ([]*Data, int) {
+	if minDuration <= 0 {
+		return flights, 0
+	}
+
+	kept := make([]*Data, 0, len(flights))
+	for _, flight := range flights {
+		if flight.duration >= minDuration {
+			kept = append(kept, flight)
+		}
+	}
+	return kept, len(flights) - len(kept)
+}
+
+// Sort keys accepted by SortFlights
+const (
+	SortDate        = "date"         // takeoff time
+	SortDuration    = "duration"     // takeoff to landing
+	SortMaxAltitude = "max-altitude" // highest altitude reached
+	SortDistance    = "distance"     // track length
+)
+
+// ValidateSortKey checks that key is empty (input order) or one of the known sort keys
+func ValidateSortKey(key string) error {
+	switch key {
+	case "", SortDate, SortDuration, SortMaxAltitude, SortDistance:
+		return nil
+	default:
+		return fmt.Errorf("invalid sort key %q: must be %s, %s, %s or %s", key, SortDate, SortDuration, SortMaxAltitude, SortDistance)
+	}
+}
+
+// SortFlights sorts flights in ascending order of the given key, or descending when
+// reverse is set. Flights with equal keys keep their input order. An empty key keeps
+// the input order, or reverses it with reverse.
+func SortFlights(flights []*Data, key string, reverse bool) error {
+	if err := ValidateSortKey(key); err != nil {
+		return err
+	}
+
+	if key == "" {
+		if reverse {
+			for i, j := 0, len(flights)-1; i < j; i, j = i+1, j-1 {
+				flights[i], flights[j] = flights[j], flights[i]
+			}
+		}
+		return nil
+	}
+
+	var less func(a, b *Data) bool
+	switch key {
+	case SortDate:
+		less = func(a, b *Data) bool { return a.takeoff.Before(b.takeoff) }
+	case SortDuration:
+		less = func(a, b *Data) bool { return a.duration < b.duration }
+	case SortMaxAltitude:
+		less = func(a, b *Data) bool { return a.MaxAltitude < b.MaxAltitude }
+	case SortDistance:
+		less = func(a, b *Data) bool { return a.TrackDistance < b.TrackDistance }
+	}
+
+	sort.SliceStable(flights, func(i, j int) bool {
+		if reverse {
+			return less(flights[j], flights[i])
+		}
+		return less(flights[i], flights[j])
+	})
+	return nil
+}
+
+// UTF8BOM is the UTF-8 encoded byte order mark
+const UTF8BOM = "\uFEFF"
+
 // Options holds configuration for creating logbook data
 type Options struct {
-	LandingSites *sites.Collection
-	Filename     string
-	SpeedWindow  float64
-	AltitudeUnit string
-	SpeedUnit    string
-	ClimbUnit    string
-	TimeFormat   string
+	LandingSites   *sites.Collection
+	Filename       string
+	SpeedWindow    float64
+	LevelThreshold float64
+	MinFixes       int           // flights with fewer fixes are marked InsufficientData
+	ClimbNoise     float64       // altitude change in meters treated as sensor noise
+	SmoothWindow   time.Duration // moving-average window over altitudes for climb and descent rates
+	AltitudeUnit   string
+	SpeedUnit      string
+	ClimbUnit      string
+	DistanceUnit   string
+	TimeFormat     string
+	// CoordFormat and CoordPrecision format positions and the takeoff and landing
+	// sites outside every known site, see utils.FormatPosition. A zero precision uses
+	// utils.DefaultCoordPrecision.
+	CoordFormat    string
+	CoordPrecision int
+	// NearSiteDistance annotates takeoffs and landings outside every site but within this
+	// many meters of one as "near <site> (<distance>m)"; 0 disables the annotation
+	NearSiteDistance float64
+	// TurnpointRadius is the radius in meters of the turnpoint cylinders for the task
+	// completion; 0 uses flight.DefaultTurnpointRadius
+	TurnpointRadius float64
+	// DetectTakeoffLanding uses flight.DetectTakeoffLanding for the takeoff and landing
+	// fixes instead of the first and last fix, excluding ground time from the duration
+	DetectTakeoffLanding bool
 }
 
-// CreateData creates logbook data from a flight using the provided options
+// CreateData creates logbook data from a flight using the provided options.
+// Flights without fixes yield nil and are skipped; flights with fewer than
+// opts.MinFixes fixes are kept but marked InsufficientData.
 func CreateData(f *flight.Flight, opts Options) *Data {
 	if len(f.Fixes) == 0 {
 		return nil
 	}
 
-	takeoffFix := f.Fixes[0]
-	landingFix := f.Fixes[len(f.Fixes)-1]
-	duration := landingFix.Time.Sub(takeoffFix.Time)
+	takeoffIdx, landingIdx := 0, len(f.Fixes)-1
+	if opts.DetectTakeoffLanding {
+		takeoffIdx, landingIdx = flight.DetectTakeoffLanding(f)
+	}
+	takeoffFix := f.Fixes[takeoffIdx]
+	landingFix := f.Fixes[landingIdx]
+	duration := max(landingFix.Time.Sub(takeoffFix.Time), 0) // clamped when the logger clock ran backward
 	altitudeDiff := int(landingFix.AltWGS84) - int(takeoffFix.AltWGS84)
 
-	// Calculate flight statistics
-	stats := f.GetStatistics(opts.SpeedWindow)
+	// Calculate flight statistics, unless there are too few fixes for them to be meaningful
+	insufficientData := len(f.Fixes) < opts.MinFixes
+	stats := &flight.Statistics{}
+	if !insufficientData {
+		stats = f.GetStatistics(flight.StatsOptions{
+			SpeedWindow:    opts.SpeedWindow,
+			LevelThreshold: opts.LevelThreshold,
+			ClimbNoise:     opts.ClimbNoise,
+			SmoothWindow:   opts.SmoothWindow,
+		})
+	}
+	climbPercent, sinkPercent, levelPercent := stats.VerticalTimePercentages()
 
 	// Determine takeoff and landing sites
-	takeoffSite := utils.FormatCoordinates(takeoffFix.Lat, takeoffFix.Lon)
-	landingSite := utils.FormatCoordinates(landingFix.Lat, landingFix.Lon)
+	takeoffSite := opts.formatPosition(takeoffFix.Lat, takeoffFix.Lon)
+	landingSite := opts.formatPosition(landingFix.Lat, landingFix.Lon)
 
 	if opts.LandingSites != nil {
-		takeoffSite = opts.LandingSites.FindLandingSite(takeoffFix.Lat, takeoffFix.Lon)
-		landingSite = opts.LandingSites.FindLandingSite(landingFix.Lat, landingFix.Lon)
+		takeoffSite = findSite(opts, takeoffFix.Lat, takeoffFix.Lon)
+		landingSite = findSite(opts, landingFix.Lat, landingFix.Lon)
+	}
+
+	var biggestClimbTime string
+	if stats.BiggestClimbGain > 0 {
+		biggestClimbTime = utils.FormatTime(stats.BiggestClimbTime, opts.TimeFormat)
+	}
+
+	var taskAchieved, taskPercent float64
+	var taskCompleted bool
+	if completion := f.CalculateTaskCompletion(opts.TurnpointRadius); completion != nil {
+		taskAchieved = math.Round(units.Distance(completion.AchievedDistance, opts.DistanceUnit)*10) / 10
+		taskPercent = math.Round(completion.Percentage*10) / 10
+		taskCompleted = completion.Completed
 	}
 
 	// Apply unit conversions
 	takeoffAltConverted := int(units.Altitude(float64(takeoffFix.AltWGS84), opts.AltitudeUnit))
 	landingAltConverted := int(units.Altitude(float64(landingFix.AltWGS84), opts.AltitudeUnit))
-	altitudeDiffConverted := int(units.Altitude(float64(altitudeDiff), opts.AltitudeUnit))
+	altitudeDiffConverted := int(units.Height(float64(altitudeDiff), opts.AltitudeUnit))
 	maxAltitudeConverted := int(units.Altitude(float64(stats.MaxAltitude), opts.AltitudeUnit))
 	minAltitudeConverted := int(units.Altitude(float64(stats.MinAltitude), opts.AltitudeUnit))
 	maxGroundSpeedConverted := int(math.Round(units.Speed(stats.MaxGroundSpeed, opts.SpeedUnit)))
-	maxClimbRateConverted := math.Round(units.Climb(stats.MaxClimbRate, opts.ClimbUnit))
-	maxDescentRateConverted := math.Round(units.Climb(stats.MaxDescentRate, opts.ClimbUnit))
+	climbDecimals := units.ClimbDecimals(opts.ClimbUnit)
+	maxClimbRateConverted := units.Round(units.Climb(stats.MaxClimbRate, opts.ClimbUnit), climbDecimals)
+	maxDescentRateConverted := units.Round(units.Climb(stats.MaxDescentRate, opts.ClimbUnit), climbDecimals)
 
 	return &Data{
 		Date:               f.Date.Format("2006-01-02"),
 		TakeoffLat:         takeoffFix.Lat,
 		TakeoffLon:         takeoffFix.Lon,
-		TakeoffPosition:    utils.FormatCoordinates(takeoffFix.Lat, takeoffFix.Lon),
+		TakeoffPosition:    opts.formatPosition(takeoffFix.Lat, takeoffFix.Lon),
 		TakeoffSite:        takeoffSite,
 		LandingLat:         landingFix.Lat,
 		LandingLon:         landingFix.Lon,
-		LandingPosition:    utils.FormatCoordinates(landingFix.Lat, landingFix.Lon),
+		LandingPosition:    opts.formatPosition(landingFix.Lat, landingFix.Lon),
 		LandingSite:        landingSite,
 		TakeoffAlt:         takeoffAltConverted,
 		LandingAlt:         landingAltConverted,
 		AltitudeDiff:       altitudeDiffConverted,
 		MaxAltitude:        maxAltitudeConverted,
 		MinAltitude:        minAltitudeConverted,
+		MaxAltitudeBaro:    int(units.Altitude(float64(stats.MaxAltitudeBaro), opts.AltitudeUnit)),
+		MinAltitudeBaro:    int(units.Altitude(float64(stats.MinAltitudeBaro), opts.AltitudeUnit)),
 		MaxGroundSpeed:     maxGroundSpeedConverted,
+		StraightLineSpeed:  math.Round(units.Speed(stats.StraightLineSpeed, opts.SpeedUnit)*10) / 10,
 		MaxClimbRate:       maxClimbRateConverted,
 		MaxDescentRate:     maxDescentRateConverted,
+		MaxTurnRate:        math.Round(stats.MaxTurnRate),
+		BiggestClimbGain:   int(units.Height(stats.BiggestClimbGain, opts.AltitudeUnit)),
+		BiggestClimbTime:   biggestClimbTime,
+		TotalClimb:         int(units.Height(stats.TotalClimb, opts.AltitudeUnit)),
+		TaskDistance:       math.Round(units.Distance(f.CalculateTaskDistance(), opts.DistanceUnit)*10) / 10,
+		TaskAchieved:       taskAchieved,
+		TaskPercent:        taskPercent,
+		TaskCompleted:      taskCompleted,
+		TrackDistance:      math.Round(units.Distance(f.CalculateTrackDistance(), opts.DistanceUnit)*10) / 10,
+		OpenDistance:       math.Round(units.Distance(stats.OpenDistance, opts.DistanceUnit)*10) / 10,
+		GlideRatio:         math.Round(stats.GlideRatio*10) / 10,
 		FlightDuration:     utils.FormatDuration(duration),
+		MovingTime:         utils.FormatDuration(stats.MovingTime),
+		ClimbPercent:       math.Round(climbPercent*10) / 10,
+		SinkPercent:        math.Round(sinkPercent*10) / 10,
+		LevelPercent:       math.Round(levelPercent*10) / 10,
 		TakeoffTime:        utils.FormatTime(takeoffFix.Time, opts.TimeFormat),
 		LandingTime:        utils.FormatTime(landingFix.Time, opts.TimeFormat),
 		Pilot:              f.Pilot,
@@ -144,8 +433,13 @@ func CreateData(f *flight.Flight, opts Options) *Data {
 		FlightRecorderType: f.FlightRecorderType,
 		Filename:           opts.Filename,
 		AltitudeUnit:       units.AltitudeSymbol(opts.AltitudeUnit),
+		HeightUnit:         units.AltitudeSymbol(units.HeightUnit(opts.AltitudeUnit)),
 		SpeedUnit:          units.SpeedSymbol(opts.SpeedUnit),
 		VerticalSpeedUnit:  units.ClimbSymbol(opts.ClimbUnit),
+		DistanceUnit:       units.DistanceSymbol(opts.DistanceUnit),
+		InsufficientData:   insufficientData,
+		takeoff:            takeoffFix.Time,
+		duration:           duration,
 	}
 }
 
@@ -181,16 +475,162 @@ func GetTemplateDataFields() []string {
 	return fields
 }
 
+// unknownFieldPattern matches the execution error of text/template and html/template
+// for a field that does not exist, e.g. "can't evaluate field Pilots in type *logbook.Data"
+var unknownFieldPattern = regexp.MustCompile(`can't evaluate field (\w+) in type \*?logbook\.(\w+)`)
+
+// templateTypes are the logbook types reachable from TemplateData in templates
+var templateTypes = map[string]reflect.Type{
+	"TemplateData":  reflect.TypeOf(TemplateData{}),
+	"Data":          reflect.TypeOf(Data{}),
+	"PilotSummary":  reflect.TypeOf(PilotSummary{}),
+	"GliderSummary": reflect.TypeOf(GliderSummary{}),
+}
+
+// DescribeTemplateError rewrites the opaque error of a template referencing a field
+// that does not exist into one naming the field and listing the available ones. Other
+// errors are returned unchanged.
+func DescribeTemplateError(err error) error {
+	match := unknownFieldPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	t, ok := templateTypes[match[2]]
+	if !ok {
+		return err
+	}
+
+	var available []string
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			available = append(available, t.Field(i).Name)
+		}
+	}
+	return fmt.Errorf("unknown field %s in %s, available: %s (%w)", match[1], match[2], strings.Join(available, ", "), err)
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing TemplateData, with Data
+// as the type of the Flights items. It is derived by reflection so it stays in sync
+// with the structs, like GetDataFields. Fields are named after their json tag when
+// present, and every field without omitempty is listed as required (always present).
+func JSONSchema() map[string]interface{} {
+	schema := schemaForType(reflect.TypeOf(TemplateData{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "igc-tool logbook"
+	return schema
+}
+
+// schemaForType returns the JSON Schema of a Go type
+func schemaForType(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaForType(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			// Only include exported fields (fields that start with uppercase)
+			if field.PkgPath != "" {
+				continue
+			}
+			name, omitEmpty, skip := jsonFieldName(field)
+			if skip {
+				continue
+			}
+			properties[name] = schemaForType(field.Type)
+			if !omitEmpty {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// jsonFieldName returns the name encoding/json uses for a struct field, whether it
+// is omitted when empty, and whether it is skipped entirely
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = field.Name
+	if parts[0] != "" {
+		name = parts[0]
+	}
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// findSite returns the name of the landing site at the given coordinates, or when
+// opts.NearSiteDistance is set and no site contains them, "near <site> (<distance>m)"
+// for the closest site within that distance
+func findSite(opts Options, lat, lon float64) string {
+	if opts.NearSiteDistance > 0 {
+		site, distance, inside := opts.LandingSites.FindNearestLandingSite(lat, lon)
+		if !inside && site.Name != "" && distance <= opts.NearSiteDistance {
+			return fmt.Sprintf("near %s (%.0fm)", site.Name, distance)
+		}
+	}
+	if name, ok := opts.LandingSites.Lookup(lat, lon); ok {
+		return name
+	}
+	return opts.formatPosition(lat, lon)
+}
+
+// formatPosition formats coordinates in the configured coordinate format
+func (opts Options) formatPosition(lat, lon float64) string {
+	precision := opts.CoordPrecision
+	if precision == 0 {
+		precision = utils.DefaultCoordPrecision
+	}
+	return utils.FormatPosition(lat, lon, opts.CoordFormat, precision)
+}
+
 // CreateOptions creates Options from config
 func CreateOptions(cfg *config.Config, landingSites *sites.Collection, filename string) Options {
 	return Options{
-		LandingSites: landingSites,
-		Filename:     filename,
-		SpeedWindow:  cfg.SpeedWindow,
-		AltitudeUnit: cfg.AltitudeUnit,
-		SpeedUnit:    cfg.SpeedUnit,
-		ClimbUnit:    cfg.ClimbUnit,
-		TimeFormat:   cfg.TimeFormat,
+		LandingSites:    landingSites,
+		Filename:        filename,
+		SpeedWindow:     cfg.SpeedWindow,
+		LevelThreshold:  cfg.LevelThreshold,
+		MinFixes:        cfg.MinFixes,
+		ClimbNoise:      cfg.ClimbNoise,
+		SmoothWindow:    time.Duration(cfg.SmoothWindow * float64(time.Second)),
+		AltitudeUnit:    cfg.AltitudeUnit,
+		SpeedUnit:       cfg.SpeedUnit,
+		ClimbUnit:       cfg.ClimbUnit,
+		DistanceUnit:    cfg.DistanceUnit,
+		TimeFormat:      cfg.TimeFormat,
+		CoordFormat:     cfg.CoordFormat,
+		CoordPrecision:  cfg.CoordPrecision,
+		TurnpointRadius: cfg.TurnpointRadius,
 	}
 }
 
@@ -200,50 +640,76 @@ func CreateTemplateData(flights []*Data, opts Options) *TemplateData {
 		return &TemplateData{
 			Flights:           []*Data{},
 			TotalFlights:      0,
+			ByPilot:           map[string]*PilotSummary{},
+			ByGlider:          map[string]*GliderSummary{},
 			AltitudeUnit:      units.AltitudeSymbol(opts.AltitudeUnit),
 			SpeedUnit:         units.SpeedSymbol(opts.SpeedUnit),
 			VerticalSpeedUnit: units.ClimbSymbol(opts.ClimbUnit),
+			DistanceUnit:      units.DistanceSymbol(opts.DistanceUnit),
 		}
 	}
 
 	// Calculate aggregated statistics
 	var totalDuration time.Duration
 	var totalAltitude int
+	var totalDistance, totalOpenDistance, maxDistance float64
+	var altitudeFlights int
 	var maxAltitude int
 	var maxDuration time.Duration
-	var minDuration time.Duration = time.Hour * 24 // Start with a large value
+	var minDuration time.Duration
 
-	pilots := make(map[string]bool)
-	gliders := make(map[string]bool)
+	pilots := make(map[string]*PilotSummary)
+	gliders := make(map[string]*GliderSummary)
 	sites := make(map[string]bool)
 
 	var firstDate, lastDate time.Time
 
 	for i, flight := range flights {
-		// Parse flight duration
-		duration, err := parseDuration(flight.FlightDuration)
-		if err == nil {
-			totalDuration += duration
-			if duration > maxDuration {
-				maxDuration = duration
-			}
-			if duration < minDuration {
-				minDuration = duration
-			}
+		totalDuration += flight.duration
+		if flight.duration > maxDuration {
+			maxDuration = flight.duration
+		}
+		if i == 0 || flight.duration < minDuration {
+			minDuration = flight.duration
+		}
+
+		totalDistance += flight.TrackDistance
+		totalOpenDistance += flight.OpenDistance
+		if flight.TrackDistance > maxDistance {
+			maxDistance = flight.TrackDistance
 		}
 
-		// Track altitude statistics
-		totalAltitude += flight.MaxAltitude
-		if flight.MaxAltitude > maxAltitude {
-			maxAltitude = flight.MaxAltitude
+		// Track altitude statistics, skipping flights too sparse for reliable values
+		if !flight.InsufficientData {
+			totalAltitude += flight.MaxAltitude
+			altitudeFlights++
+			if flight.MaxAltitude > maxAltitude {
+				maxAltitude = flight.MaxAltitude
+			}
 		}
 
 		// Track unique values
 		if flight.Pilot != "" {
-			pilots[flight.Pilot] = true
+			summary := pilots[flight.Pilot]
+			if summary == nil {
+				summary = &PilotSummary{}
+				pilots[flight.Pilot] = summary
+			}
+			summary.Flights++
+			summary.duration += flight.duration
+			summary.TotalDistance += flight.TrackDistance
+			if !flight.InsufficientData && flight.MaxAltitude > summary.MaxAltitude {
+				summary.MaxAltitude = flight.MaxAltitude
+			}
 		}
 		if flight.GliderType != "" {
-			gliders[flight.GliderType] = true
+			summary := gliders[flight.GliderType]
+			if summary == nil {
+				summary = &GliderSummary{}
+				gliders[flight.GliderType] = summary
+			}
+			summary.Flights++
+			summary.duration += flight.duration
 		}
 		if flight.TakeoffSite != "" {
 			sites[flight.TakeoffSite] = true
@@ -262,14 +728,19 @@ func CreateTemplateData(flights []*Data, opts Options) *TemplateData {
 
 	// Convert maps to slices
 	uniquePilots := make([]string, 0, len(pilots))
-	for pilot := range pilots {
+	for pilot, summary := range pilots {
 		uniquePilots = append(uniquePilots, pilot)
+		summary.TotalTime = utils.FormatDuration(summary.duration)
+		summary.TotalDistance = math.Round(summary.TotalDistance*10) / 10
 	}
+	sort.Strings(uniquePilots)
 
 	uniqueGliders := make([]string, 0, len(gliders))
-	for glider := range gliders {
+	for glider, summary := range gliders {
 		uniqueGliders = append(uniqueGliders, glider)
+		summary.TotalTime = utils.FormatDuration(summary.duration)
 	}
+	sort.Strings(uniqueGliders)
 
 	uniqueSites := make([]string, 0, len(sites))
 	for site := range sites {
@@ -278,11 +749,9 @@ func CreateTemplateData(flights []*Data, opts Options) *TemplateData {
 
 	// Calculate averages
 	avgFlightTime := totalDuration / time.Duration(len(flights))
-	avgMaxAltitude := totalAltitude / len(flights)
-
-	// Handle edge cases for min duration
-	if minDuration == time.Hour*24 {
-		minDuration = 0
+	var avgMaxAltitude int
+	if altitudeFlights > 0 {
+		avgMaxAltitude = totalAltitude / altitudeFlights
 	}
 
 	return &TemplateData{
@@ -291,27 +760,135 @@ func CreateTemplateData(flights []*Data, opts Options) *TemplateData {
 		TotalFlights:      len(flights),
 		FirstDate:         firstDate.Format("2006-01-02"),
 		LastDate:          lastDate.Format("2006-01-02"),
+		TotalDistance:     math.Round(totalDistance*10) / 10,
+		TotalOpenDistance: math.Round(totalOpenDistance*10) / 10,
+		AvgDistance:       math.Round(totalDistance/float64(len(flights))*10) / 10,
+		MaxDistance:       maxDistance,
 		AvgFlightTime:     utils.FormatDuration(avgFlightTime),
 		MaxFlightTime:     utils.FormatDuration(maxDuration),
 		MinFlightTime:     utils.FormatDuration(minDuration),
 		MaxAltitude:       maxAltitude,
 		AvgMaxAltitude:    avgMaxAltitude,
 		UniquePilots:      uniquePilots,
+		ByPilot:           pilots,
+		ByGlider:          gliders,
 		UniqueGliders:     uniqueGliders,
 		UniqueSites:       uniqueSites,
 		AltitudeUnit:      units.AltitudeSymbol(opts.AltitudeUnit),
 		SpeedUnit:         units.SpeedSymbol(opts.SpeedUnit),
 		VerticalSpeedUnit: units.ClimbSymbol(opts.ClimbUnit),
+		DistanceUnit:      units.DistanceSymbol(opts.DistanceUnit),
+		totalTime:         totalDuration,
+		avgFlightTime:     avgFlightTime,
+		maxFlightTime:     maxDuration,
+		minFlightTime:     minDuration,
+	}
+}
+
+// Aggregates returns the summary values of the logbook in a stable order, with durations
+// in minutes so they can be ingested by dashboards without parsing. Distances are in the
+// unit named by the distance_unit aggregate.
+func (t *TemplateData) Aggregates() []Aggregate {
+	minutes := func(duration time.Duration) int {
+		return int(duration.Minutes())
+	}
+
+	return []Aggregate{
+		{Key: "total_flights", Value: t.TotalFlights},
+		{Key: "total_time_minutes", Value: minutes(t.totalTime)},
+		{Key: "avg_flight_time_minutes", Value: minutes(t.avgFlightTime)},
+		{Key: "max_flight_time_minutes", Value: minutes(t.maxFlightTime)},
+		{Key: "min_flight_time_minutes", Value: minutes(t.minFlightTime)},
+		{Key: "total_distance", Value: t.TotalDistance},
+		{Key: "total_open_distance", Value: t.TotalOpenDistance},
+		{Key: "avg_distance", Value: t.AvgDistance},
+		{Key: "max_distance", Value: t.MaxDistance},
+		{Key: "distance_unit", Value: t.DistanceUnit},
+		{Key: "max_altitude", Value: t.MaxAltitude},
+		{Key: "avg_max_altitude", Value: t.AvgMaxAltitude},
+		{Key: "altitude_unit", Value: t.AltitudeUnit},
+		{Key: "first_date", Value: t.FirstDate},
+		{Key: "last_date", Value: t.LastDate},
+	}
+}
+
+// WriteAggregates writes the aggregates as key=value lines or as a flat JSON object
+// indented with indent
+func WriteAggregates(w io.Writer, aggregates []Aggregate, format, indent string) error {
+	switch format {
+	case StatsFormatKeyValue:
+		for _, aggregate := range aggregates {
+			if _, err := fmt.Fprintf(w, "%s=%v\n", aggregate.Key, aggregate.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case StatsFormatJSON:
+		object := make(map[string]interface{}, len(aggregates))
+		for _, aggregate := range aggregates {
+			object[aggregate.Key] = aggregate.Value
+		}
+		data, err := utils.MarshalJSON(object, indent)
+		if err != nil {
+			return fmt.Errorf("failed to marshal aggregates: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	default:
+		return fmt.Errorf("invalid stats-only format %q: must be %s or %s", format, StatsFormatKeyValue, StatsFormatJSON)
 	}
 }
 
-// parseDuration parses a duration string in the format used by utils.FormatDuration
-func parseDuration(durationStr string) (time.Duration, error) {
-	// Handle the custom format "XhYm" used by utils.FormatDuration
-	var hours, minutes int
-	n, err := fmt.Sscanf(durationStr, "%dh%dm", &hours, &minutes)
-	if err != nil || n != 2 {
-		return 0, fmt.Errorf("invalid duration format: %s", durationStr)
+// WriteCSV writes a header row with the Data field names followed by one row per flight
+func WriteCSV(w io.Writer, flights []*Data, opts CSVOptions) error {
+	if opts.BOM {
+		if _, err := io.WriteString(w, UTF8BOM); err != nil {
+			return fmt.Errorf("failed to write BOM: %w", err)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		writer.Comma = opts.Delimiter
+	}
+
+	if err := writer.Write(GetDataFields()); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, flight := range flights {
+		v := reflect.ValueOf(*flight)
+		t := v.Type()
+
+		var row []string
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			row = append(row, formatCSVValue(v.Field(i), opts.DecimalComma))
+		}
+
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatCSVValue formats a single Data field for CSV output
+func formatCSVValue(v reflect.Value, decimalComma bool) string {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		s := strconv.FormatFloat(v.Float(), 'f', -1, 64)
+		if decimalComma {
+			s = strings.Replace(s, ".", ",", 1)
+		}
+		return s
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		return fmt.Sprint(v.Interface())
 	}
-	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
 }
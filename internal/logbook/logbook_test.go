@@ -1,14 +1,20 @@
 package logbook
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"text/template"
 	"time"
 
 	"igc-tool/internal/config"
 	"igc-tool/internal/flight"
 	"igc-tool/internal/sites"
 
+	"github.com/paulmach/orb"
 	"github.com/twpayne/go-igc"
 )
 
@@ -233,6 +239,285 @@ func TestCreateData(t *testing.T) {
 	}
 }
 
+func TestCreateDataMinFixes(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	testFlight := &flight.Flight{
+		Date:  time.Date(2025, 7, 18, 0, 0, 0, 0, time.UTC),
+		Pilot: "TestPilot",
+		Fixes: []*igc.BRecord{
+			{Lat: 45.814, Lon: 6.246, Time: baseTime, AltWGS84: 1500},
+			{Lat: 45.815, Lon: 6.247, Time: baseTime.Add(30 * time.Minute), AltWGS84: 1800},
+			{Lat: 45.816, Lon: 6.248, Time: baseTime.Add(time.Hour), AltWGS84: 1600},
+		},
+	}
+
+	tests := []struct {
+		name               string
+		minFixes           int
+		expectInsufficient bool
+		expectMaxAltitude  int
+	}{
+		{name: "below threshold", minFixes: 10, expectInsufficient: true, expectMaxAltitude: 0},
+		{name: "at threshold", minFixes: 3, expectInsufficient: false, expectMaxAltitude: 1800},
+		{name: "disabled", minFixes: 0, expectInsufficient: false, expectMaxAltitude: 1800},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CreateData(testFlight, Options{
+				SpeedWindow:  5.0,
+				MinFixes:     tt.minFixes,
+				AltitudeUnit: "m",
+				SpeedUnit:    "kmh",
+				ClimbUnit:    "ms",
+				TimeFormat:   "24h",
+			})
+			if result == nil {
+				t.Fatalf("expected non-nil result, got nil")
+			}
+
+			if result.InsufficientData != tt.expectInsufficient {
+				t.Errorf("expected InsufficientData %v, got %v", tt.expectInsufficient, result.InsufficientData)
+			}
+			if result.MaxAltitude != tt.expectMaxAltitude {
+				t.Errorf("expected max altitude %d, got %d", tt.expectMaxAltitude, result.MaxAltitude)
+			}
+			// The flight itself is still listed with its basic data
+			if result.Pilot != "TestPilot" || result.FlightDuration == "" || result.TakeoffAlt != 1500 {
+				t.Errorf("expected basic flight data to be kept, got pilot %q duration %q takeoff %d",
+					result.Pilot, result.FlightDuration, result.TakeoffAlt)
+			}
+		})
+	}
+}
+
+func TestCreateTemplateDataSkipsInsufficientData(t *testing.T) {
+	flights := []*Data{
+		{Date: "2025-07-18", FlightDuration: "1h0m", MaxAltitude: 2000, duration: time.Hour},
+		{Date: "2025-07-19", FlightDuration: "0h1m", InsufficientData: true, duration: time.Minute},
+	}
+
+	result := CreateTemplateData(flights, Options{AltitudeUnit: "m", SpeedUnit: "kmh", ClimbUnit: "ms"})
+
+	if result.TotalFlights != 2 {
+		t.Errorf("expected 2 flights, got %d", result.TotalFlights)
+	}
+	if result.AvgMaxAltitude != 2000 {
+		t.Errorf("expected average max altitude 2000, got %d", result.AvgMaxAltitude)
+	}
+	if result.TotalTime != "1h1m" {
+		t.Errorf("expected total time 1h1m, got %s", result.TotalTime)
+	}
+}
+
+func TestCreateTemplateDataDurations(t *testing.T) {
+	// The formatted durations are truncated to minutes; aggregates must use the raw values
+	flights := []*Data{
+		{FlightDuration: "0h20m", duration: 20*time.Minute + 40*time.Second},
+		{FlightDuration: "0h20m", duration: 20*time.Minute + 40*time.Second},
+		{FlightDuration: "0h20m", duration: 20*time.Minute + 40*time.Second},
+		{FlightDuration: "26h0m", duration: 26 * time.Hour},
+	}
+
+	result := CreateTemplateData(flights, Options{AltitudeUnit: "m", SpeedUnit: "kmh", ClimbUnit: "ms"})
+
+	expected := map[string]string{
+		"TotalTime":     "27h2m",
+		"AvgFlightTime": "6h45m",
+		"MaxFlightTime": "26h0m",
+		"MinFlightTime": "0h20m",
+	}
+	actual := map[string]string{
+		"TotalTime":     result.TotalTime,
+		"AvgFlightTime": result.AvgFlightTime,
+		"MaxFlightTime": result.MaxFlightTime,
+		"MinFlightTime": result.MinFlightTime,
+	}
+	for field, value := range expected {
+		if actual[field] != value {
+			t.Errorf("expected %s %s, got %s", field, value, actual[field])
+		}
+	}
+
+	// 27h2m from the raw durations, not 26h60m summed from the formatted ones
+	for _, aggregate := range result.Aggregates() {
+		if aggregate.Key == "total_time_minutes" && aggregate.Value != 27*60+2 {
+			t.Errorf("expected total_time_minutes 1622, got %v", aggregate.Value)
+		}
+	}
+}
+
+func TestCreateTemplateDataMinMaxFlightTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		flights     []*Data
+		expectedMin string
+		expectedMax string
+	}{
+		{
+			name:        "single flight",
+			flights:     []*Data{{duration: 90 * time.Minute}},
+			expectedMin: "1h30m",
+			expectedMax: "1h30m",
+		},
+		{
+			name:        "genuine 24h flight",
+			flights:     []*Data{{duration: 24 * time.Hour}},
+			expectedMin: "24h0m",
+			expectedMax: "24h0m",
+		},
+		{
+			name:        "longer than 24h",
+			flights:     []*Data{{duration: 30 * time.Hour}, {duration: 25 * time.Hour}},
+			expectedMin: "25h0m",
+			expectedMax: "30h0m",
+		},
+		{
+			name: "unparsable formatted durations",
+			flights: []*Data{
+				{FlightDuration: "n/a", duration: 45 * time.Minute},
+				{FlightDuration: "", duration: 2 * time.Hour},
+			},
+			expectedMin: "0h45m",
+			expectedMax: "2h0m",
+		},
+		{
+			name:        "zero durations",
+			flights:     []*Data{{}, {}},
+			expectedMin: "0h0m",
+			expectedMax: "0h0m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CreateTemplateData(tt.flights, Options{AltitudeUnit: "m", SpeedUnit: "kmh", ClimbUnit: "ms"})
+			if result.MinFlightTime != tt.expectedMin || result.MaxFlightTime != tt.expectedMax {
+				t.Errorf("expected min %s and max %s, got %s and %s",
+					tt.expectedMin, tt.expectedMax, result.MinFlightTime, result.MaxFlightTime)
+			}
+		})
+	}
+}
+
+func TestCreateDataDistanceUnit(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	testFlight := &flight.Flight{
+		Fixes: []*igc.BRecord{
+			{Lat: 45.0, Lon: 6.0, Time: baseTime, AltWGS84: 1500},
+			{Lat: 45.1, Lon: 6.0, Time: baseTime.Add(time.Hour), AltWGS84: 600}, // ~11.1 km
+		},
+	}
+
+	tests := []struct {
+		unit           string
+		expectedTrack  float64
+		expectedSymbol string
+	}{
+		{unit: "km", expectedTrack: 11.1, expectedSymbol: "km"},
+		{unit: "mi", expectedTrack: 6.9, expectedSymbol: "mi"},
+		{unit: "nm", expectedTrack: 6.0, expectedSymbol: "NM"},
+		{unit: "", expectedTrack: 11.1, expectedSymbol: "km"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			result := CreateData(testFlight, Options{DistanceUnit: tt.unit, AltitudeUnit: "m", SpeedUnit: "kmh", ClimbUnit: "ms"})
+			if result.TrackDistance != tt.expectedTrack || result.OpenDistance != tt.expectedTrack {
+				t.Errorf("expected track and open distance %v, got %v and %v", tt.expectedTrack, result.TrackDistance, result.OpenDistance)
+			}
+			if result.DistanceUnit != tt.expectedSymbol {
+				t.Errorf("expected unit %s, got %s", tt.expectedSymbol, result.DistanceUnit)
+			}
+		})
+	}
+}
+
+func TestCreateTemplateDataDistances(t *testing.T) {
+	flights := []*Data{
+		{TrackDistance: 12.3, OpenDistance: 8.1},
+		{TrackDistance: 45.6, OpenDistance: 30.2},
+		{TrackDistance: 0.8, OpenDistance: 0.3},
+	}
+
+	result := CreateTemplateData(flights, Options{AltitudeUnit: "m", SpeedUnit: "kmh", ClimbUnit: "ms"})
+
+	if result.TotalDistance != 58.7 {
+		t.Errorf("expected total distance 58.7, got %v", result.TotalDistance)
+	}
+	if result.TotalOpenDistance != 38.6 {
+		t.Errorf("expected total open distance 38.6, got %v", result.TotalOpenDistance)
+	}
+	if result.AvgDistance != 19.6 {
+		t.Errorf("expected average distance 19.6, got %v", result.AvgDistance)
+	}
+	if result.MaxDistance != 45.6 {
+		t.Errorf("expected max distance 45.6, got %v", result.MaxDistance)
+	}
+}
+
+func TestCreateTemplateDataByPilot(t *testing.T) {
+	flights := []*Data{
+		{Pilot: "Zoe", TrackDistance: 10.2, MaxAltitude: 1800, duration: time.Hour},
+		{Pilot: "Adam", TrackDistance: 5.5, MaxAltitude: 2100, duration: 30 * time.Minute},
+		{Pilot: "Zoe", TrackDistance: 20.1, MaxAltitude: 2500, duration: 90 * time.Minute},
+		{Pilot: "Zoe", TrackDistance: 0.4, MaxAltitude: 3000, duration: time.Minute, InsufficientData: true},
+		{Pilot: "", TrackDistance: 3, duration: time.Hour},
+	}
+
+	result := CreateTemplateData(flights, Options{AltitudeUnit: "m", SpeedUnit: "kmh", ClimbUnit: "ms"})
+
+	if !reflect.DeepEqual(result.UniquePilots, []string{"Adam", "Zoe"}) {
+		t.Errorf("expected sorted pilots [Adam Zoe], got %v", result.UniquePilots)
+	}
+	if len(result.ByPilot) != 2 {
+		t.Fatalf("expected 2 pilot summaries, got %d", len(result.ByPilot))
+	}
+
+	zoe := result.ByPilot["Zoe"]
+	if zoe.Flights != 3 || zoe.TotalTime != "2h31m" || zoe.TotalDistance != 30.7 || zoe.MaxAltitude != 2500 {
+		t.Errorf("unexpected summary for Zoe: %+v", *zoe)
+	}
+
+	var buf bytes.Buffer
+	tmpl := template.Must(template.New("test").Parse(`{{range $p, $s := .ByPilot}}{{$p}}: {{$s.TotalTime}} {{end}}`))
+	if err := tmpl.Execute(&buf, result); err != nil {
+		t.Fatalf("failed to execute template: %v", err)
+	}
+	if buf.String() != "Adam: 0h30m Zoe: 2h31m " {
+		t.Errorf("unexpected template output %q", buf.String())
+	}
+}
+
+func TestCreateTemplateDataByGlider(t *testing.T) {
+	flights := []*Data{
+		{GliderType: "Ozone Rush 6", duration: 2 * time.Hour},
+		{GliderType: "Advance Iota 2", duration: 45 * time.Minute},
+		{GliderType: "Ozone Rush 6", duration: 30 * time.Minute},
+		{GliderType: "", duration: time.Hour},
+	}
+
+	result := CreateTemplateData(flights, Options{AltitudeUnit: "m", SpeedUnit: "kmh", ClimbUnit: "ms"})
+
+	if !reflect.DeepEqual(result.UniqueGliders, []string{"Advance Iota 2", "Ozone Rush 6"}) {
+		t.Errorf("expected sorted gliders, got %v", result.UniqueGliders)
+	}
+
+	expected := map[string]GliderSummary{
+		"Advance Iota 2": {Flights: 1, TotalTime: "0h45m"},
+		"Ozone Rush 6":   {Flights: 2, TotalTime: "2h30m"},
+	}
+	if len(result.ByGlider) != len(expected) {
+		t.Fatalf("expected %d glider summaries, got %d", len(expected), len(result.ByGlider))
+	}
+	for glider, want := range expected {
+		got := result.ByGlider[glider]
+		if got == nil || got.Flights != want.Flights || got.TotalTime != want.TotalTime {
+			t.Errorf("expected %s to have %+v, got %+v", glider, want, got)
+		}
+	}
+}
+
 func TestGetDataFields(t *testing.T) {
 	fields := GetDataFields()
 
@@ -244,7 +529,7 @@ func TestGetDataFields(t *testing.T) {
 	expectedFields := []string{
 		"Date", "TakeoffLat", "TakeoffLon", "TakeoffSite", "LandingSite",
 		"MaxAltitude", "MaxGroundSpeed", "FlightDuration", "Pilot",
-		"AltitudeUnit", "SpeedUnit", "VerticalSpeedUnit",
+		"AltitudeUnit", "HeightUnit", "SpeedUnit", "VerticalSpeedUnit",
 	}
 
 	fieldMap := make(map[string]bool)
@@ -400,3 +685,658 @@ func TestDataStructCompleteness(t *testing.T) {
 		t.Errorf("unit fields not set properly")
 	}
 }
+
+func TestWriteCSV(t *testing.T) {
+	flights := []*Data{
+		{Date: "2025-07-18", Pilot: "Jöhn Doe", TakeoffLat: 45.814, MaxAltitude: 1800, MaxClimbRate: 2.5},
+	}
+
+	tests := []struct {
+		name     string
+		opts     CSVOptions
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "default comma delimiter",
+			opts:     CSVOptions{},
+			contains: []string{"Date,TakeoffLat,", "2025-07-18,45.814,", ",1800,", ",2.5,", "Jöhn Doe"},
+		},
+		{
+			name:     "semicolon with decimal comma",
+			opts:     CSVOptions{Delimiter: ';', DecimalComma: true},
+			contains: []string{"Date;TakeoffLat;", "2025-07-18;45,814;", ";2,5;"},
+			excludes: []string{"45.814"},
+		},
+		{
+			name:     "with BOM",
+			opts:     CSVOptions{BOM: true},
+			contains: []string{UTF8BOM + "Date,"},
+		},
+		{
+			name:     "comma delimiter with decimal comma quotes decimals",
+			opts:     CSVOptions{Delimiter: ',', DecimalComma: true},
+			contains: []string{`"45,814"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteCSV(&buf, flights, tt.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			output := buf.String()
+			if strings.HasPrefix(output, UTF8BOM) != tt.opts.BOM {
+				t.Errorf("expected BOM present=%v, got output %q", tt.opts.BOM, output)
+			}
+
+			lines := strings.Split(strings.TrimSpace(output), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("expected header and 1 row, got %d lines: %q", len(lines), output)
+			}
+
+			for _, want := range tt.contains {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got %q", want, output)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("expected output not to contain %q, got %q", unwanted, output)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema()
+
+	if schema["type"] != "object" {
+		t.Errorf("expected object schema, got %v", schema["type"])
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	if len(properties) != len(GetTemplateDataFields()) {
+		t.Errorf("expected %d properties, got %d", len(GetTemplateDataFields()), len(properties))
+	}
+
+	flights := properties["flights"].(map[string]interface{})
+	if flights["type"] != "array" {
+		t.Fatalf("expected flights to be an array, got %v", flights["type"])
+	}
+	item := flights["items"].(map[string]interface{})
+	itemProperties := item["properties"].(map[string]interface{})
+	if len(itemProperties) != len(GetDataFields()) {
+		t.Errorf("expected %d flight properties, got %d", len(GetDataFields()), len(itemProperties))
+	}
+
+	expectedTypes := map[string]string{
+		"date":              "string",
+		"max_altitude":      "integer",
+		"max_climb_rate":    "number",
+		"insufficient_data": "boolean",
+	}
+	for name, expectedType := range expectedTypes {
+		property := itemProperties[name].(map[string]interface{})
+		if property["type"] != expectedType {
+			t.Errorf("expected %s to be %s, got %v", name, expectedType, property["type"])
+		}
+	}
+
+	required := item["required"].([]string)
+	if len(required) != len(GetDataFields()) {
+		t.Errorf("expected all %d flight fields to be required, got %d", len(GetDataFields()), len(required))
+	}
+}
+
+func TestJSONKeys(t *testing.T) {
+	templateData := &TemplateData{
+		Flights:       []*Data{{TakeoffLat: 45.814, GliderID: "HB-123", TrackDistance: 12.5}},
+		TotalFlights:  1,
+		TotalDistance: 12.5,
+		UniquePilots:  []string{"Jane Doe"},
+	}
+
+	data, err := json.Marshal(templateData)
+	if err != nil {
+		t.Fatalf("failed to marshal template data: %v", err)
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		t.Fatalf("failed to unmarshal template data: %v", err)
+	}
+
+	for _, key := range []string{"flights", "total_flights", "total_distance", "distance_unit", "avg_max_altitude", "unique_pilots", "vertical_speed_unit"} {
+		if _, ok := object[key]; !ok {
+			t.Errorf("expected key %q in %s", key, data)
+		}
+	}
+
+	flight := object["flights"].([]interface{})[0].(map[string]interface{})
+	expected := map[string]interface{}{
+		"takeoff_lat":       45.814,
+		"glider_id":         "HB-123",
+		"track_distance":    12.5,
+		"insufficient_data": false,
+	}
+	for key, value := range expected {
+		if flight[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, flight[key])
+		}
+	}
+
+	// Every exported field is tagged with a snake_case name
+	for _, typ := range []reflect.Type{reflect.TypeOf(Data{}), reflect.TypeOf(TemplateData{})} {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name, _, _ := jsonFieldName(field)
+			if name == field.Name || strings.ToLower(name) != name {
+				t.Errorf("%s.%s has no snake_case json tag, got %q", typ.Name(), field.Name, name)
+			}
+		}
+	}
+}
+
+func TestJSONFieldName(t *testing.T) {
+	type tagged struct {
+		Plain    string
+		Renamed  string `json:"renamed"`
+		Optional string `json:"optional,omitempty"`
+		Skipped  string `json:"-"`
+	}
+
+	tests := []struct {
+		field        string
+		expectedName string
+		expectedOmit bool
+		expectedSkip bool
+	}{
+		{"Plain", "Plain", false, false},
+		{"Renamed", "renamed", false, false},
+		{"Optional", "optional", true, false},
+		{"Skipped", "", false, true},
+	}
+
+	typ := reflect.TypeOf(tagged{})
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			field, _ := typ.FieldByName(tt.field)
+			name, omitEmpty, skip := jsonFieldName(field)
+			if name != tt.expectedName || omitEmpty != tt.expectedOmit || skip != tt.expectedSkip {
+				t.Errorf("expected (%q, %v, %v), got (%q, %v, %v)",
+					tt.expectedName, tt.expectedOmit, tt.expectedSkip, name, omitEmpty, skip)
+			}
+		})
+	}
+}
+
+func TestWriteAggregates(t *testing.T) {
+	templateData := &TemplateData{
+		TotalFlights:   2,
+		TotalTime:      "2h30m",
+		AvgFlightTime:  "1h15m",
+		MaxFlightTime:  "2h0m",
+		MinFlightTime:  "0h30m",
+		TotalDistance:  42.5,
+		MaxDistance:    30,
+		DistanceUnit:   "km",
+		MaxAltitude:    2500,
+		AvgMaxAltitude: 2000,
+		AltitudeUnit:   "m",
+		FirstDate:      "2025-07-01",
+		LastDate:       "2025-07-31",
+		totalTime:      2*time.Hour + 30*time.Minute + 50*time.Second,
+		avgFlightTime:  time.Hour + 15*time.Minute + 25*time.Second,
+		maxFlightTime:  2 * time.Hour,
+		minFlightTime:  30*time.Minute + 50*time.Second,
+	}
+
+	tests := []struct {
+		name        string
+		format      string
+		expected    []string
+		expectError bool
+	}{
+		{
+			name:     "key=value",
+			format:   StatsFormatKeyValue,
+			expected: []string{"total_flights=2\n", "total_time_minutes=150\n", "min_flight_time_minutes=30\n", "total_distance=42.5\n", "max_distance=30\n", "distance_unit=km\n", "first_date=2025-07-01\n"},
+		},
+		{
+			name:     "json",
+			format:   StatsFormatJSON,
+			expected: []string{`"total_flights":2`, `"total_time_minutes":150`, `"total_distance":42.5`, `"altitude_unit":"m"`},
+		},
+		{name: "invalid format", format: "xml", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteAggregates(&buf, templateData.Aggregates(), tt.format, "")
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("expected output to contain %q, got %q", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCreateDataNearSite(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	testFlight := &flight.Flight{
+		Fixes: []*igc.BRecord{
+			{Lat: 45.814, Lon: 6.246, Time: baseTime, AltWGS84: 1500},
+			{Lat: 45.816, Lon: 6.246, Time: baseTime.Add(time.Hour), AltWGS84: 600},
+		},
+	}
+	landingSites := &sites.Collection{
+		Sites: []sites.LandingSite{{Name: "Launch", Center: orb.Point{6.246, 45.814}, Radius: 100}},
+	}
+
+	tests := []struct {
+		name             string
+		nearSiteDistance float64
+		expectedLanding  string
+	}{
+		{name: "disabled", nearSiteDistance: 0, expectedLanding: "45.816,6.246"},
+		{name: "within distance", nearSiteDistance: 200, expectedLanding: "near Launch (122m)"},
+		{name: "beyond distance", nearSiteDistance: 100, expectedLanding: "45.816,6.246"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CreateData(testFlight, Options{
+				LandingSites:     landingSites,
+				NearSiteDistance: tt.nearSiteDistance,
+				AltitudeUnit:     "m",
+				SpeedUnit:        "kmh",
+				ClimbUnit:        "ms",
+			})
+			if result.TakeoffSite != "Launch" {
+				t.Errorf("expected takeoff site Launch, got %s", result.TakeoffSite)
+			}
+			if result.LandingSite != tt.expectedLanding {
+				t.Errorf("expected landing site %q, got %q", tt.expectedLanding, result.LandingSite)
+			}
+		})
+	}
+}
+
+func TestCreateDataCoordFormat(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	testFlight := &flight.Flight{
+		Fixes: []*igc.BRecord{
+			{Lat: 45.814, Lon: 6.246, Time: baseTime, AltWGS84: 1500},
+			{Lat: 45.81612, Lon: 6.24634, Time: baseTime.Add(time.Hour), AltWGS84: 600},
+		},
+	}
+	landingSites := &sites.Collection{
+		Sites: []sites.LandingSite{{Name: "Launch", Center: orb.Point{6.246, 45.814}, Radius: 100}},
+	}
+
+	tests := []struct {
+		name             string
+		coordFormat      string
+		coordPrecision   int
+		expectedPosition string
+	}{
+		{name: "default", expectedPosition: "45.816,6.246"},
+		{name: "decimal precision", coordFormat: "decimal", coordPrecision: 5, expectedPosition: "45.81612,6.24634"},
+		{name: "dms", coordFormat: "dms", expectedPosition: "45°48'58\"N 6°14'47\"E"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CreateData(testFlight, Options{
+				LandingSites:   landingSites,
+				CoordFormat:    tt.coordFormat,
+				CoordPrecision: tt.coordPrecision,
+				AltitudeUnit:   "m",
+				SpeedUnit:      "kmh",
+				ClimbUnit:      "ms",
+			})
+			if result.TakeoffSite != "Launch" {
+				t.Errorf("expected takeoff site Launch, got %s", result.TakeoffSite)
+			}
+			// The landing is outside every site, so its site is the formatted position
+			if result.LandingSite != tt.expectedPosition || result.LandingPosition != tt.expectedPosition {
+				t.Errorf("expected landing site and position %q, got %q and %q", tt.expectedPosition, result.LandingSite, result.LandingPosition)
+			}
+		})
+	}
+}
+
+func TestCreateDataDetectTakeoffLanding(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	var fixes []*igc.BRecord
+	lat := 45.814
+	for i := 0; i < 18; i++ {
+		// Ground time for the first and last minute, ~40 km/h in between
+		if i >= 6 && i < 12 {
+			lat += 0.001
+		}
+		fixes = append(fixes, &igc.BRecord{Lat: lat, Lon: 6.246, AltWGS84: 1000, Time: baseTime.Add(time.Duration(i) * 10 * time.Second)})
+	}
+	testFlight := &flight.Flight{Fixes: fixes}
+
+	tests := []struct {
+		name             string
+		detect           bool
+		expectedTakeoff  string
+		expectedLanding  string
+		expectedDuration string
+	}{
+		{name: "first and last fix", detect: false, expectedTakeoff: "12:00:00", expectedLanding: "12:02:50", expectedDuration: "0h2m"},
+		{name: "detected", detect: true, expectedTakeoff: "12:00:50", expectedLanding: "12:01:50", expectedDuration: "0h1m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CreateData(testFlight, Options{
+				DetectTakeoffLanding: tt.detect,
+				AltitudeUnit:         "m",
+				SpeedUnit:            "kmh",
+				ClimbUnit:            "ms",
+				TimeFormat:           "15:04:05",
+			})
+			if result.TakeoffTime != tt.expectedTakeoff || result.LandingTime != tt.expectedLanding {
+				t.Errorf("expected %s-%s, got %s-%s", tt.expectedTakeoff, tt.expectedLanding, result.TakeoffTime, result.LandingTime)
+			}
+			if result.FlightDuration != tt.expectedDuration {
+				t.Errorf("expected duration %s, got %s", tt.expectedDuration, result.FlightDuration)
+			}
+		})
+	}
+}
+
+func TestDateFilter(t *testing.T) {
+	day := func(s string) time.Time {
+		date, _ := time.Parse(DateLayout, s)
+		return date
+	}
+
+	tests := []struct {
+		name        string
+		since       string
+		until       string
+		date        time.Time
+		expected    bool
+		expectError bool
+	}{
+		{name: "no bounds", date: day("2024-06-01"), expected: true},
+		{name: "no bounds without date", date: time.Time{}, expected: true},
+		{name: "on since date", since: "2024-06-01", date: day("2024-06-01"), expected: true},
+		{name: "before since date", since: "2024-06-01", date: day("2024-05-31"), expected: false},
+		{name: "on until date afternoon", until: "2024-06-01", date: day("2024-06-01").Add(15 * time.Hour), expected: true},
+		{name: "after until date", until: "2024-06-01", date: day("2024-06-02"), expected: false},
+		{name: "within range", since: "2024-01-01", until: "2024-12-31", date: day("2024-07-14"), expected: true},
+		{name: "without date", since: "2024-01-01", date: time.Time{}, expected: false},
+		{name: "invalid since", since: "01/06/2024", expectError: true},
+		{name: "invalid until", until: "2024-13-01", expectError: true},
+		{name: "until before since", since: "2024-06-02", until: "2024-06-01", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseDateFilter(tt.since, tt.until)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := filter.Includes(tt.date); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFilterMinDuration(t *testing.T) {
+	flights := []*Data{
+		{Filename: "a", duration: 2 * time.Minute},
+		{Filename: "b", duration: time.Hour},
+		{Filename: "c", duration: 5 * time.Minute},
+		{Filename: "d", duration: 0},
+	}
+
+	tests := []struct {
+		name            string
+		minDuration     time.Duration
+		expected        string
+		expectedSkipped int
+	}{
+		{name: "disabled", minDuration: 0, expected: "abcd", expectedSkipped: 0},
+		{name: "five minutes", minDuration: 5 * time.Minute, expected: "bc", expectedSkipped: 2},
+		{name: "longer than all", minDuration: 2 * time.Hour, expected: "", expectedSkipped: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, skipped := FilterMinDuration(flights, tt.minDuration)
+
+			var order string
+			for _, flight := range kept {
+				order += flight.Filename
+			}
+			if order != tt.expected || skipped != tt.expectedSkipped {
+				t.Errorf("expected %q with %d skipped, got %q with %d skipped", tt.expected, tt.expectedSkipped, order, skipped)
+			}
+		})
+	}
+}
+
+func TestSortFlights(t *testing.T) {
+	baseTime := time.Date(2025, 7, 18, 12, 0, 0, 0, time.UTC)
+	newFlights := func() []*Data {
+		return []*Data{
+			{Filename: "a", MaxAltitude: 2000, TrackDistance: 10, takeoff: baseTime.Add(48 * time.Hour), duration: time.Hour},
+			{Filename: "b", MaxAltitude: 1500, TrackDistance: 30, takeoff: baseTime, duration: 3 * time.Hour},
+			{Filename: "c", MaxAltitude: 2500, TrackDistance: 20, takeoff: baseTime.Add(24 * time.Hour), duration: 2 * time.Hour},
+			{Filename: "d", MaxAltitude: 1500, TrackDistance: 5, takeoff: baseTime.Add(72 * time.Hour), duration: 2 * time.Hour},
+		}
+	}
+
+	tests := []struct {
+		key         string
+		reverse     bool
+		expected    string
+		expectError bool
+	}{
+		{key: "", expected: "abcd"},
+		{key: "", reverse: true, expected: "dcba"},
+		{key: SortDate, expected: "bcad"},
+		{key: SortDuration, expected: "acdb"},
+		{key: SortDuration, reverse: true, expected: "bcda"}, // equal durations keep input order
+		{key: SortMaxAltitude, expected: "bdac"},
+		{key: SortDistance, reverse: true, expected: "bcad"},
+		{key: "name", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s reverse=%v", tt.key, tt.reverse), func(t *testing.T) {
+			flights := newFlights()
+			err := SortFlights(flights, tt.key, tt.reverse)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error for invalid sort key")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var order string
+			for _, flight := range flights {
+				order += flight.Filename
+			}
+			if order != tt.expected {
+				t.Errorf("expected order %s, got %s", tt.expected, order)
+			}
+		})
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	data := CreateTemplateData([]*Data{
+		{Date: "2023-07-30", Pilot: "<script>alert(1)</script>", TakeoffSite: "Col & Pass", FlightDuration: "1h30m", TrackDistance: 42.14, duration: 90 * time.Minute},
+	}, Options{DistanceUnit: "km"})
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, data, HTMLOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := buf.String()
+
+	for _, expected := range []string{"<table>", "&lt;script&gt;alert(1)&lt;/script&gt;", "Col &amp; Pass", "42.1 km", "<dt>Flights</dt><dd>1</dd>"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q", expected)
+		}
+	}
+	if strings.Contains(output, "<script>") {
+		t.Error("pilot name was not escaped")
+	}
+
+	buf.Reset()
+	if err := WriteHTML(&buf, data, HTMLOptions{Template: "<p>{{.TotalFlights}} {{range .Flights}}{{.Pilot}}{{end}}</p>"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "<p>1 &lt;script&gt;alert(1)&lt;/script&gt;</p>"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	if strings.HasPrefix(buf.String(), UTF8BOM) {
+		t.Error("expected no BOM by default")
+	}
+
+	buf.Reset()
+	if err := WriteHTML(&buf, data, HTMLOptions{Template: "<p>{{.TotalFlights}}</p>", BOM: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := UTF8BOM + "<p>1</p>"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteHTML(&buf, data, HTMLOptions{Template: "{{.Unclosed", BOM: true}); err == nil {
+		t.Error("expected error for invalid template")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written for an invalid template, got %q", buf.String())
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	data := struct {
+		Date     string
+		Pilot    string
+		Altitude int
+		Distance float64
+	}{"2023-07-30", "John Doe", 1980, 13.757}
+
+	tests := []struct {
+		name        string
+		template    string
+		expected    string
+		expectError bool
+	}{
+		{name: "add integers", template: "{{add .Altitude 20}}", expected: "2000"},
+		{name: "sub integers", template: "{{sub .Altitude 480}}", expected: "1500"},
+		{name: "add float", template: "{{add .Distance 1}}", expected: "14.757"},
+		{name: "number", template: "{{.Distance | number 1}}", expected: "13.8"},
+		{name: "number of an integer", template: "{{number 2 .Altitude}}", expected: "1980.00"},
+		{name: "pad", template: "[{{.Pilot | pad 10}}]", expected: "[John Doe  ]"},
+		{name: "padLeft", template: "[{{.Altitude | padLeft 6}}]", expected: "[  1980]"},
+		{name: "pad shorter than value", template: "[{{.Pilot | pad 2}}]", expected: "[John Doe]"},
+		{name: "upper and lower", template: "{{upper .Pilot}} {{lower .Pilot}}", expected: "JOHN DOE john doe"},
+		{name: "date", template: `{{.Date | date "02.01.2006"}}`, expected: "30.07.2023"},
+		{name: "altitude", template: `{{.Altitude | altitude "m"}}`, expected: "1980m"},
+		{name: "flight level", template: `{{altitude "FL" 65}}`, expected: "FL065"},
+		{name: "altitude of text", template: `{{.Pilot | altitude "m"}}`, expectError: true},
+		{name: "invalid date", template: `{{.Pilot | date "02.01.2006"}}`, expectError: true},
+		{name: "arithmetic on text", template: "{{add .Pilot 1}}", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New("test").Funcs(TemplateFuncs).Parse(tt.template)
+			if err != nil {
+				t.Fatalf("failed to parse template: %v", err)
+			}
+
+			var buf bytes.Buffer
+			err = tmpl.Execute(&buf, data)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got output %q", buf.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestDescribeTemplateError(t *testing.T) {
+	data := &TemplateData{Flights: []*Data{{Pilot: "John Doe"}}, ByPilot: map[string]*PilotSummary{"John Doe": {Flights: 1}}}
+
+	tests := []struct {
+		name     string
+		template string
+		contains []string
+	}{
+		{"unknown aggregate", "{{.TotalFlight}}", []string{"unknown field TotalFlight in TemplateData", "TotalFlights", "ByGlider"}},
+		{"unknown flight field", "{{range .Flights}}{{.Pilots}}{{end}}", []string{"unknown field Pilots in Data", "GliderType"}},
+		{"unknown summary field", `{{(index .ByPilot "John Doe").Time}}`, []string{"unknown field Time in PilotSummary", "TotalTime"}},
+		{"missing map key", "{{.ByPilot.Nobody}}", []string{`map has no entry for key "Nobody"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := template.Must(template.New("test").Option("missingkey=error").Parse(tt.template))
+			err := tmpl.Execute(&bytes.Buffer{}, data)
+			if err == nil {
+				t.Fatal("expected template error")
+			}
+
+			described := DescribeTemplateError(err)
+			for _, expected := range tt.contains {
+				if !strings.Contains(described.Error(), expected) {
+					t.Errorf("expected %q in %q", expected, described.Error())
+				}
+			}
+		})
+	}
+
+	other := fmt.Errorf("some other error")
+	if DescribeTemplateError(other) != other {
+		t.Error("expected unrelated errors to be returned unchanged")
+	}
+}
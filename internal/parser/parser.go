@@ -1,15 +1,88 @@
 package parser
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
+	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
 	"igc-tool/internal/flight"
+	"igc-tool/internal/source"
 
 	"github.com/twpayne/go-igc"
 )
 
+// CenturyPivot is the two-digit year from which HFDTE years belong to the 1900s:
+// 70-99 map to 1970-1999 and 00-69 to 2000-2069
+const CenturyPivot = 70
+
+// expandTwoDigitYear returns the four-digit year of a two-digit HFDTE year
+func expandTwoDigitYear(year int) int {
+	if year >= CenturyPivot {
+		return 1900 + year
+	}
+	return 2000 + year
+}
+
+// parseDate extracts the flight date and flight-of-day number from the HFDTE record,
+// either in the legacy HFDTEDDMMYY form or the newer HFDTEDATE:DDMMYY,NN form. The
+// flight number is 0 when absent, and a zero date is returned without error when the
+// file has no HFDTE record. Two-digit years are expanded with CenturyPivot.
+func parseDate(igcData *igc.IGC) (time.Time, int, error) {
+	record, exists := igcData.HRecordsByTLC["DTE"]
+	if !exists || record == nil {
+		return time.Time{}, 0, nil
+	}
+
+	// go-igc leaves the value empty and keeps the text as the long name when the
+	// record does not match the expected format
+	raw := record.Value
+	if raw == "" {
+		raw = record.LongName
+	}
+	if i := strings.LastIndex(raw, ":"); i >= 0 {
+		raw = raw[i+1:]
+	}
+
+	datePart, numberPart, hasNumber := strings.Cut(strings.TrimSpace(raw), ",")
+	day, dayErr := strconv.Atoi(safeSlice(datePart, 0, 2))
+	month, monthErr := strconv.Atoi(safeSlice(datePart, 2, 4))
+	year, yearErr := strconv.Atoi(safeSlice(datePart, 4, 6))
+	if len(datePart) != 6 || dayErr != nil || monthErr != nil || yearErr != nil {
+		return time.Time{}, 0, fmt.Errorf("malformed HFDTE date %q: expected DDMMYY", raw)
+	}
+
+	date := time.Date(expandTwoDigitYear(year), time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day || int(date.Month()) != month {
+		return time.Time{}, 0, fmt.Errorf("malformed HFDTE date %q: no such day", raw)
+	}
+
+	flightNumber := 0
+	if hasNumber {
+		number, err := strconv.Atoi(strings.TrimSpace(numberPart))
+		if err != nil {
+			return time.Time{}, 0, fmt.Errorf("malformed HFDTE flight number %q", numberPart)
+		}
+		flightNumber = number
+	}
+
+	return date, flightNumber, nil
+}
+
+// safeSlice returns s[from:to], or an empty string when s is too short
+func safeSlice(s string, from, to int) string {
+	if len(s) < to {
+		return ""
+	}
+	return s[from:to]
+}
+
 // getHRecordValue extracts the value from an H record if it exists
 func getHRecordValue(records map[string]*igc.HRecord, key string) string {
 	if record, exists := records[key]; exists && record != nil {
@@ -18,36 +91,324 @@ func getHRecordValue(records map[string]*igc.HRecord, key string) string {
 	return ""
 }
 
+// Options controls the Parse functions reading from a source
+type Options struct {
+	// Logger receives a debug message for each file parsed: its fix count, warnings such
+	// as missing headers, and parse time. Nil discards them.
+	Logger *slog.Logger
+	// Retry is the policy for transient read errors, used by ParseIGCWithRetry
+	Retry RetryPolicy
+}
+
+// logger returns the logger of the options, one discarding messages when unset
+func (o Options) logger() *slog.Logger {
+	if o.Logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return o.Logger
+}
+
+// logParse logs the outcome of parsing ref, started at start
+func logParse(logger *slog.Logger, ref string, start time.Time, result *ParseResult, err error) {
+	elapsed := time.Since(start)
+	if err != nil {
+		logger.Debug("failed to parse file", "file", ref, "error", err, "elapsed", elapsed)
+		return
+	}
+	logger.Debug("parsed file", "file", ref, "fixes", len(result.Flight.Fixes), "date", result.Flight.Date.Format(time.DateOnly), "elapsed", elapsed)
+	for _, warning := range result.Warnings {
+		logger.Debug("parse warning", "file", ref, "warning", warning)
+	}
+}
+
 // ParseIGCFile parses an IGC file and returns a Flight struct
-func ParseIGCFile(filename string) (*flight.Flight, error) {
-	file, err := os.Open(filename)
+func ParseIGCFile(filename string, opts Options) (*flight.Flight, error) {
+	return ParseIGC(source.FileSystem{}, filename, opts)
+}
+
+// ParseIGC opens ref from the given source and parses it into a Flight struct
+func ParseIGC(src source.Source, ref string, opts Options) (*flight.Flight, error) {
+	result, err := ParseIGCWithWarnings(src, ref, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Flight, nil
+}
+
+// ParseIGCHeaders parses only the header section of an IGC file from the filesystem
+func ParseIGCHeaders(filename string) (*flight.Flight, error) {
+	file, err := source.FileSystem{}.Open(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
 	}
 	defer file.Close()
 
-	igcData, err := igc.Parse(file)
+	return ParseIGCHeadersReader(file)
+}
+
+// ParseIGCHeadersReader parses the records preceding the first B record (fix) and
+// returns a Flight with its header fields and task but without fixes. It avoids
+// reading and decoding the track, which makes scanning large archives fast.
+func ParseIGCHeadersReader(r io.Reader) (*flight.Flight, error) {
+	var header bytes.Buffer
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.HasPrefix(line, "B") {
+			break
+		}
+		header.WriteString(line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read IGC headers: %w", err)
+		}
+	}
+
+	result, err := parseIGC(&header, false)
+	if err != nil {
+		return nil, err
+	}
+	return result.Flight, nil
+}
+
+// Errors for files whose content is not a usable flight, wrapped by Validate and the
+// Parse functions. Errors opening or reading a file wrap the underlying error instead,
+// such as fs.ErrNotExist or fs.ErrPermission.
+var (
+	// ErrEmptyFile is returned for a file without any content
+	ErrEmptyFile = errors.New("empty file")
+	// ErrNotIGC is returned for content that does not look like an IGC file
+	ErrNotIGC = errors.New("not an IGC file")
+	// ErrNoFixes is returned by the Parse functions for an IGC file without B records
+	ErrNoFixes = errors.New("no GPS fixes")
+)
+
+// IsInvalidContent reports whether err is due to the content of a file rather than to
+// opening or reading it: an empty file, a file that is not IGC, or one without fixes.
+// Batch commands skip such files with a warning.
+func IsInvalidContent(err error) bool {
+	return errors.Is(err, ErrEmptyFile) || errors.Is(err, ErrNotIGC) || errors.Is(err, ErrNoFixes)
+}
+
+// ValidateFile checks that a file from the filesystem looks like an IGC file
+func ValidateFile(filename string) error {
+	return Validate(source.FileSystem{}, filename)
+}
+
+// Validate opens ref from the given source and checks that its content looks like an
+// IGC file. See ValidateReader.
+func Validate(src source.Source, ref string) error {
+	file, err := src.Open(ref)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", ref, err)
+	}
+	defer file.Close()
+
+	return ValidateReader(file)
+}
+
+// ValidateReader is a cheap content sniff: the first line must be an A record
+// (manufacturer and logger id) and at least one B record (fix) must follow. Reading
+// stops at the first B record. Empty content returns ErrEmptyFile and other files
+// failing the check return an error wrapping ErrNotIGC; the content is not otherwise
+// decoded, so passing files may still fail to parse.
+func ValidateReader(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	for first := true; ; first = false {
+		line, err := reader.ReadString('\n')
+		if first {
+			line = strings.TrimPrefix(line, "\uFEFF")
+			if line == "" && err == io.EOF {
+				return ErrEmptyFile
+			}
+			if !strings.HasPrefix(line, "A") {
+				return fmt.Errorf("%w: missing A record", ErrNotIGC)
+			}
+		} else if strings.HasPrefix(line, "B") {
+			return nil
+		}
+		if err == io.EOF {
+			return fmt.Errorf("%w: no B records", ErrNotIGC)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read IGC data: %w", err)
+		}
+	}
+}
+
+// RetryPolicy controls how transient read errors are retried
+type RetryPolicy struct {
+	Retries int           // number of retries after the first attempt, 0 disables retrying
+	Backoff time.Duration // delay before the first retry, doubled for each further retry
+}
+
+// ParseIGCFileWithRetry parses an IGC file from the filesystem, retrying transient read errors
+func ParseIGCFileWithRetry(filename string, opts Options) (*flight.Flight, error) {
+	return ParseIGCWithRetry(source.FileSystem{}, filename, opts)
+}
+
+// ParseIGCWithRetry reads ref from the given source, retrying transient read errors
+// according to opts.Retry, and parses the content into a Flight struct. Only reading
+// is retried: missing files, permission problems and invalid IGC content are permanent.
+func ParseIGCWithRetry(src source.Source, ref string, opts Options) (*flight.Flight, error) {
+	var data []byte
+	var err error
+
+	logger := opts.logger()
+	backoff := opts.Retry.Backoff
+	for attempt := 0; ; attempt++ {
+		data, err = readAll(src, ref)
+		if err == nil || attempt >= opts.Retry.Retries || !IsTransientError(err) {
+			break
+		}
+		logger.Debug("retrying read", "file", ref, "attempt", attempt+1, "error", err, "backoff", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", ref, err)
+	}
+
+	start := time.Now()
+	result, err := parseIGC(bytes.NewReader(data), true)
+	logParse(logger, ref, start, result, err)
+	if err != nil {
+		return nil, err
+	}
+	return result.Flight, nil
+}
+
+// IsTransientError reports whether a read error may succeed when retried. Missing
+// files, permission errors and invalid arguments are permanent; anything else (I/O
+// errors, stale network handles, timeouts) is considered transient.
+func IsTransientError(err error) bool {
+	return !errors.Is(err, fs.ErrNotExist) &&
+		!errors.Is(err, fs.ErrPermission) &&
+		!errors.Is(err, fs.ErrInvalid)
+}
+
+// readAll opens ref from the source and reads its whole content
+func readAll(src source.Source, ref string) ([]byte, error) {
+	file, err := src.Open(ref)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// MaxLineWarnings is the number of malformed lines reported individually in
+// ParseResult.Warnings; further ones are counted in a single warning
+const MaxLineWarnings = 10
+
+// ParseResult is a parsed flight with the non-fatal problems found in its file, such
+// as malformed lines that were skipped or missing headers
+type ParseResult struct {
+	Flight         *flight.Flight
+	Warnings       []string
+	MalformedLines int // lines go-igc could not read, all counted even past MaxLineWarnings
+}
+
+// ParseIGCWithWarnings opens ref from the given source and parses it like ParseIGC,
+// also returning the non-fatal problems found in the file
+func ParseIGCWithWarnings(src source.Source, ref string, opts Options) (*ParseResult, error) {
+	file, err := src.Open(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", ref, err)
+	}
+	defer file.Close()
+
+	start := time.Now()
+	result, err := parseIGC(file, true)
+	logParse(opts.logger(), ref, start, result, err)
+	return result, err
+}
+
+// ParseIGCReader parses IGC data from a reader and returns a Flight struct. Content
+// that is not a usable flight returns an error wrapping ErrEmptyFile, ErrNotIGC or
+// ErrNoFixes.
+func ParseIGCReader(r io.Reader) (*flight.Flight, error) {
+	result, err := parseIGC(r, true)
+	if err != nil {
+		return nil, err
+	}
+	return result.Flight, nil
+}
+
+// ParseIGCReaderWithWarnings parses IGC data from a reader like ParseIGCReader, also
+// returning the non-fatal problems found in the data
+func ParseIGCReaderWithWarnings(r io.Reader) (*ParseResult, error) {
+	return parseIGC(r, true)
+}
+
+// parseWarnings lists the missing headers and the malformed lines reported by go-igc,
+// up to MaxLineWarnings of them, and returns the number of malformed lines. Malformed
+// records are skipped, or kept with the fields that could be read.
+func parseWarnings(igcData *igc.IGC) ([]string, int) {
+	var warnings []string
+
+	if len(igcData.Records) > 0 {
+		if _, ok := igcData.Records[0].(*igc.ARecord); !ok {
+			warnings = append(warnings, "missing A record (logger manufacturer and ID)")
+		}
+	}
+	_, hasDate := igcData.HRecordsByTLC["DTE"]
+	if !hasDate {
+		warnings = append(warnings, "missing HFDTE record: the flight date and fix times are unknown")
+	}
+
+	var lineWarnings []string
+	for _, err := range igcData.Errs {
+		var lineErr *igc.Error
+		if !errors.As(err, &lineErr) {
+			lineWarnings = append(lineWarnings, err.Error())
+			continue
+		}
+		// Without HFDTE go-igc reports every timed record as having no date, which the
+		// warning above already covers
+		if !hasDate && lineErr.Err.Error() == "no date" {
+			continue
+		}
+		lineWarnings = append(lineWarnings, fmt.Sprintf("line %d: %v", lineErr.Line, lineErr.Err))
+	}
+	malformed := len(lineWarnings)
+	if len(lineWarnings) > MaxLineWarnings {
+		more := len(lineWarnings) - MaxLineWarnings
+		lineWarnings = append(lineWarnings[:MaxLineWarnings], fmt.Sprintf("%d more malformed lines", more))
+	}
+
+	return append(warnings, lineWarnings...), malformed
+}
+
+// parseIGC parses IGC data from a reader, returning ErrNoFixes for data without B
+// records when requireFixes is set
+func parseIGC(r io.Reader, requireFixes bool) (*ParseResult, error) {
+	igcData, err := igc.Parse(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse IGC file: %w", err)
 	}
 
+	if len(igcData.Records) == 0 && len(igcData.Errs) == 0 {
+		return nil, ErrEmptyFile
+	}
 	// Check if the file has any valid IGC data
 	if len(igcData.HRecordsByTLC) == 0 && len(igcData.BRecords) == 0 {
-		return nil, fmt.Errorf("file does not contain valid IGC data")
+		return nil, fmt.Errorf("%w: no H or B records", ErrNotIGC)
+	}
+	if requireFixes && len(igcData.BRecords) == 0 {
+		return nil, ErrNoFixes
 	}
 
 	// Convert from go-igc format to our internal format
 	var f flight.Flight
 
-	// Extract date from HFDTE record
-	if hfdteRecord, exists := igcData.HRecordsByTLC["DTE"]; exists && hfdteRecord != nil {
-		if hfdteRecord.Value != "" && len(hfdteRecord.Value) >= 6 {
-			dateStr := hfdteRecord.Value[:6] // DDMMYY format
-			// Use time.Parse with Go's reference time format for DDMMYY (020106)
-			if parsedDate, parseErr := time.Parse("020106", dateStr); parseErr == nil {
-				f.Date = parsedDate
-			}
-		}
+	f.Date, f.FlightOfDay, err = parseDate(igcData)
+	if err != nil {
+		return nil, err
 	}
 
 	// Extract pilot information from H records
@@ -66,8 +427,102 @@ func ParseIGCFile(filename string) (*flight.Flight, error) {
 	f.AltGPSRef = getHRecordValue(igcData.HRecordsByTLC, "ALG")
 	f.AltPressureRef = getHRecordValue(igcData.HRecordsByTLC, "ALP")
 
+	f.Task = parseTask(igcData.Records)
+	f.Comments = parseComments(igcData.Records)
+
 	// Convert B records to our Fix format
 	f.Fixes = igcData.BRecords
 
-	return &f, nil
+	// go-igc dates fixes with its own century pivot (1993); move them to the same
+	// century as the parsed date
+	if len(f.Fixes) > 0 && !f.Date.IsZero() {
+		if years := f.Date.Year() - f.Fixes[0].Time.Year(); years <= -50 || years >= 50 {
+			for _, fix := range f.Fixes {
+				fix.Time = fix.Time.AddDate(years/100*100, 0, 0)
+			}
+		}
+	}
+	// go-igc already adds a day to fixes past midnight UTC, so no rollover correction is
+	// needed here; only the days it adds at logger clock resets are taken back
+	undoClockResetRollovers(f.Fixes)
+
+	warnings, malformed := parseWarnings(igcData)
+	return &ParseResult{Flight: &f, Warnings: warnings, MalformedLines: malformed}, nil
+}
+
+// undoClockResetRollovers takes back the days go-igc adds to B record times. B records
+// only carry the time of day, so go-igc adds a day whenever a fix is timed before the
+// previous one, which is right past midnight UTC (23:59:59 then 00:00:01) but turns a
+// logger clock reset of a few seconds into a jump of nearly a day. A jump forward of
+// more than flight.RolloverThreshold is such a reset; the day is removed from that fix
+// and every fix after it, leaving the backward step for the statistics to skip.
+func undoClockResetRollovers(fixes []*igc.BRecord) {
+	var offset time.Duration
+	for i, fix := range fixes {
+		if i > 0 && fix.Time.Add(offset).Sub(fixes[i-1].Time) > flight.RolloverThreshold {
+			offset -= 24 * time.Hour
+		}
+		fix.Time = fix.Time.Add(offset)
+	}
+}
+
+// parseComments returns the logger comments of the L records, or nil if there are none
+func parseComments(records []igc.Record) []flight.Comment {
+	var comments []flight.Comment
+	for _, record := range records {
+		switch record := record.(type) {
+		case *igc.LRecord:
+			if record != nil {
+				comments = append(comments, flight.Comment{Source: record.Input, Text: strings.TrimSpace(record.Text)})
+			}
+		case *igc.LRecordWithoutTLC:
+			if record != nil {
+				comments = append(comments, flight.Comment{Text: strings.TrimSpace(record.Text)})
+			}
+		}
+	}
+	return comments
+}
+
+// parseTask builds the declared task from the C records, or returns nil if there are
+// none. The waypoint records are, in order: takeoff, start, turnpoints, finish, landing.
+func parseTask(records []igc.Record) *flight.Task {
+	var declaration *igc.CRecordDeclaration
+	var waypoints []flight.Waypoint
+
+	for _, record := range records {
+		switch record := record.(type) {
+		case *igc.CRecordDeclaration:
+			if record != nil {
+				declaration = record
+			}
+		case *igc.CRecordWaypoint:
+			if record != nil {
+				waypoints = append(waypoints, flight.Waypoint{
+					Name: strings.TrimSpace(record.Text),
+					Lat:  record.Lat,
+					Lon:  record.Lon,
+				})
+			}
+		}
+	}
+
+	// A usable task needs at least takeoff, start, finish and landing
+	if len(waypoints) < 4 {
+		return nil
+	}
+
+	task := &flight.Task{
+		Takeoff:    waypoints[0],
+		Start:      waypoints[1],
+		Turnpoints: waypoints[2 : len(waypoints)-2],
+		Finish:     waypoints[len(waypoints)-2],
+		Landing:    waypoints[len(waypoints)-1],
+	}
+	if declaration != nil {
+		task.DeclarationTime = declaration.DeclarationTime
+		task.Description = strings.TrimSpace(declaration.Text)
+	}
+
+	return task
 }
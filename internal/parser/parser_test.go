@@ -1,10 +1,23 @@
 package parser
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"igc-tool/internal/flight"
+
 	"github.com/twpayne/go-igc"
 )
 
@@ -106,7 +119,7 @@ B1152234548857N00614806EA012220150000308
 	tmpFile.Close()
 
 	// Parse the file
-	flight, err := ParseIGCFile(tmpFile.Name())
+	flight, err := ParseIGCFile(tmpFile.Name(), Options{})
 	if err != nil {
 		t.Fatalf("failed to parse IGC file: %v", err)
 	}
@@ -146,3 +159,507 @@ B1152234548857N00614806EA012220150000308
 		}
 	}
 }
+
+func TestParseIGCReader(t *testing.T) {
+	igcContent := "AXSDUB54EB\nHFDTE300723\nHFPLTPILOTINCHARGE:TestPilot\nB1152214548857N00614809EA012230150000308\n"
+
+	flight, err := ParseIGCReader(strings.NewReader(igcContent))
+	if err != nil {
+		t.Fatalf("failed to parse IGC data: %v", err)
+	}
+
+	if flight.Pilot != "TestPilot" {
+		t.Errorf("expected pilot 'TestPilot', got '%s'", flight.Pilot)
+	}
+	if len(flight.Fixes) != 1 {
+		t.Errorf("expected 1 fix, got %d", len(flight.Fixes))
+	}
+
+	if _, err := ParseIGCReader(strings.NewReader("")); err == nil {
+		t.Errorf("expected error for empty input")
+	}
+}
+
+func TestParseComments(t *testing.T) {
+	igcContent := `AXSDUB54EB
+HFDTE300723
+LXCTSENDER XCTrack 0.9.11
+B1152214548857N00614809EA012230150000308
+LPFCEVENT THERMAL START
+L bare comment
+B1152224548857N00614809EA012230150000308
+`
+
+	parsed, err := ParseIGCReader(strings.NewReader(igcContent))
+	if err != nil {
+		t.Fatalf("failed to parse IGC data: %v", err)
+	}
+
+	expected := []flight.Comment{
+		{Source: "XCT", Text: "SENDER XCTrack 0.9.11"},
+		{Source: "PFC", Text: "EVENT THERMAL START"},
+		{Text: "bare comment"},
+	}
+	if !reflect.DeepEqual(parsed.Comments, expected) {
+		t.Errorf("expected comments %v, got %v", expected, parsed.Comments)
+	}
+	if len(parsed.Fixes) != 2 {
+		t.Errorf("expected 2 fixes, got %d", len(parsed.Fixes))
+	}
+}
+
+func TestParseTaskDeclaration(t *testing.T) {
+	igcContent := `AXSDUB54EB
+HFDTE300723
+C300723102030300723000102Triangle
+C0000000N00000000ETAKEOFF
+C4548000N00614000ESTART
+C4600000N00614000ETP1
+C4600000N00630000ETP2
+C4548000N00614000EFINISH
+C0000000N00000000ELANDING
+B1152214548857N00614809EA012230150000308
+`
+
+	flight, err := ParseIGCReader(strings.NewReader(igcContent))
+	if err != nil {
+		t.Fatalf("failed to parse IGC data: %v", err)
+	}
+
+	if flight.Task == nil {
+		t.Fatal("expected a declared task")
+	}
+
+	task := flight.Task
+	if task.Description != "Triangle" {
+		t.Errorf("expected description 'Triangle', got '%s'", task.Description)
+	}
+	if task.Start.Name != "START" || task.Finish.Name != "FINISH" {
+		t.Errorf("expected START/FINISH, got %s/%s", task.Start.Name, task.Finish.Name)
+	}
+	if len(task.Turnpoints) != 2 || task.Turnpoints[0].Name != "TP1" || task.Turnpoints[1].Name != "TP2" {
+		t.Errorf("expected turnpoints TP1, TP2, got %+v", task.Turnpoints)
+	}
+	if task.Start.Lat != 45.8 || task.Start.Lon != 6.0+14.0/60 {
+		t.Errorf("unexpected start position %f,%f", task.Start.Lat, task.Start.Lon)
+	}
+
+	// START -> TP1 (~22 km north) -> TP2 (~21 km east) -> FINISH
+	distance := flight.CalculateTaskDistance()
+	if distance < 60000 || distance > 75000 {
+		t.Errorf("expected task distance around 67 km, got %f m", distance)
+	}
+}
+
+func TestParseWithoutTaskDeclaration(t *testing.T) {
+	igcContent := "AXSDUB54EB\nHFDTE300723\nB1152214548857N00614809EA012230150000308\n"
+
+	flight, err := ParseIGCReader(strings.NewReader(igcContent))
+	if err != nil {
+		t.Fatalf("failed to parse IGC data: %v", err)
+	}
+
+	if flight.Task != nil {
+		t.Errorf("expected no task, got %+v", flight.Task)
+	}
+	if distance := flight.CalculateTaskDistance(); distance != 0 {
+		t.Errorf("expected 0 task distance, got %f", distance)
+	}
+}
+
+func TestParseIGCHeadersReader(t *testing.T) {
+	igcContent := "AXSDUB54EB\nHFDTE300723\nHFPLTPILOTINCHARGE:TestPilot\nHFGTYGLIDERTYPE:TestGlider\n" +
+		"B1152214548857N00614809EA012230150000308\nB1152224548857N00614809EA012230150000308\n"
+
+	flight, err := ParseIGCHeadersReader(strings.NewReader(igcContent))
+	if err != nil {
+		t.Fatalf("failed to parse IGC headers: %v", err)
+	}
+
+	if flight.Pilot != "TestPilot" {
+		t.Errorf("expected pilot 'TestPilot', got '%s'", flight.Pilot)
+	}
+	if flight.GliderType != "TestGlider" {
+		t.Errorf("expected glider type 'TestGlider', got '%s'", flight.GliderType)
+	}
+	if len(flight.Fixes) != 0 {
+		t.Errorf("expected no fixes, got %d", len(flight.Fixes))
+	}
+
+	// Headers without a trailing newline or fixes are still parsed
+	flight, err = ParseIGCHeadersReader(strings.NewReader("AXSDUB54EB\nHFPLTPILOTINCHARGE:TestPilot"))
+	if err != nil {
+		t.Fatalf("failed to parse IGC headers: %v", err)
+	}
+	if flight.Pilot != "TestPilot" {
+		t.Errorf("expected pilot 'TestPilot', got '%s'", flight.Pilot)
+	}
+}
+
+// flakySource fails to open the reference a fixed number of times before succeeding
+type flakySource struct {
+	content  string
+	failures int
+	err      error
+	opens    int
+}
+
+func (s *flakySource) Open(ref string) (io.ReadCloser, error) {
+	s.opens++
+	if s.opens <= s.failures {
+		return nil, s.err
+	}
+	return io.NopCloser(strings.NewReader(s.content)), nil
+}
+
+func (s *flakySource) List(ref string, recursive bool) ([]string, error) {
+	return []string{ref}, nil
+}
+
+func TestParseIGCWithRetry(t *testing.T) {
+	igcContent := "AXSDUB54EB\nHFDTE300723\nB1152214548857N00614809EA012230150000308\n"
+	transient := &fs.PathError{Op: "read", Path: "flight.igc", Err: syscall.EIO}
+
+	tests := []struct {
+		name          string
+		content       string
+		failures      int
+		err           error
+		retries       int
+		expectError   bool
+		expectedOpens int
+	}{
+		{"no failures", igcContent, 0, nil, 0, false, 1},
+		{"transient error recovered", igcContent, 2, transient, 3, false, 3},
+		{"transient error exhausts retries", igcContent, 3, transient, 2, true, 3},
+		{"retries disabled", igcContent, 1, transient, 0, true, 1},
+		{"missing file not retried", igcContent, 1, fs.ErrNotExist, 3, true, 1},
+		{"permission error not retried", igcContent, 1, fmt.Errorf("open: %w", fs.ErrPermission), 3, true, 1},
+		{"invalid content not retried", "not an igc file\n", 0, nil, 3, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &flakySource{content: tt.content, failures: tt.failures, err: tt.err}
+			_, err := ParseIGCWithRetry(src, "flight.igc", Options{Retry: RetryPolicy{Retries: tt.retries, Backoff: time.Millisecond}})
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if src.opens != tt.expectedOpens {
+				t.Errorf("expected %d open attempts, got %d", tt.expectedOpens, src.opens)
+			}
+		})
+	}
+}
+
+func TestParseDateFormats(t *testing.T) {
+	tests := []struct {
+		name                string
+		dateRecord          string
+		expectedDate        time.Time
+		expectedFlightOfDay int
+	}{
+		{"legacy format", "HFDTE300723", time.Date(2023, 7, 30, 0, 0, 0, 0, time.UTC), 0},
+		{"new format with flight number", "HFDTEDATE:300723,01", time.Date(2023, 7, 30, 0, 0, 0, 0, time.UTC), 1},
+		{"new format second flight", "HFDTEDATE:010824,02", time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), 2},
+		{"new format without flight number", "HFDTEDATE:300723", time.Date(2023, 7, 30, 0, 0, 0, 0, time.UTC), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			igcContent := "AXSDUB54EB\n" + tt.dateRecord + "\nB1152214548857N00614809EA012230150000308\n"
+
+			flight, err := ParseIGCReader(strings.NewReader(igcContent))
+			if err != nil {
+				t.Fatalf("failed to parse IGC data: %v", err)
+			}
+
+			if !flight.Date.Equal(tt.expectedDate) {
+				t.Errorf("expected date %v, got %v", tt.expectedDate, flight.Date)
+			}
+			if flight.FlightOfDay != tt.expectedFlightOfDay {
+				t.Errorf("expected flight of day %d, got %d", tt.expectedFlightOfDay, flight.FlightOfDay)
+			}
+		})
+	}
+}
+
+func TestParseDateCenturyAndErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		dateRecord   string
+		expectedDate time.Time
+		expectError  bool
+	}{
+		{"year 69 is 2069", "HFDTE010169", time.Date(2069, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"year 70 is 1970", "HFDTE010170", time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"year 99 is 1999", "HFDTE311299", time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{"year 00 is 2000", "HFDTEDATE:290200,01", time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC), false},
+		{"too short", "HFDTE3007", time.Time{}, true},
+		{"invalid month", "HFDTE301323", time.Time{}, true},
+		{"invalid day", "HFDTE310623", time.Time{}, true},
+		{"invalid flight number", "HFDTEDATE:300723,XX", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			igcContent := "AXSDUB54EB\n" + tt.dateRecord + "\nB1152214548857N00614809EA012230150000308\n"
+
+			flight, err := ParseIGCReader(strings.NewReader(igcContent))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got date %v", flight.Date)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse IGC data: %v", err)
+			}
+
+			if !flight.Date.Equal(tt.expectedDate) {
+				t.Errorf("expected date %v, got %v", tt.expectedDate, flight.Date)
+			}
+			if fixDate := flight.Fixes[0].Time.Truncate(24 * time.Hour); !fixDate.Equal(tt.expectedDate) {
+				t.Errorf("expected fix on %v, got %v", tt.expectedDate, flight.Fixes[0].Time)
+			}
+		})
+	}
+}
+
+func TestValidateReader(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		expectErr error
+	}{
+		{"valid", "AXSDUB54EB\nHFDTE300723\nB1152214548857N00614809EA012230150000308\n", nil},
+		{"CRLF line endings", "AXSDUB54EB\r\nHFDTE300723\r\nB1152214548857N00614809EA012230150000308\r\n", nil},
+		{"byte order mark", "\uFEFFAXSDUB54EB\nB1152214548857N00614809EA012230150000308", nil},
+		{"garbage", "not an igc file\n", ErrNotIGC},
+		{"empty", "", ErrEmptyFile},
+		{"headers only", "AXSDUB54EB\nHFDTE300723\n", ErrNotIGC},
+		{"B record first", "B1152214548857N00614809EA012230150000308\n", ErrNotIGC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateReader(strings.NewReader(tt.content))
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				if !IsInvalidContent(err) {
+					t.Errorf("expected %v to be invalid content", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseIGCErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		expectErr error
+	}{
+		{"empty", "", ErrEmptyFile},
+		{"blank lines", "\n\n", ErrEmptyFile},
+		{"garbage", "not an igc file\n", ErrNotIGC},
+		{"headers only", "AXSDUB54EB\nHFDTE300723\nHFPLTPILOTINCHARGE:TestPilot\n", ErrNoFixes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseIGCReader(strings.NewReader(tt.content))
+			if !errors.Is(err, tt.expectErr) {
+				t.Errorf("expected %v, got %v", tt.expectErr, err)
+			}
+			if !IsInvalidContent(err) {
+				t.Errorf("expected %v to be invalid content", err)
+			}
+		})
+	}
+
+	// Headers are parsed without fixes
+	if _, err := ParseIGCHeadersReader(strings.NewReader("AXSDUB54EB\nHFDTE300723\n")); err != nil {
+		t.Errorf("unexpected error parsing headers: %v", err)
+	}
+
+	// Missing files are not invalid content
+	_, err := ParseIGCFile(filepath.Join(t.TempDir(), "missing.igc"), Options{})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if IsInvalidContent(err) {
+		t.Errorf("expected a missing file not to be invalid content")
+	}
+}
+
+func TestParseIGCReaderWithWarnings(t *testing.T) {
+	const fix = "B1152214548857N00614809EA012230150000308"
+
+	tests := []struct {
+		name              string
+		content           string
+		expectedFixes     int
+		expectedWarnings  []string
+		expectedMalformed int
+	}{
+		{
+			name:          "clean file",
+			content:       "AXSDUB54EB\nHFDTE300723\n" + fix + "\n" + fix + "\n",
+			expectedFixes: 2,
+		},
+		{
+			name:              "malformed B record",
+			content:           "AXSDUB54EB\nHFDTE300723\n" + fix + "\nB11522\n" + fix + "\n",
+			expectedFixes:     2,
+			expectedWarnings:  []string{"line 4: "},
+			expectedMalformed: 1,
+		},
+		{
+			name:             "missing headers",
+			content:          "HFPLTPILOTINCHARGE:TestPilot\n" + fix + "\n",
+			expectedFixes:    1,
+			expectedWarnings: []string{"missing A record", "missing HFDTE record"},
+		},
+		{
+			name:          "many malformed lines",
+			content:       "AXSDUB54EB\nHFDTE300723\n" + fix + "\n" + strings.Repeat("B11522\n", MaxLineWarnings+3),
+			expectedFixes: 1,
+			expectedWarnings: append(slices.Repeat([]string{"line "}, MaxLineWarnings),
+				"3 more malformed lines"),
+			expectedMalformed: MaxLineWarnings + 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseIGCReaderWithWarnings(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(result.Flight.Fixes) != tt.expectedFixes {
+				t.Errorf("expected %d fixes, got %d", tt.expectedFixes, len(result.Flight.Fixes))
+			}
+			if result.MalformedLines != tt.expectedMalformed {
+				t.Errorf("expected %d malformed lines, got %d", tt.expectedMalformed, result.MalformedLines)
+			}
+			if len(result.Warnings) != len(tt.expectedWarnings) {
+				t.Fatalf("expected %d warnings, got %d: %q", len(tt.expectedWarnings), len(result.Warnings), result.Warnings)
+			}
+			for i, expected := range tt.expectedWarnings {
+				if !strings.Contains(result.Warnings[i], expected) {
+					t.Errorf("expected warning %d to contain %q, got %q", i, expected, result.Warnings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseMidnightRollover(t *testing.T) {
+	tests := []struct {
+		name             string
+		bRecords         []string
+		expectedTimes    []time.Time
+		expectedDuration time.Duration
+	}{
+		{
+			name: "across midnight UTC",
+			bRecords: []string{
+				"B2359504548857N00614809EA012230150000308",
+				"B2359594548858N00614810EA012230150000308",
+				"B0000054548859N00614811EA012230150000308",
+				"B0000154548860N00614812EA012230150000308",
+			},
+			expectedTimes: []time.Time{
+				time.Date(2023, 7, 30, 23, 59, 50, 0, time.UTC),
+				time.Date(2023, 7, 30, 23, 59, 59, 0, time.UTC),
+				time.Date(2023, 7, 31, 0, 0, 5, 0, time.UTC),
+				time.Date(2023, 7, 31, 0, 0, 15, 0, time.UTC),
+			},
+			expectedDuration: 25 * time.Second,
+		},
+		{
+			name: "clock reset stays on the same day",
+			bRecords: []string{
+				"B1200004548857N00614809EA012230150000308",
+				"B1200104548858N00614810EA012230150000308",
+				"B1200054548859N00614811EA012230150000308",
+				"B1200204548860N00614812EA012230150000308",
+			},
+			expectedTimes: []time.Time{
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 10, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 5, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 20, 0, time.UTC),
+			},
+			expectedDuration: 20 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			igcContent := "AXSDUB54EB\nHFDTE300723\n" + strings.Join(tt.bRecords, "\n") + "\n"
+			flight, err := ParseIGCReader(strings.NewReader(igcContent))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(flight.Fixes) != len(tt.expectedTimes) {
+				t.Fatalf("expected %d fixes, got %d", len(tt.expectedTimes), len(flight.Fixes))
+			}
+			for i, expected := range tt.expectedTimes {
+				if !flight.Fixes[i].Time.Equal(expected) {
+					t.Errorf("fix %d: expected time %s, got %s", i, expected, flight.Fixes[i].Time)
+				}
+			}
+			if duration := flight.Duration(); duration != tt.expectedDuration {
+				t.Errorf("expected duration %s, got %s", tt.expectedDuration, duration)
+			}
+		})
+	}
+}
+
+func TestOptionsLogger(t *testing.T) {
+	var buf bytes.Buffer
+	opts := Options{Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.igc")
+	content := "HFPLTPILOTINCHARGE:TestPilot\nB1152214548857N00614809EA012230150000308\n"
+	if err := os.WriteFile(valid, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	empty := filepath.Join(dir, "empty.igc")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	if _, err := ParseIGCFile(valid, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ParseIGCFileWithRetry(empty, opts); err == nil {
+		t.Fatalf("expected an error for an empty file")
+	}
+
+	output := buf.String()
+	for _, expected := range []string{
+		`msg="parsed file" file=` + valid + " fixes=1",
+		`msg="parse warning" file=` + valid + ` warning="missing A record`,
+		`msg="failed to parse file" file=` + empty + ` error="empty file"`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected log to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
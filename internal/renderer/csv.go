@@ -0,0 +1,80 @@
+package renderer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"igc-tool/internal/flight"
+	"igc-tool/internal/units"
+	"igc-tool/internal/utils"
+)
+
+// FixesCSVOptions controls the columns and units of a fixes CSV export
+type FixesCSVOptions struct {
+	AltitudeUnit  string
+	TimeFormat    string
+	IncludeSpeeds bool // add ground speed, vertical speed and heading columns
+}
+
+// FixesCSVHeader returns the column names of a fixes CSV export
+func FixesCSVHeader(includeSpeeds bool) []string {
+	header := []string{"time", "lat", "lon", "alt_gps", "alt_baro"}
+	if includeSpeeds {
+		header = append(header, "ground_speed_kmh", "vertical_speed_ms", "heading_deg")
+	}
+	return header
+}
+
+// WriteFixesCSV writes one CSV row per fix with its time, position and GPS and
+// barometric altitudes, converted to opts.AltitudeUnit. With opts.IncludeSpeeds the
+// instantaneous ground speed in km/h and vertical speed in m/s since the previous fix
+// are added; they are empty for the first fix and for fixes sharing a timestamp. The
+// heading, the bearing from the previous fix, is also added, empty where the fixes are
+// too close for it to be meaningful.
+func WriteFixesCSV(w io.Writer, f *flight.Flight, opts FixesCSVOptions) error {
+	writer := csv.NewWriter(w)
+
+	var headings []float64
+	if opts.IncludeSpeeds {
+		headings = f.Headings()
+	}
+
+	if err := writer.Write(FixesCSVHeader(opts.IncludeSpeeds)); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for i, fix := range f.Fixes {
+		row := []string{
+			utils.FormatTime(fix.Time, opts.TimeFormat),
+			strconv.FormatFloat(fix.Lat, 'f', 6, 64),
+			strconv.FormatFloat(fix.Lon, 'f', 6, 64),
+			strconv.Itoa(int(math.Round(units.Altitude(fix.AltWGS84, opts.AltitudeUnit)))),
+			strconv.Itoa(int(math.Round(units.Altitude(fix.AltBarometric, opts.AltitudeUnit)))),
+		}
+
+		if opts.IncludeSpeeds {
+			groundSpeed, verticalSpeed := "", ""
+			if i > 0 {
+				if ground, vertical, ok := flight.FixSpeeds(f.Fixes[i-1], fix); ok {
+					groundSpeed = strconv.FormatFloat(ground, 'f', 1, 64)
+					verticalSpeed = strconv.FormatFloat(vertical, 'f', 1, 64)
+				}
+			}
+			heading := ""
+			if !math.IsNaN(headings[i]) {
+				heading = strconv.FormatFloat(headings[i], 'f', 0, 64)
+			}
+			row = append(row, groundSpeed, verticalSpeed, heading)
+		}
+
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,70 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestWriteFixesCSV(t *testing.T) {
+	baseTime := time.Date(2023, 7, 30, 13, 0, 0, 0, time.UTC)
+	f := &flight.Flight{
+		Fixes: []*igc.BRecord{
+			{Time: baseTime, Lat: 45.8, Lon: 6.2, AltWGS84: 1000, AltBarometric: 990},
+			{Time: baseTime.Add(10 * time.Second), Lat: 45.801, Lon: 6.2, AltWGS84: 1020, AltBarometric: 1010},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		opts     FixesCSVOptions
+		expected [][]string
+	}{
+		{
+			name: "meters and 24h",
+			opts: FixesCSVOptions{AltitudeUnit: "m", TimeFormat: "24h"},
+			expected: [][]string{
+				{"time", "lat", "lon", "alt_gps", "alt_baro"},
+				{"13:00:00", "45.800000", "6.200000", "1000", "990"},
+				{"13:00:10", "45.801000", "6.200000", "1020", "1010"},
+			},
+		},
+		{
+			name: "feet, am/pm and speeds",
+			opts: FixesCSVOptions{AltitudeUnit: "ft", TimeFormat: "ampm", IncludeSpeeds: true},
+			expected: [][]string{
+				{"time", "lat", "lon", "alt_gps", "alt_baro", "ground_speed_kmh", "vertical_speed_ms", "heading_deg"},
+				{"1:00:00 PM", "45.800000", "6.200000", "3281", "3248", "", "", ""},
+				{"1:00:10 PM", "45.801000", "6.200000", "3346", "3314", "40.0", "2.0", "0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteFixesCSV(&buf, f, tt.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatalf("failed to read CSV: %v", err)
+			}
+			if len(records) != len(tt.expected) {
+				t.Fatalf("expected %d rows, got %d", len(tt.expected), len(records))
+			}
+			for i := range records {
+				if strings.Join(records[i], ",") != strings.Join(tt.expected[i], ",") {
+					t.Errorf("row %d: expected %v, got %v", i, tt.expected[i], records[i])
+				}
+			}
+		})
+	}
+}
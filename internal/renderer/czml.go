@@ -0,0 +1,149 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"igc-tool/internal/flight"
+)
+
+// CZMLClock represents the playback clock of a CZML document packet
+type CZMLClock struct {
+	Interval    string  `json:"interval"`
+	CurrentTime string  `json:"currentTime"`
+	Multiplier  float64 `json:"multiplier"`
+	Range       string  `json:"range"`
+	Step        string  `json:"step"`
+}
+
+// CZMLPosition represents a time-sampled position. CartographicDegrees holds
+// flattened [offset, longitude, latitude, height] samples, where offset is the
+// number of seconds since Epoch.
+type CZMLPosition struct {
+	Epoch               string    `json:"epoch"`
+	CartographicDegrees []float64 `json:"cartographicDegrees"`
+}
+
+// CZMLColor represents a CZML color as RGBA components
+type CZMLColor struct {
+	RGBA []int `json:"rgba"`
+}
+
+// CZMLSolidColor represents a solid color material
+type CZMLSolidColor struct {
+	Color CZMLColor `json:"color"`
+}
+
+// CZMLMaterial represents a CZML material
+type CZMLMaterial struct {
+	SolidColor CZMLSolidColor `json:"solidColor"`
+}
+
+// CZMLPath represents the trail drawn behind an animated entity
+type CZMLPath struct {
+	Material  CZMLMaterial `json:"material"`
+	Width     float64      `json:"width"`
+	LeadTime  float64      `json:"leadTime"`
+	TrailTime float64      `json:"trailTime"`
+}
+
+// CZMLPoint represents the marker drawn at the entity position
+type CZMLPoint struct {
+	Color     CZMLColor `json:"color"`
+	PixelSize float64   `json:"pixelSize"`
+}
+
+// CZMLPacket represents a CZML packet; the first packet of a document must have the id "document"
+type CZMLPacket struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name,omitempty"`
+	Version      string        `json:"version,omitempty"`
+	Clock        *CZMLClock    `json:"clock,omitempty"`
+	Availability string        `json:"availability,omitempty"`
+	Position     *CZMLPosition `json:"position,omitempty"`
+	Path         *CZMLPath     `json:"path,omitempty"`
+	Point        *CZMLPoint    `json:"point,omitempty"`
+}
+
+// czmlTrackColor is the color used for the glider marker and its trail
+var czmlTrackColor = CZMLColor{RGBA: []int{255, 0, 0, 255}}
+
+// RenderToCZML converts a flight track to a CZML document for time-animated playback
+// in Cesium. The glider is an entity whose position is sampled at each valid fix, with
+// sample times stored as offsets in seconds from the first valid fix (the epoch).
+func RenderToCZML(flight *flight.Flight) ([]byte, error) {
+	if len(flight.Fixes) == 0 {
+		return nil, fmt.Errorf("no GPS fixes found in flight data")
+	}
+
+	var epoch, end time.Time
+	var samples []float64
+	for _, fix := range flight.Fixes {
+		if !fix.Valid() {
+			continue
+		}
+		if epoch.IsZero() {
+			epoch = fix.Time.UTC()
+		}
+		end = fix.Time.UTC()
+		samples = append(samples, end.Sub(epoch).Seconds(), fix.Lon, fix.Lat, fix.AltWGS84)
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no valid GPS fixes found in flight data")
+	}
+
+	interval := formatCZMLTime(epoch) + "/" + formatCZMLTime(end)
+
+	name := flight.Pilot
+	if name == "" {
+		name = "Flight"
+	}
+
+	document := []CZMLPacket{
+		{
+			ID:      "document",
+			Name:    name,
+			Version: "1.0",
+			Clock: &CZMLClock{
+				Interval:    interval,
+				CurrentTime: formatCZMLTime(epoch),
+				Multiplier:  10,
+				Range:       "LOOP_STOP",
+				Step:        "SYSTEM_CLOCK_MULTIPLIER",
+			},
+		},
+		{
+			ID:           "flight",
+			Name:         name,
+			Availability: interval,
+			Position: &CZMLPosition{
+				Epoch:               formatCZMLTime(epoch),
+				CartographicDegrees: samples,
+			},
+			Path: &CZMLPath{
+				Material:  CZMLMaterial{SolidColor: CZMLSolidColor{Color: czmlTrackColor}},
+				Width:     2,
+				LeadTime:  0,
+				TrailTime: end.Sub(epoch).Seconds(),
+			},
+			Point: &CZMLPoint{
+				Color:     czmlTrackColor,
+				PixelSize: 8,
+			},
+		},
+	}
+
+	result, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CZML: %w", err)
+	}
+
+	return result, nil
+}
+
+// formatCZMLTime formats a time as an ISO 8601 UTC timestamp
+func formatCZMLTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,68 @@
+package renderer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestRenderToCZML(t *testing.T) {
+	baseTime := time.Date(2023, 7, 30, 23, 59, 50, 0, time.UTC)
+	f := &flight.Flight{
+		Pilot: "Test Pilot",
+		Fixes: []*igc.BRecord{
+			{Time: baseTime, Lat: 45.8, Lon: 6.2, AltWGS84: 1500},
+			{Time: baseTime.Add(10 * time.Second), Lat: 45.9, Lon: 6.3, AltWGS84: 1510},
+			{Time: baseTime.Add(20 * time.Second), Lat: 46.0, Lon: 6.4, AltWGS84: 1520},
+		},
+	}
+
+	data, err := RenderToCZML(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var packets []CZMLPacket
+	if err := json.Unmarshal(data, &packets); err != nil {
+		t.Fatalf("failed to unmarshal CZML: %v", err)
+	}
+
+	if len(packets) != 2 {
+		t.Fatalf("expected 2 packets, got %d", len(packets))
+	}
+	if packets[0].ID != "document" || packets[0].Version != "1.0" {
+		t.Errorf("expected document packet with version 1.0, got id %q version %q", packets[0].ID, packets[0].Version)
+	}
+
+	entity := packets[1]
+	if entity.Availability != "2023-07-30T23:59:50Z/2023-07-31T00:00:10Z" {
+		t.Errorf("unexpected availability %q", entity.Availability)
+	}
+	if entity.Position == nil {
+		t.Fatalf("expected a position")
+	}
+	if entity.Position.Epoch != "2023-07-30T23:59:50Z" {
+		t.Errorf("expected epoch at the first fix, got %q", entity.Position.Epoch)
+	}
+
+	// Offsets keep counting across midnight
+	expected := []float64{0, 6.2, 45.8, 1500, 10, 6.3, 45.9, 1510, 20, 6.4, 46.0, 1520}
+	if len(entity.Position.CartographicDegrees) != len(expected) {
+		t.Fatalf("expected %d values, got %d", len(expected), len(entity.Position.CartographicDegrees))
+	}
+	for i, value := range expected {
+		if entity.Position.CartographicDegrees[i] != value {
+			t.Errorf("value %d: expected %v, got %v", i, value, entity.Position.CartographicDegrees[i])
+		}
+	}
+}
+
+func TestRenderToCZMLNoFixes(t *testing.T) {
+	if _, err := RenderToCZML(&flight.Flight{}); err == nil {
+		t.Errorf("expected error for flight without fixes")
+	}
+}
@@ -0,0 +1,228 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+// KMLOptions controls the optional parts of a KML document
+type KMLOptions struct {
+	Indent       string // indentation per nesting level, empty for compact output
+	Track        bool   // add a time-stamped gx:Track for playback in Google Earth
+	ColorByClimb bool   // split the line into segments colored by vertical speed
+	Description  string // document description, e.g. flight metadata
+}
+
+// KML is the root element of a KML document
+type KML struct {
+	XMLName  xml.Name    `xml:"kml"`
+	XMLNS    string      `xml:"xmlns,attr"`
+	XMLNSGX  string      `xml:"xmlns:gx,attr"`
+	Document KMLDocument `xml:"Document"`
+}
+
+// KMLDocument holds the shared styles and placemarks of a KML document
+type KMLDocument struct {
+	Name        string         `xml:"name"`
+	Description string         `xml:"description,omitempty"`
+	Styles      []KMLStyle     `xml:"Style"`
+	Placemarks  []KMLPlacemark `xml:"Placemark"`
+}
+
+// KMLStyle represents a line style referenced by placemarks through its ID
+type KMLStyle struct {
+	ID        string       `xml:"id,attr"`
+	LineStyle KMLLineStyle `xml:"LineStyle"`
+}
+
+// KMLLineStyle represents the color, in aabbggrr hex notation, and width of a line
+type KMLLineStyle struct {
+	Color string  `xml:"color"`
+	Width float64 `xml:"width"`
+}
+
+// KMLPlacemark represents a line or time-stamped track
+type KMLPlacemark struct {
+	Name       string         `xml:"name"`
+	StyleURL   string         `xml:"styleUrl,omitempty"`
+	LineString *KMLLineString `xml:"LineString,omitempty"`
+	Track      *KMLTrack      `xml:"gx:Track,omitempty"`
+}
+
+// KMLLineString represents a line with "lon,lat,alt" tuples separated by spaces
+type KMLLineString struct {
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+// KMLTrack represents a gx:Track with one "when" timestamp per "gx:coord" position
+type KMLTrack struct {
+	AltitudeMode string   `xml:"altitudeMode"`
+	When         []string `xml:"when"`
+	Coords       []string `xml:"gx:coord"`
+}
+
+// kmlClimbClass is a vertical speed band of a track colored by climb rate
+type kmlClimbClass struct {
+	id      string
+	minRate float64 // lower bound in m/s of the band
+	color   string
+}
+
+// kmlClimbClasses are the vertical speed bands from strongest sink to strongest climb
+var kmlClimbClasses = []kmlClimbClass{
+	{id: "strong-sink", minRate: -1e9, color: "ffff0000"}, // blue
+	{id: "sink", minRate: -2, color: "ffffff00"},          // cyan
+	{id: "level", minRate: -flight.DefaultLevelThreshold, color: "ff00ff00"},
+	{id: "climb", minRate: flight.DefaultLevelThreshold, color: "ff00ffff"}, // yellow
+	{id: "strong-climb", minRate: 2, color: "ff0000ff"},                     // red
+}
+
+// kmlTrackColor is the line color of a track that is not colored by climb rate (red)
+const kmlTrackColor = "ff0000ff"
+
+// RenderToKML converts a flight track to a KML document for Google Earth. Altitudes use
+// altitudeMode absolute so the track floats at the recorded GPS altitude.
+func RenderToKML(flight *flight.Flight, opts KMLOptions) ([]byte, error) {
+	fixes := validFixes(flight)
+	if len(fixes) == 0 {
+		return nil, fmt.Errorf("no valid GPS fixes found in flight data")
+	}
+
+	name := flight.Pilot
+	if name == "" {
+		name = "Flight"
+	}
+	if !flight.Date.IsZero() {
+		name += " " + flight.Date.Format("2006-01-02")
+	}
+
+	document := KMLDocument{
+		Name:        name,
+		Description: opts.Description,
+	}
+
+	if opts.ColorByClimb {
+		for _, class := range kmlClimbClasses {
+			document.Styles = append(document.Styles, KMLStyle{ID: class.id, LineStyle: KMLLineStyle{Color: class.color, Width: 3}})
+		}
+		document.Placemarks = append(document.Placemarks, climbSegments(fixes)...)
+	} else {
+		document.Styles = append(document.Styles, KMLStyle{ID: "track", LineStyle: KMLLineStyle{Color: kmlTrackColor, Width: 2}})
+		document.Placemarks = append(document.Placemarks, KMLPlacemark{
+			Name:       "Track",
+			StyleURL:   "#track",
+			LineString: lineString(fixes),
+		})
+	}
+
+	if opts.Track {
+		track := &KMLTrack{AltitudeMode: "absolute"}
+		for _, fix := range fixes {
+			track.When = append(track.When, fix.Time.UTC().Format(time.RFC3339))
+			track.Coords = append(track.Coords, formatFloat(fix.Lon)+" "+formatFloat(fix.Lat)+" "+formatFloat(fix.AltWGS84))
+		}
+		document.Placemarks = append(document.Placemarks, KMLPlacemark{Name: "Timed track", Track: track})
+	}
+
+	kml := KML{
+		XMLNS:    "http://www.opengis.net/kml/2.2",
+		XMLNSGX:  "http://www.google.com/kml/ext/2.2",
+		Document: document,
+	}
+
+	var body []byte
+	var err error
+	if opts.Indent != "" {
+		body, err = xml.MarshalIndent(kml, "", opts.Indent)
+	} else {
+		body, err = xml.Marshal(kml)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal KML: %w", err)
+	}
+
+	return append([]byte(xml.Header), body...), nil
+}
+
+// climbSegments splits the track into placemarks of consecutive fixes in the same
+// vertical speed band, each segment starting at the last fix of the previous one so
+// the line stays continuous
+func climbSegments(fixes []*igc.BRecord) []KMLPlacemark {
+	var placemarks []KMLPlacemark
+	start := 0
+	class := climbClass(fixes, 0)
+	for i := 1; i <= len(fixes); i++ {
+		if i < len(fixes) && climbClass(fixes, i) == class {
+			continue
+		}
+		end := i
+		if end < len(fixes) {
+			end++ // include the first fix of the next segment
+		}
+		placemarks = append(placemarks, KMLPlacemark{
+			Name:       class,
+			StyleURL:   "#" + class,
+			LineString: lineString(fixes[start:end]),
+		})
+		if i < len(fixes) {
+			start = i
+			class = climbClass(fixes, i)
+		}
+	}
+	return placemarks
+}
+
+// climbClass returns the ID of the vertical speed band of the fix at index i, using
+// the climb rate over the trailing flight.VerticalWindowSeconds to smooth GPS noise
+func climbClass(fixes []*igc.BRecord, i int) string {
+	windowStart := i
+	for windowStart > 0 && fixes[i].Time.Sub(fixes[windowStart-1].Time).Seconds() <= flight.VerticalWindowSeconds {
+		windowStart--
+	}
+
+	rate := 0.0
+	if seconds := fixes[i].Time.Sub(fixes[windowStart].Time).Seconds(); seconds > 0 {
+		rate = (fixes[i].AltWGS84 - fixes[windowStart].AltWGS84) / seconds
+	}
+
+	id := kmlClimbClasses[0].id
+	for _, class := range kmlClimbClasses {
+		if rate >= class.minRate {
+			id = class.id
+		}
+	}
+	return id
+}
+
+// validFixes returns the valid fixes of a flight
+func validFixes(flight *flight.Flight) []*igc.BRecord {
+	var fixes []*igc.BRecord
+	for _, fix := range flight.Fixes {
+		if fix.Valid() {
+			fixes = append(fixes, fix)
+		}
+	}
+	return fixes
+}
+
+// lineString returns an absolute altitude line through the given fixes
+func lineString(fixes []*igc.BRecord) *KMLLineString {
+	coordinates := make([]string, len(fixes))
+	for i, fix := range fixes {
+		coordinates[i] = formatFloat(fix.Lon) + "," + formatFloat(fix.Lat) + "," + formatFloat(fix.AltWGS84)
+	}
+	return &KMLLineString{AltitudeMode: "absolute", Coordinates: strings.Join(coordinates, " ")}
+}
+
+// formatFloat formats a coordinate with the fewest digits that represent it exactly
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
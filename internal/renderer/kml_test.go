@@ -0,0 +1,88 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestRenderToKML(t *testing.T) {
+	baseTime := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+	// Level for 20 seconds, then climbing at 3 m/s for 20 seconds
+	var fixes []*igc.BRecord
+	for i := 0; i <= 40; i++ {
+		alt := 1500.0
+		if i > 20 {
+			alt += float64(i-20) * 3
+		}
+		fixes = append(fixes, &igc.BRecord{Time: baseTime.Add(time.Duration(i) * time.Second), Lat: 45.8 + float64(i)*0.0001, Lon: 6.2, AltWGS84: alt})
+	}
+	f := &flight.Flight{Pilot: "Test Pilot", Date: baseTime, Fixes: fixes}
+
+	tests := []struct {
+		name               string
+		opts               KMLOptions
+		expectedPlacemarks []string
+		expectTrack        bool
+	}{
+		{name: "plain", opts: KMLOptions{}, expectedPlacemarks: []string{"Track"}},
+		{name: "with timed track", opts: KMLOptions{Track: true}, expectedPlacemarks: []string{"Track", "Timed track"}, expectTrack: true},
+		{name: "colored by climb", opts: KMLOptions{ColorByClimb: true}, expectedPlacemarks: []string{"level", "climb", "strong-climb"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := RenderToKML(f, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(string(data), xml.Header) {
+				t.Errorf("expected an XML header")
+			}
+
+			var kml KML
+			if err := xml.Unmarshal(data, &kml); err != nil {
+				t.Fatalf("failed to unmarshal KML: %v", err)
+			}
+			if kml.Document.Name != "Test Pilot 2023-07-30" {
+				t.Errorf("unexpected document name %q", kml.Document.Name)
+			}
+
+			var names []string
+			coordinates := 0
+			for _, placemark := range kml.Document.Placemarks {
+				names = append(names, placemark.Name)
+				if placemark.LineString != nil {
+					if placemark.LineString.AltitudeMode != "absolute" {
+						t.Errorf("expected absolute altitude mode, got %q", placemark.LineString.AltitudeMode)
+					}
+					coordinates += len(strings.Fields(placemark.LineString.Coordinates))
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expectedPlacemarks, ",") {
+				t.Errorf("expected placemarks %v, got %v", tt.expectedPlacemarks, names)
+			}
+			// Colored segments share their boundary fixes
+			if coordinates < len(fixes) {
+				t.Errorf("expected at least %d coordinates, got %d", len(fixes), coordinates)
+			}
+
+			if tt.expectTrack {
+				if !strings.Contains(string(data), "<gx:Track>") || !strings.Contains(string(data), "<when>2023-07-30T12:00:40Z</when>") {
+					t.Errorf("expected a gx:Track with timestamps")
+				}
+			}
+		})
+	}
+}
+
+func TestRenderToKMLNoFixes(t *testing.T) {
+	if _, err := RenderToKML(&flight.Flight{}, KMLOptions{}); err == nil {
+		t.Errorf("expected error for flight without fixes")
+	}
+}
@@ -0,0 +1,180 @@
+package scoring
+
+import (
+	"fmt"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+// Turnpoint counts of the free distance: OLC scores free flights with up to 3
+// turnpoints, and some leagues allow up to 5
+const (
+	DefaultTurnpoints = 3
+	MaxTurnpoints     = 5
+)
+
+// MaxOptimizationPoints is the number of fixes the exact search runs on. Longer tracks
+// are sampled down to it, then each chosen point is refined on the full track.
+const MaxOptimizationPoints = 1000
+
+// maxRefinePasses bounds the refinement passes, which usually settle in two or three
+const maxRefinePasses = 10
+
+// Point is a fix chosen as the start, a turnpoint or the finish of a scored flight
+type Point struct {
+	Index int // index of the fix in the flight
+	Time  time.Time
+	Lat   float64
+	Lon   float64
+}
+
+// Result is the best free distance found for a flight
+type Result struct {
+	Turnpoints int       // turnpoints allowed; fewer are used when they add no distance
+	Points     []Point   // start, turnpoints and finish, in track order
+	Legs       []float64 // length of each leg in meters
+	Distance   float64   // scored distance in meters, the sum of the legs
+}
+
+// ValidateTurnpoints checks that turnpoints is between 0 and MaxTurnpoints
+func ValidateTurnpoints(turnpoints int) error {
+	if turnpoints < 0 || turnpoints > MaxTurnpoints {
+		return fmt.Errorf("invalid number of turnpoints %d: must be between 0 and %d", turnpoints, MaxTurnpoints)
+	}
+	return nil
+}
+
+// FreeDistance finds the start, turnpoints and finish, in track order, maximizing the
+// distance flown through them, as scored for OLC free flights. The search is exact
+// for tracks of up to MaxOptimizationPoints fixes; longer tracks are searched on
+// evenly sampled fixes and the points found are then moved along the full track while
+// that increases the distance, which finds the optimum or comes within a few meters
+// of it. It returns nil for flights with fewer than 2 fixes.
+func FreeDistance(f *flight.Flight, turnpoints int) *Result {
+	fixes := f.Fixes
+	if len(fixes) < 2 {
+		return nil
+	}
+
+	step := (len(fixes) + MaxOptimizationPoints - 1) / MaxOptimizationPoints
+	sampled := make([]int, 0, len(fixes)/step+2)
+	for i := 0; i < len(fixes); i += step {
+		sampled = append(sampled, i)
+	}
+	if sampled[len(sampled)-1] != len(fixes)-1 {
+		sampled = append(sampled, len(fixes)-1)
+	}
+
+	indices := optimize(fixes, sampled, turnpoints+1)
+	if step > 1 {
+		refine(fixes, indices)
+	}
+
+	result := &Result{Turnpoints: turnpoints}
+	for i, index := range indices {
+		fix := fixes[index]
+		result.Points = append(result.Points, Point{Index: index, Time: fix.Time, Lat: fix.Lat, Lon: fix.Lon})
+		if i > 0 {
+			leg := distance(fixes[indices[i-1]], fix)
+			result.Legs = append(result.Legs, leg)
+			result.Distance += leg
+		}
+	}
+	return result
+}
+
+// optimize returns the fix indices, taken from candidates in order, of the path of
+// the given number of legs with the greatest length. best[p] holds the length of the
+// longest path with the current number of legs ending at candidate p, and
+// parents[leg][p] the candidate before p on that path; a candidate may repeat, which
+// makes a zero-length leg when a turnpoint adds no distance.
+func optimize(fixes []*igc.BRecord, candidates []int, legs int) []int {
+	n := len(candidates)
+	best := make([]float64, n)
+	next := make([]float64, n)
+	parents := make([][]int, legs)
+
+	for leg := range parents {
+		parents[leg] = make([]int, n)
+		for p := 0; p < n; p++ {
+			bestLength, bestParent := -1.0, p
+			for q := 0; q <= p; q++ {
+				length := best[q] + distance(fixes[candidates[q]], fixes[candidates[p]])
+				if length > bestLength {
+					bestLength, bestParent = length, q
+				}
+			}
+			next[p] = bestLength
+			parents[leg][p] = bestParent
+		}
+		best, next = next, best
+	}
+
+	end := 0
+	for p := 0; p < n; p++ {
+		if best[p] > best[end] {
+			end = p
+		}
+	}
+
+	path := make([]int, legs+1)
+	path[legs] = end
+	for leg := legs - 1; leg >= 0; leg-- {
+		path[leg] = parents[leg][path[leg+1]]
+	}
+	for i, p := range path {
+		path[i] = candidates[p]
+	}
+	return path
+}
+
+// refine moves each point of the path to the fix between its neighbors that makes
+// the longest path, repeating while the path gets longer
+func refine(fixes []*igc.BRecord, path []int) {
+	last := len(path) - 1
+	for pass := 0; pass < maxRefinePasses; pass++ {
+		improved := false
+		for i, current := range path {
+			from, to := 0, len(fixes)-1
+			if i > 0 {
+				from = path[i-1]
+			}
+			if i < last {
+				to = path[i+1]
+			}
+
+			length := func(index int) float64 {
+				var total float64
+				if i > 0 {
+					total += distance(fixes[path[i-1]], fixes[index])
+				}
+				if i < last {
+					total += distance(fixes[index], fixes[path[i+1]])
+				}
+				return total
+			}
+
+			bestIndex, bestLength := current, length(current)
+			for index := from; index <= to; index++ {
+				if l := length(index); l > bestLength {
+					bestIndex, bestLength = index, l
+				}
+			}
+			if bestIndex != current {
+				path[i] = bestIndex
+				improved = true
+			}
+		}
+		if !improved {
+			return
+		}
+	}
+}
+
+// distance returns the distance in meters between two fixes
+func distance(a, b *igc.BRecord) float64 {
+	return flight.HaversineDistance(a.Lat, a.Lon, b.Lat, b.Lon)
+}
@@ -0,0 +1,318 @@
+package scoring
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+// buildTrack returns a flight through the given points, one fix a second
+func buildTrack(points [][2]float64) *flight.Flight {
+	baseTime := time.Date(2023, 7, 30, 11, 0, 0, 0, time.UTC)
+	fixes := make([]*igc.BRecord, len(points))
+	for i, p := range points {
+		fixes[i] = &igc.BRecord{Time: baseTime.Add(time.Duration(i) * time.Second), Lat: p[0], Lon: p[1]}
+	}
+	return &flight.Flight{Fixes: fixes}
+}
+
+// randomWalk returns n points of a random walk from 45N 6E
+func randomWalk(rng *rand.Rand, n int, stepDegrees float64) [][2]float64 {
+	points := make([][2]float64, n)
+	lat, lon := 45.0, 6.0
+	for i := range points {
+		lat += (rng.Float64() - 0.5) * stepDegrees
+		lon += (rng.Float64() - 0.5) * stepDegrees
+		points[i] = [2]float64{lat, lon}
+	}
+	return points
+}
+
+// bruteForce returns the longest path through the given number of turnpoints by
+// trying every combination of fixes in order
+func bruteForce(fixes []*igc.BRecord, turnpoints int) float64 {
+	var search func(from, remaining int) float64
+	search = func(from, remaining int) float64 {
+		if remaining == 0 {
+			return 0
+		}
+		best := 0.0
+		for next := from; next < len(fixes); next++ {
+			best = math.Max(best, distance(fixes[from], fixes[next])+search(next, remaining-1))
+		}
+		return best
+	}
+
+	best := 0.0
+	for start := range fixes {
+		best = math.Max(best, search(start, turnpoints+1))
+	}
+	return best
+}
+
+func TestFreeDistance(t *testing.T) {
+	tests := []struct {
+		name             string
+		points           [][2]float64
+		turnpoints       int
+		expectedDistance float64
+		expectedIndices  []int
+	}{
+		{
+			name:             "straight line",
+			points:           [][2]float64{{45.0, 6.0}, {45.05, 6.0}, {45.1, 6.0}},
+			turnpoints:       3,
+			expectedDistance: flight.HaversineDistance(45.0, 6.0, 45.1, 6.0),
+		},
+		{
+			name:             "out and return",
+			points:           [][2]float64{{45.0, 6.0}, {45.05, 6.0}, {45.1, 6.0}, {45.05, 6.0}, {45.0, 6.0}},
+			turnpoints:       1,
+			expectedDistance: 2 * flight.HaversineDistance(45.0, 6.0, 45.1, 6.0),
+			expectedIndices:  []int{0, 2, 4},
+		},
+		{
+			name:             "out and return without turnpoints",
+			points:           [][2]float64{{45.0, 6.0}, {45.05, 6.0}, {45.1, 6.0}, {45.05, 6.0}, {45.0, 6.0}},
+			turnpoints:       0,
+			expectedDistance: flight.HaversineDistance(45.0, 6.0, 45.1, 6.0),
+		},
+		{
+			name:       "triangle",
+			points:     [][2]float64{{45.0, 6.0}, {45.1, 6.0}, {45.05, 6.05}, {45.1, 6.1}, {45.0, 6.1}},
+			turnpoints: 3,
+			expectedDistance: flight.HaversineDistance(45.0, 6.0, 45.1, 6.0) +
+				flight.HaversineDistance(45.1, 6.0, 45.05, 6.05) +
+				flight.HaversineDistance(45.05, 6.05, 45.1, 6.1) +
+				flight.HaversineDistance(45.1, 6.1, 45.0, 6.1),
+			expectedIndices: []int{0, 1, 2, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FreeDistance(buildTrack(tt.points), tt.turnpoints)
+
+			if math.Abs(result.Distance-tt.expectedDistance) > 0.01 {
+				t.Errorf("expected distance %.1f, got %.1f", tt.expectedDistance, result.Distance)
+			}
+			if len(result.Points) != tt.turnpoints+2 || len(result.Legs) != tt.turnpoints+1 {
+				t.Fatalf("expected %d points and %d legs, got %d and %d",
+					tt.turnpoints+2, tt.turnpoints+1, len(result.Points), len(result.Legs))
+			}
+			for i := 1; i < len(result.Points); i++ {
+				if result.Points[i].Index < result.Points[i-1].Index {
+					t.Errorf("expected points in track order, got %v", result.Points)
+				}
+			}
+			if tt.expectedIndices != nil {
+				for i, index := range tt.expectedIndices {
+					if result.Points[i].Index != index {
+						t.Errorf("point %d: expected fix %d, got %d", i, index, result.Points[i].Index)
+					}
+				}
+			}
+		})
+	}
+
+	if FreeDistance(buildTrack([][2]float64{{45.0, 6.0}}), 3) != nil {
+		t.Errorf("expected no result for a single fix")
+	}
+}
+
+func TestFreeDistanceMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 5; trial++ {
+		f := buildTrack(randomWalk(rng, 25, 0.02))
+		for turnpoints := 0; turnpoints <= 2; turnpoints++ {
+			expected := bruteForce(f.Fixes, turnpoints)
+			if result := FreeDistance(f, turnpoints); math.Abs(result.Distance-expected) > 0.01 {
+				t.Errorf("trial %d, %d turnpoints: expected %.1f, got %.1f", trial, turnpoints, expected, result.Distance)
+			}
+		}
+	}
+}
+
+func TestFreeDistanceLongTrack(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	points := randomWalk(rng, 3*MaxOptimizationPoints+17, 0.002)
+	f := buildTrack(points)
+
+	result := FreeDistance(f, DefaultTurnpoints)
+
+	// The sampled search and refinement is at least as good as the exact search on the
+	// sampled fixes alone
+	var sampled [][2]float64
+	for i := 0; i < len(points); i += 4 {
+		sampled = append(sampled, points[i])
+	}
+	if coarse := FreeDistance(buildTrack(sampled), DefaultTurnpoints); result.Distance < coarse.Distance-0.01 {
+		t.Errorf("expected at least the distance over every 4th fix %.1f, got %.1f", coarse.Distance, result.Distance)
+	}
+
+	total := 0.0
+	for i, leg := range result.Legs {
+		a, b := result.Points[i], result.Points[i+1]
+		if b.Index < a.Index {
+			t.Errorf("expected points in track order, got %d after %d", b.Index, a.Index)
+		}
+		if expected := flight.HaversineDistance(a.Lat, a.Lon, b.Lat, b.Lon); math.Abs(leg-expected) > 0.01 {
+			t.Errorf("leg %d: expected %.1f, got %.1f", i, expected, leg)
+		}
+		total += leg
+	}
+	if math.Abs(total-result.Distance) > 0.01 {
+		t.Errorf("expected the distance %.1f to be the sum of the legs %.1f", result.Distance, total)
+	}
+}
+
+func TestValidateTurnpoints(t *testing.T) {
+	for _, turnpoints := range []int{0, 3, MaxTurnpoints} {
+		if err := ValidateTurnpoints(turnpoints); err != nil {
+			t.Errorf("expected %d to be valid, got %v", turnpoints, err)
+		}
+	}
+	for _, turnpoints := range []int{-1, MaxTurnpoints + 1} {
+		if err := ValidateTurnpoints(turnpoints); err == nil {
+			t.Errorf("expected %d to be invalid", turnpoints)
+		}
+	}
+}
+
+func TestIsFAITriangle(t *testing.T) {
+	tests := []struct {
+		name     string
+		legs     [3]float64
+		expected bool
+	}{
+		{"equilateral", [3]float64{10000, 10000, 10000}, true},
+		{"shortest leg above 28%", [3]float64{28500, 35750, 35750}, true},
+		{"shortest leg below 28%", [3]float64{27000, 36500, 36500}, false},
+		{"large with shortest leg at 25%", [3]float64{200000, 300000, 300000}, true},
+		{"large with longest leg above 45%", [3]float64{250000, 250000, 460000}, false},
+		{"degenerate", [3]float64{0, 0, 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := IsFAITriangle(tt.legs); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+// trianglePoints returns a track flying from the first corner around the triangle
+// through the other two and back to within gap degrees of latitude of the start, with
+// steps fixes per leg
+func trianglePoints(corners [3][2]float64, gap float64, steps int) [][2]float64 {
+	end := [2]float64{corners[0][0] + gap, corners[0][1]}
+	waypoints := [][2]float64{corners[0], corners[1], corners[2], end}
+
+	var points [][2]float64
+	for leg := 0; leg < 3; leg++ {
+		from, to := waypoints[leg], waypoints[leg+1]
+		for i := 0; i < steps; i++ {
+			fraction := float64(i) / float64(steps)
+			points = append(points, [2]float64{from[0] + (to[0]-from[0])*fraction, from[1] + (to[1]-from[1])*fraction})
+		}
+	}
+	return append(points, end)
+}
+
+func TestTriangle(t *testing.T) {
+	// About 11 km legs, a FAI triangle
+	equilateral := [3][2]float64{{45.0, 6.0}, {45.1, 6.0}, {45.05, 6.122}}
+	// A long thin triangle, closed but not FAI
+	flat := [3][2]float64{{45.0, 6.0}, {45.2, 6.0}, {45.1, 6.03}}
+
+	tests := []struct {
+		name        string
+		points      [][2]float64
+		fai         bool
+		expectFound bool
+		expectFAI   bool
+	}{
+		{"closed FAI triangle", trianglePoints(equilateral, 0, 10), true, true, true},
+		{"closed FAI triangle with a gap", trianglePoints(equilateral, 0.01, 10), true, true, true},
+		{"flat triangle", trianglePoints(flat, 0, 10), false, true, false},
+		{"FAI triangle within a flat one", trianglePoints(flat, 0, 10), true, true, true},
+		{"open distance", [][2]float64{{45.0, 6.0}, {45.1, 6.0}, {45.2, 6.0}, {45.3, 6.0}}, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Triangle(buildTrack(tt.points), tt.fai)
+			if !tt.expectFound {
+				if result != nil {
+					t.Errorf("expected no triangle, got %+v", result)
+				}
+				return
+			}
+			if result == nil {
+				t.Fatal("expected a triangle")
+			}
+
+			if result.FAI != tt.expectFAI {
+				t.Errorf("expected FAI %v, got %v", tt.expectFAI, result.FAI)
+			}
+			if math.Abs(result.Perimeter-(result.Legs[0]+result.Legs[1]+result.Legs[2])) > 0.01 {
+				t.Errorf("expected the perimeter to be the sum of the legs, got %.1f", result.Perimeter)
+			}
+			if math.Abs(result.Distance-(result.Perimeter-result.ClosingDistance)) > 0.01 {
+				t.Errorf("expected the distance to be the perimeter less the closing distance, got %.1f", result.Distance)
+			}
+			if result.ClosingStart.Index > result.Points[0].Index || result.ClosingEnd.Index < result.Points[2].Index {
+				t.Errorf("expected the closing fixes outside the triangle, got %d and %d", result.ClosingStart.Index, result.ClosingEnd.Index)
+			}
+			if result.ClosingDistance > ClosingRatio*result.Perimeter {
+				t.Errorf("expected a closed triangle, got a gap of %.1f for a perimeter of %.1f", result.ClosingDistance, result.Perimeter)
+			}
+		})
+	}
+
+	// The FAI triangle within the flat one is smaller
+	flatTrack := buildTrack(trianglePoints(flat, 0, 10))
+	if faiResult, flatResult := Triangle(flatTrack, true), Triangle(flatTrack, false); flatResult.FAI || faiResult.Distance >= flatResult.Distance {
+		t.Errorf("expected a larger flat triangle than FAI one, got %.0f (FAI %v) and %.0f", flatResult.Distance, flatResult.FAI, faiResult.Distance)
+	}
+
+	// The vertices of the equilateral track are its corners, and the gap is deducted
+	result := Triangle(buildTrack(trianglePoints(equilateral, 0.01, 10)), true)
+	for i, index := range []int{0, 10, 20} {
+		if result.Points[i].Index != index {
+			t.Errorf("vertex %d: expected fix %d, got %d", i, index, result.Points[i].Index)
+		}
+	}
+	expectedGap := flight.HaversineDistance(45.0, 6.0, 45.01, 6.0)
+	if math.Abs(result.ClosingDistance-expectedGap) > 1 {
+		t.Errorf("expected closing distance %.0f, got %.0f", expectedGap, result.ClosingDistance)
+	}
+}
+
+func TestTriangleLongTrack(t *testing.T) {
+	equilateral := [3][2]float64{{45.0, 6.0}, {45.1, 6.0}, {45.05, 6.122}}
+	steps := MaxTrianglePoints
+	f := buildTrack(trianglePoints(equilateral, 0, steps))
+
+	result := Triangle(f, true)
+	if result == nil {
+		t.Fatal("expected a triangle")
+	}
+	for i, index := range []int{0, steps, 2 * steps} {
+		if result.Points[i].Index != index {
+			t.Errorf("vertex %d: expected fix %d, got %d", i, index, result.Points[i].Index)
+		}
+	}
+	expected := flight.HaversineDistance(45.0, 6.0, 45.1, 6.0) +
+		flight.HaversineDistance(45.1, 6.0, 45.05, 6.122) +
+		flight.HaversineDistance(45.05, 6.122, 45.0, 6.0)
+	if math.Abs(result.Distance-expected) > 1 {
+		t.Errorf("expected distance %.0f, got %.0f", expected, result.Distance)
+	}
+}
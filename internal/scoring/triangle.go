@@ -0,0 +1,226 @@
+package scoring
+
+import (
+	"math"
+
+	"igc-tool/internal/flight"
+
+	"github.com/twpayne/go-igc"
+)
+
+// ClosingRatio is the largest gap between the start and the end of a triangle, as a
+// share of its perimeter, for it to count as closed. The gap is deducted from the
+// perimeter to give the scored distance.
+const ClosingRatio = 0.2
+
+// FAI triangle shape rules: every leg is at least FAIMinLegRatio of the perimeter, or
+// for triangles of at least FAILargeTriangleDistance, between FAILargeMinLegRatio and
+// FAILargeMaxLegRatio of it
+const (
+	FAIMinLegRatio           = 0.28
+	FAILargeTriangleDistance = 750000.0 // meters
+	FAILargeMinLegRatio      = 0.25
+	FAILargeMaxLegRatio      = 0.45
+)
+
+// MaxTrianglePoints is the number of fixes the triangle search runs on. The search
+// tries every triangle, so longer tracks are sampled down to it and the vertices found
+// are then refined on the full track.
+const MaxTrianglePoints = 300
+
+// TriangleResult is the best closed triangle found for a flight
+type TriangleResult struct {
+	Points    [3]Point   // the triangle vertices, in track order
+	Legs      [3]float64 // lengths in meters of the legs from each vertex to the next, the last closing back to the first
+	Perimeter float64    // sum of the legs in meters
+	// ClosingStart and ClosingEnd are the fixes before the first vertex and after the last
+	// one closest to each other, and ClosingDistance the gap between them in meters
+	ClosingStart    Point
+	ClosingEnd      Point
+	ClosingDistance float64
+	Distance        float64 // scored distance in meters, the perimeter less the closing distance
+	FAI             bool    // the legs meet the FAI triangle shape rules
+}
+
+// IsFAITriangle reports whether legs with the given lengths in meters meet the FAI
+// triangle shape rules
+func IsFAITriangle(legs [3]float64) bool {
+	perimeter := legs[0] + legs[1] + legs[2]
+	if perimeter <= 0 {
+		return false
+	}
+	shortest := math.Min(legs[0], math.Min(legs[1], legs[2]))
+	longest := math.Max(legs[0], math.Max(legs[1], legs[2]))
+	if perimeter >= FAILargeTriangleDistance {
+		return shortest >= FAILargeMinLegRatio*perimeter && longest <= FAILargeMaxLegRatio*perimeter
+	}
+	return shortest >= FAIMinLegRatio*perimeter
+}
+
+// Triangle finds the closed triangle with the greatest scored distance, or returns nil
+// when the flight closes no triangle. A triangle is three fixes in track order; it is
+// closed when a fix before the first vertex and one after the last are within
+// ClosingRatio of the perimeter of each other. With fai set, only triangles meeting
+// the FAI shape rules are considered. The search is exact on tracks of up to
+// MaxTrianglePoints fixes and approximate, though usually within a few meters, on
+// longer ones.
+func Triangle(f *flight.Flight, fai bool) *TriangleResult {
+	fixes := f.Fixes
+	if len(fixes) < 3 {
+		return nil
+	}
+
+	step := (len(fixes) + MaxTrianglePoints - 1) / MaxTrianglePoints
+	var sampled []int
+	for i := 0; i < len(fixes); i += step {
+		sampled = append(sampled, i)
+	}
+	if sampled[len(sampled)-1] != len(fixes)-1 {
+		sampled = append(sampled, len(fixes)-1)
+	}
+
+	vertices, ok := searchTriangle(fixes, sampled, fai)
+	if !ok {
+		return nil
+	}
+	result := triangleResult(fixes, vertices, step)
+	if step > 1 {
+		// Moving the first or last vertex changes the fixes that can close the triangle,
+		// so the refined triangle is only kept when it still closes and scores higher
+		refined := vertices
+		refineTriangle(fixes, &refined, step, fai)
+		if r := triangleResult(fixes, refined, step); r.ClosingDistance <= ClosingRatio*r.Perimeter && r.Distance > result.Distance {
+			return r
+		}
+	}
+	return result
+}
+
+// searchTriangle tries every triangle of candidate fixes, returning the fix indices of
+// the closed one with the greatest scored distance
+func searchTriangle(fixes []*igc.BRecord, candidates []int, fai bool) ([3]int, bool) {
+	n := len(candidates)
+	distances := make([][]float64, n)
+	for i := range distances {
+		distances[i] = make([]float64, n)
+		for j := range distances[i] {
+			distances[i][j] = distance(fixes[candidates[i]], fixes[candidates[j]])
+		}
+	}
+
+	// gaps[a][c] is the closest a candidate up to a comes to one from c onward
+	gaps := make([][]float64, n)
+	for a := range gaps {
+		gaps[a] = make([]float64, n)
+		for c := n - 1; c >= a; c-- {
+			gap := distances[a][c]
+			if a > 0 {
+				gap = math.Min(gap, gaps[a-1][c])
+			}
+			if c < n-1 {
+				gap = math.Min(gap, gaps[a][c+1])
+			}
+			gaps[a][c] = gap
+		}
+	}
+
+	var best [3]int
+	bestDistance := 0.0
+	found := false
+	for a := 0; a < n; a++ {
+		for c := a + 2; c < n; c++ {
+			closing := distances[c][a]
+			gap := gaps[a][c]
+			for b := a + 1; b < c; b++ {
+				legs := [3]float64{distances[a][b], distances[b][c], closing}
+				perimeter := legs[0] + legs[1] + legs[2]
+				if perimeter-gap <= bestDistance || gap > ClosingRatio*perimeter {
+					continue
+				}
+				if fai && !IsFAITriangle(legs) {
+					continue
+				}
+				best = [3]int{candidates[a], candidates[b], candidates[c]}
+				bestDistance = perimeter - gap
+				found = true
+			}
+		}
+	}
+	return best, found
+}
+
+// refineTriangle moves each vertex within step fixes of its position, keeping the track
+// order and the FAI shape when fai is set, while that makes the perimeter longer
+func refineTriangle(fixes []*igc.BRecord, vertices *[3]int, step int, fai bool) {
+	legs := func(v [3]int) [3]float64 {
+		return [3]float64{
+			distance(fixes[v[0]], fixes[v[1]]),
+			distance(fixes[v[1]], fixes[v[2]]),
+			distance(fixes[v[2]], fixes[v[0]]),
+		}
+	}
+	perimeter := func(l [3]float64) float64 { return l[0] + l[1] + l[2] }
+
+	for pass := 0; pass < maxRefinePasses; pass++ {
+		improved := false
+		for i := range vertices {
+			from, to := max(vertices[i]-step, 0), min(vertices[i]+step, len(fixes)-1)
+			if i > 0 {
+				from = max(from, vertices[i-1]+1)
+			}
+			if i < 2 {
+				to = min(to, vertices[i+1]-1)
+			}
+
+			bestPerimeter := perimeter(legs(*vertices))
+			for index := from; index <= to; index++ {
+				candidate := *vertices
+				candidate[i] = index
+				l := legs(candidate)
+				if p := perimeter(l); p > bestPerimeter && (!fai || IsFAITriangle(l)) {
+					*vertices = candidate
+					bestPerimeter = p
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			return
+		}
+	}
+}
+
+// triangleResult builds the result for the triangle with the given vertices, finding
+// the closest fixes before the first vertex and after the last one. With a step above
+// 1, only every step-th fix is compared and then those around the closest pair.
+func triangleResult(fixes []*igc.BRecord, vertices [3]int, step int) *TriangleResult {
+	result := &TriangleResult{}
+	for i, index := range vertices {
+		fix := fixes[index]
+		result.Points[i] = Point{Index: index, Time: fix.Time, Lat: fix.Lat, Lon: fix.Lon}
+		result.Legs[i] = distance(fix, fixes[vertices[(i+1)%3]])
+		result.Perimeter += result.Legs[i]
+	}
+	result.FAI = IsFAITriangle(result.Legs)
+
+	start, end := vertices[0], vertices[2]
+	result.ClosingDistance = distance(fixes[start], fixes[end])
+	closest := func(fromStart, toStart, fromEnd, toEnd, stride int) {
+		for s := fromStart; s <= toStart; s += stride {
+			for e := fromEnd; e <= toEnd; e += stride {
+				if d := distance(fixes[s], fixes[e]); d < result.ClosingDistance {
+					start, end, result.ClosingDistance = s, e, d
+				}
+			}
+		}
+	}
+	closest(0, vertices[0], vertices[2], len(fixes)-1, step)
+	if step > 1 {
+		closest(max(start-step, 0), min(start+step, vertices[0]), max(end-step, vertices[2]), min(end+step, len(fixes)-1), 1)
+	}
+	result.ClosingStart = Point{Index: start, Time: fixes[start].Time, Lat: fixes[start].Lat, Lon: fixes[start].Lon}
+	result.ClosingEnd = Point{Index: end, Time: fixes[end].Time, Lat: fixes[end].Lat, Lon: fixes[end].Lon}
+	result.Distance = result.Perimeter - result.ClosingDistance
+
+	return result
+}
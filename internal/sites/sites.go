@@ -3,6 +3,7 @@ package sites
 import (
 	"encoding/csv"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 
@@ -10,13 +11,17 @@ import (
 	"igc-tool/internal/utils"
 
 	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/planar"
 )
 
-// LandingSite represents a landing site with name, center point, and radius
+// LandingSite represents a landing site with name, center point, and radius, or an
+// optional polygon boundary for irregular fields
 type LandingSite struct {
-	Name   string
-	Center orb.Point
-	Radius float64 // radius in meters
+	Name    string
+	Center  orb.Point
+	Radius  float64     // radius in meters
+	Polygon orb.Polygon // boundary in [longitude, latitude] points, nil for circular sites
 }
 
 // Collection holds a collection of landing sites
@@ -24,7 +29,10 @@ type Collection struct {
 	Sites []LandingSite
 }
 
-// LoadLandingSites loads landing sites from a CSV file
+// LoadLandingSites loads landing sites from a CSV file with the columns name, lat, lon
+// and radius, and an optional fifth column holding the site boundary as a WKT polygon
+// in longitude/latitude order, e.g. "POLYGON((6.24 45.81, 6.25 45.81, 6.25 45.82, 6.24 45.81))".
+// The radius may be left empty for polygon sites.
 func LoadLandingSites(filename string) (*Collection, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -33,6 +41,7 @@ func LoadLandingSites(filename string) (*Collection, error) {
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // rows with and without a polygon may be mixed
 	records, err := reader.ReadAll()
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse CSV: %w", err)
@@ -52,8 +61,16 @@ func LoadLandingSites(filename string) (*Collection, error) {
 
 	for i := startRow; i < len(records); i++ {
 		record := records[i]
-		if len(record) != 4 {
-			continue // Skip rows that don't have exactly 4 columns
+		if len(record) != 4 && len(record) != 5 {
+			continue // Skip rows that don't have 4 columns, or 5 with a polygon
+		}
+
+		var polygon orb.Polygon
+		if len(record) == 5 && record[4] != "" {
+			polygon, err = wkt.UnmarshalPolygon(record[4])
+			if err != nil {
+				continue
+			}
 		}
 
 		name := record[0]
@@ -71,31 +88,93 @@ func LoadLandingSites(filename string) (*Collection, error) {
 			continue
 		}
 
-		radius, err := strconv.ParseFloat(record[3], 64)
-		if err != nil {
-			continue
+		var radius float64
+		if record[3] != "" || polygon == nil {
+			radius, err = strconv.ParseFloat(record[3], 64)
+			if err != nil {
+				continue
+			}
 		}
 
 		sites = append(sites, LandingSite{
-			Name:   name,
-			Center: orb.Point{lon, lat}, // orb.Point is [longitude, latitude]
-			Radius: radius,
+			Name:    name,
+			Center:  orb.Point{lon, lat}, // orb.Point is [longitude, latitude]
+			Radius:  radius,
+			Polygon: polygon,
 		})
 	}
 
 	return &Collection{Sites: sites}, nil
 }
 
-// FindLandingSite finds the landing site name for given coordinates
+// FindLandingSite finds the landing site name for given coordinates, as Lookup does,
+// or formats the coordinates with utils.FormatCoordinates when no site contains them
 func (c *Collection) FindLandingSite(lat, lon float64) string {
+	if name, ok := c.Lookup(lat, lon); ok {
+		return name
+	}
+	return utils.FormatCoordinates(lat, lon, utils.DefaultCoordPrecision)
+}
+
+// Lookup returns the name of the landing site containing the given coordinates and
+// whether there is one. Polygon sites containing the point take precedence over
+// circular sites within their radius.
+func (c *Collection) Lookup(lat, lon float64) (string, bool) {
+	point := orb.Point{lon, lat}
 	for _, site := range c.Sites {
+		if site.Polygon != nil && planar.PolygonContains(site.Polygon, point) {
+			return site.Name, true
+		}
+	}
+
+	for _, site := range c.Sites {
+		if site.Polygon != nil {
+			continue
+		}
+
 		siteLat := site.Center[1]
 		siteLon := site.Center[0]
 		distance := flight.HaversineDistance(lat, lon, siteLat, siteLon)
 
 		if distance <= site.Radius {
-			return site.Name
+			return site.Name, true
 		}
 	}
-	return utils.FormatCoordinates(lat, lon)
+	return "", false
+}
+
+// FindNearestLandingSite returns the landing site closest to the given coordinates,
+// whether or not the point lies within it, with the distance in meters from the point
+// to the site's edge (its radius or polygon boundary). inside reports whether the point
+// is within the site, in which case the distance is 0. The zero LandingSite is
+// returned for an empty collection.
+func (c *Collection) FindNearestLandingSite(lat, lon float64) (site LandingSite, distance float64, inside bool) {
+	if len(c.Sites) == 0 {
+		return LandingSite{}, 0, false
+	}
+
+	distance = math.Inf(1)
+	for _, candidate := range c.Sites {
+		if d := candidate.distanceFrom(lat, lon); d < distance {
+			site, distance = candidate, d
+		}
+	}
+	return site, distance, distance == 0
+}
+
+// distanceFrom returns the distance in meters from a point to the edge of the site, 0 when inside
+func (s LandingSite) distanceFrom(lat, lon float64) float64 {
+	if s.Polygon == nil {
+		return math.Max(0, flight.HaversineDistance(lat, lon, s.Center[1], s.Center[0])-s.Radius)
+	}
+
+	if planar.PolygonContains(s.Polygon, orb.Point{lon, lat}) {
+		return 0
+	}
+
+	nearest := math.Inf(1)
+	for _, ring := range s.Polygon {
+		nearest = math.Min(nearest, flight.RingDistance(lat, lon, ring))
+	}
+	return nearest
 }
@@ -3,6 +3,8 @@ package sites
 import (
 	"os"
 	"testing"
+
+	"github.com/paulmach/orb"
 )
 
 func TestLoadLandingSites(t *testing.T) {
@@ -314,3 +316,125 @@ func TestFindLandingSiteEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadLandingSitesWithPolygon(t *testing.T) {
+	content := `name,lat,lon,radius,polygon
+Field,45.8145,6.2465,,"POLYGON((6.246 45.814, 6.247 45.814, 6.247 45.815, 6.246 45.815, 6.246 45.814))"
+Circle,46.456,7.123,1000
+BadPolygon,46.456,7.123,,"POLYGON((oops))"`
+
+	tmpFile, err := os.CreateTemp("", "sites_*.csv")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	collection, err := LoadLandingSites(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(collection.Sites) != 2 {
+		t.Fatalf("expected 2 sites, got %d", len(collection.Sites))
+	}
+	if len(collection.Sites[0].Polygon) != 1 || len(collection.Sites[0].Polygon[0]) != 5 {
+		t.Errorf("expected a polygon with one 5-point ring, got %v", collection.Sites[0].Polygon)
+	}
+	if collection.Sites[1].Polygon != nil || collection.Sites[1].Radius != 1000 {
+		t.Errorf("expected a circular site with 1000m radius, got %+v", collection.Sites[1])
+	}
+}
+
+func TestFindLandingSiteWithPolygon(t *testing.T) {
+	collection := &Collection{
+		Sites: []LandingSite{
+			{
+				Name:   "Circle",
+				Center: orb.Point{6.2465, 45.8145},
+				Radius: 1000,
+			},
+			{
+				Name:    "Field",
+				Center:  orb.Point{6.2465, 45.8145},
+				Polygon: orb.Polygon{{{6.246, 45.814}, {6.247, 45.814}, {6.247, 45.815}, {6.246, 45.815}, {6.246, 45.814}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		lat      float64
+		lon      float64
+		expected string
+	}{
+		{name: "inside polygon takes precedence", lat: 45.8145, lon: 6.2465, expected: "Field"},
+		{name: "outside polygon falls back to radius", lat: 45.816, lon: 6.2465, expected: "Circle"},
+		{name: "outside all sites", lat: 45.900, lon: 6.2465, expected: "45.900,6.247"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := collection.FindLandingSite(tt.lat, tt.lon)
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFindNearestLandingSite(t *testing.T) {
+	collection := &Collection{
+		Sites: []LandingSite{
+			{
+				Name:   "Circle",
+				Center: orb.Point{6.246, 45.814},
+				Radius: 100,
+			},
+			{
+				Name:    "Field",
+				Center:  orb.Point{7.0005, 46.0005},
+				Polygon: orb.Polygon{{{7.000, 46.000}, {7.001, 46.000}, {7.001, 46.001}, {7.000, 46.001}, {7.000, 46.000}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		lat, lon         float64
+		expectedSite     string
+		expectedDistance float64
+		tolerance        float64
+		expectedInside   bool
+	}{
+		{name: "inside circle", lat: 45.814, lon: 6.246, expectedSite: "Circle", expectedDistance: 0, expectedInside: true},
+		{name: "outside circle", lat: 45.816, lon: 6.246, expectedSite: "Circle", expectedDistance: 122, tolerance: 1},
+		{name: "inside polygon", lat: 46.0005, lon: 7.0005, expectedSite: "Field", expectedDistance: 0, expectedInside: true},
+		{name: "south of polygon", lat: 45.999, lon: 7.0005, expectedSite: "Field", expectedDistance: 111, tolerance: 1},
+		{name: "past polygon corner", lat: 45.999, lon: 7.002, expectedSite: "Field", expectedDistance: 135, tolerance: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site, distance, inside := collection.FindNearestLandingSite(tt.lat, tt.lon)
+			if site.Name != tt.expectedSite {
+				t.Errorf("expected site %s, got %s", tt.expectedSite, site.Name)
+			}
+			if distance < tt.expectedDistance-tt.tolerance || distance > tt.expectedDistance+tt.tolerance {
+				t.Errorf("expected distance %.0fm, got %.0fm", tt.expectedDistance, distance)
+			}
+			if inside != tt.expectedInside {
+				t.Errorf("expected inside %v, got %v", tt.expectedInside, inside)
+			}
+		})
+	}
+
+	empty := &Collection{}
+	if site, _, inside := empty.FindNearestLandingSite(45.814, 6.246); site.Name != "" || inside {
+		t.Errorf("expected no site for empty collection, got %+v", site)
+	}
+}
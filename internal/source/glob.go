@@ -0,0 +1,96 @@
+package source
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// IsGlob reports whether ref contains glob metacharacters
+func IsGlob(ref string) bool {
+	return strings.ContainsAny(ref, "*?[")
+}
+
+// Glob returns the IGC files matching pattern. Each path segment is matched with
+// filepath.Match semantics, and a "**" segment matches any number of directories,
+// including none. It is an error for the pattern to match no IGC files.
+func Glob(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for _, segment := range segments {
+		if _, err := filepath.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+	}
+
+	// Walk from the longest leading run of literal segments
+	static := 0
+	for static < len(segments) && !IsGlob(segments[static]) {
+		static++
+	}
+	base := strings.Join(segments[:static], "/")
+	switch {
+	case static == 0:
+		base = "."
+	case base == "":
+		base = "/"
+	}
+	base = filepath.FromSlash(base)
+	rest := segments[static:]
+	unbounded := false
+	for _, segment := range rest {
+		if segment == "**" {
+			unbounded = true
+		}
+	}
+
+	var matches []string
+	err := filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if d.IsDir() {
+			if !unbounded && len(parts) >= len(rest) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsIGCFile(path) && matchSegments(rest, parts) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error expanding pattern %s: %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("pattern %s matched no IGC files", pattern)
+	}
+
+	return matches, nil
+}
+
+// matchSegments reports whether the path segments in name match the pattern segments
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	matched, _ := filepath.Match(pattern[0], name[0])
+	return matched && matchSegments(pattern[1:], name[1:])
+}
@@ -0,0 +1,98 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testFiles := []string{
+		"top.igc",
+		"2023/05/a.igc",
+		"2023/06/b.IGC",
+		"2023/06/c.igc.gz",
+		"2023/notes.txt",
+		"2024/d.igc",
+	}
+	for _, file := range testFiles {
+		fullPath := filepath.Join(tmpDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("test content"), 0644); err != nil {
+			t.Fatalf("failed to create file %s: %v", fullPath, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		pattern     string
+		expected    []string
+		expectError bool
+	}{
+		{name: "single level", pattern: "*.igc", expected: []string{"top.igc"}},
+		{name: "doublestar", pattern: "2023/**/*", expected: []string{"2023/05/a.igc", "2023/06/b.IGC", "2023/06/c.igc.gz"}},
+		{name: "doublestar matches zero directories", pattern: "**/*.igc", expected: []string{"2023/05/a.igc", "2024/d.igc", "top.igc"}},
+		{name: "wildcard directory", pattern: "*/06/*", expected: []string{"2023/06/b.IGC", "2023/06/c.igc.gz"}},
+		{name: "character class", pattern: "202[4]/*.igc", expected: []string{"2024/d.igc"}},
+		{name: "no match", pattern: "2025/**/*.igc", expectError: true},
+		{name: "only non-IGC files", pattern: "2023/*.txt", expectError: true},
+		{name: "bad pattern", pattern: "[*.igc", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Glob(filepath.Join(tmpDir, tt.pattern))
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got %v", files)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, file := range files {
+				rel, _ := filepath.Rel(tmpDir, file)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFileSystemListGlob(t *testing.T) {
+	tmpDir := t.TempDir()
+	literal := filepath.Join(tmpDir, "[1].igc")
+	if err := os.WriteFile(literal, []byte("test content"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	// An existing file is used as is even though its name looks like a pattern
+	files, err := FileSystem{}.List(literal, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || files[0] != literal {
+		t.Errorf("expected [%s], got %v", literal, files)
+	}
+
+	files, err = FileSystem{}.List(filepath.Join(tmpDir, "*.igc"), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected 1 file, got %v", files)
+	}
+}
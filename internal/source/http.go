@@ -0,0 +1,106 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Limits applied to IGC files fetched over HTTP
+const (
+	DefaultHTTPTimeout = 30 * time.Second
+	DefaultMaxSize     = 10 << 20 // 10 MiB, far above a long flight at 1 s fixes
+)
+
+// ErrTooLarge is returned when a fetched IGC file exceeds the size limit
+var ErrTooLarge = errors.New("response body exceeds size limit")
+
+// HTTP is a Source fetching single IGC files from http:// and https:// URLs
+type HTTP struct {
+	Client  *http.Client
+	MaxSize int64 // maximum body size in bytes
+}
+
+// NewHTTP creates an HTTP source with the default timeout and size limit
+func NewHTTP() HTTP {
+	return HTTP{
+		Client:  &http.Client{Timeout: DefaultHTTPTimeout},
+		MaxSize: DefaultMaxSize,
+	}
+}
+
+// IsURL reports whether ref is an http:// or https:// URL
+func IsURL(ref string) bool {
+	lower := strings.ToLower(ref)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// ForRef returns the Source able to open ref: HTTP for URLs, the local filesystem otherwise
+func ForRef(ref string) Source {
+	if IsURL(ref) {
+		return NewHTTP()
+	}
+	return FileSystem{}
+}
+
+// Open fetches the URL, decompressing .gz files transparently. Responses other than 200
+// OK and bodies larger than MaxSize, compressed or not, are reported as errors; the size
+// is checked while reading when it is not announced.
+func (h HTTP) Open(ref string) (io.ReadCloser, error) {
+	resp, err := h.Client.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+
+	if resp.ContentLength > h.MaxSize {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w (%d bytes, limit %d)", ErrTooLarge, resp.ContentLength, h.MaxSize)
+	}
+
+	// The size limit applies to the transferred, possibly compressed, body and again to
+	// the decompressed content, so that a small .gz cannot expand without bound
+	rc, err := decompress(strings.SplitN(ref, "?", 2)[0], &limitedBody{body: resp.Body, remaining: h.MaxSize})
+	if err != nil {
+		return nil, err
+	}
+	return &limitedBody{body: rc, remaining: h.MaxSize}, nil
+}
+
+// List returns the URL itself, since a URL always refers to a single file
+func (HTTP) List(ref string, recursive bool) ([]string, error) {
+	return []string{ref}, nil
+}
+
+// limitedBody fails with ErrTooLarge once more than the allowed number of bytes are read
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrTooLarge
+	}
+	// Read one byte past the limit to tell an exact fit from an oversized body
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.body.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+func (l *limitedBody) Close() error {
+	return l.body.Close()
+}
@@ -0,0 +1,122 @@
+package source
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsURL(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected bool
+	}{
+		{ref: "http://example.com/flight.igc", expected: true},
+		{ref: "HTTPS://example.com/flight.igc", expected: true},
+		{ref: "flight.igc", expected: false},
+		{ref: "ftp://example.com/flight.igc", expected: false},
+		{ref: "/tmp/http/flight.igc", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if result := IsURL(tt.ref); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestHTTPOpen(t *testing.T) {
+	const content = "AXSDUB54EB\nHFDTE300723\n"
+	compressed := gzipData(t, []byte(content))
+	// 1 MiB of padding compresses to about 1 KiB
+	bomb := gzipData(t, bytes.Repeat([]byte("\n"), 1<<20))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flight.igc":
+			fmt.Fprint(w, content)
+		case "/flight.igc.gz":
+			w.Write(compressed)
+		case "/bomb.igc.gz":
+			w.Write(bomb)
+		case "/chunked.igc":
+			// Flushing before writing everything forces a body without Content-Length
+			fmt.Fprint(w, content)
+			w.(http.Flusher).Flush()
+			fmt.Fprint(w, content)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		maxSize     int64
+		expected    string
+		expectError error
+		expectFail  bool
+	}{
+		{name: "ok", path: "/flight.igc", maxSize: DefaultMaxSize, expected: content},
+		{name: "exact size", path: "/flight.igc", maxSize: int64(len(content)), expected: content},
+		{name: "not found", path: "/missing.igc", maxSize: DefaultMaxSize, expectFail: true},
+		{name: "announced size too large", path: "/flight.igc", maxSize: 5, expectError: ErrTooLarge},
+		{name: "streamed size too large", path: "/chunked.igc", maxSize: int64(len(content)) + 5, expectError: ErrTooLarge},
+		{name: "gzip", path: "/flight.igc.gz", maxSize: DefaultMaxSize, expected: content},
+		{name: "decompressed size too large", path: "/bomb.igc.gz", maxSize: 64 << 10, expectError: ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := HTTP{Client: server.Client(), MaxSize: tt.maxSize}
+
+			body, err := src.Open(server.URL + tt.path)
+			var data []byte
+			if err == nil {
+				data, err = io.ReadAll(body)
+				body.Close()
+			}
+
+			switch {
+			case tt.expectError != nil:
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+			case tt.expectFail:
+				if err == nil || !strings.Contains(err.Error(), "404") {
+					t.Errorf("expected status error, got %v", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if string(data) != tt.expected {
+					t.Errorf("expected %q, got %q", tt.expected, string(data))
+				}
+			}
+		})
+	}
+}
+
+// gzipData compresses data in memory
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
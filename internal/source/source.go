@@ -0,0 +1,120 @@
+package source
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Source provides access to IGC files independently of where they are stored
+type Source interface {
+	// Open opens the IGC file identified by ref for reading
+	Open(ref string) (io.ReadCloser, error)
+	// List resolves ref (a file or a directory) to the IGC files it refers to.
+	// If recursive is true, nested directories are searched as well.
+	List(ref string, recursive bool) ([]string, error)
+}
+
+// FileSystem is a Source backed by the local filesystem
+type FileSystem struct{}
+
+// IsIGCFile reports whether the name has an IGC file extension, plain or gzip-compressed
+func IsIGCFile(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".igc") || strings.HasSuffix(lower, ".igc.gz")
+}
+
+// IsGzipFile reports whether the name has a gzip extension
+func IsGzipFile(name string) bool {
+	return strings.ToLower(filepath.Ext(name)) == ".gz"
+}
+
+// Open opens a file from the local filesystem, decompressing .gz files transparently
+func (FileSystem) Open(ref string) (io.ReadCloser, error) {
+	file, err := os.Open(ref)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(ref, file)
+}
+
+// decompress wraps rc in a gzip reader when ref names a gzip file, and returns rc
+// unchanged otherwise. Closing the result closes rc.
+func decompress(ref string, rc io.ReadCloser) (io.ReadCloser, error) {
+	if !IsGzipFile(ref) {
+		return rc, nil
+	}
+	reader, err := gzip.NewReader(rc)
+	if err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", ref, err)
+	}
+	return &gzipReadCloser{Reader: reader, file: rc}, nil
+}
+
+// gzipReadCloser closes both the gzip reader and the underlying file
+type gzipReadCloser struct {
+	*gzip.Reader
+	file io.ReadCloser
+}
+
+func (g *gzipReadCloser) Close() error {
+	err := g.Reader.Close()
+	if closeErr := g.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// List returns the IGC files at the given path, which may be a file, a directory or a
+// glob pattern. Patterns are only expanded when no file exists under the literal name.
+func (FileSystem) List(ref string, recursive bool) ([]string, error) {
+	var igcFiles []string
+
+	stat, err := os.Stat(ref)
+	if err != nil {
+		if IsGlob(ref) {
+			return Glob(ref)
+		}
+		return nil, fmt.Errorf("error accessing %s: %w", ref, err)
+	}
+
+	if !stat.IsDir() {
+		if !IsIGCFile(ref) {
+			return nil, fmt.Errorf("file %s is not an IGC file", ref)
+		}
+		return []string{ref}, nil
+	}
+
+	if recursive {
+		err = filepath.WalkDir(ref, func(filePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && IsIGCFile(filePath) {
+				igcFiles = append(igcFiles, filePath)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error walking directory %s: %w", ref, err)
+		}
+		return igcFiles, nil
+	}
+
+	entries, err := os.ReadDir(ref)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory %s: %w", ref, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && IsIGCFile(entry.Name()) {
+			igcFiles = append(igcFiles, filepath.Join(ref, entry.Name()))
+		}
+	}
+
+	return igcFiles, nil
+}
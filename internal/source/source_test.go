@@ -0,0 +1,155 @@
+package source
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsIGCFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		expected bool
+	}{
+		{name: "lowercase extension", filename: "flight.igc", expected: true},
+		{name: "uppercase extension", filename: "FLIGHT.IGC", expected: true},
+		{name: "other extension", filename: "flight.txt", expected: false},
+		{name: "no extension", filename: "flight", expected: false},
+		{name: "gzip-compressed", filename: "flight.igc.gz", expected: true},
+		{name: "uppercase gzip-compressed", filename: "FLIGHT.IGC.GZ", expected: true},
+		{name: "other gzip-compressed", filename: "flight.txt.gz", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := IsIGCFile(tt.filename); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFileSystemList(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testFiles := []string{
+		"flight1.igc",
+		"notes.txt",
+		"subdir/flight2.igc",
+	}
+	for _, file := range testFiles {
+		fullPath := filepath.Join(tmpDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("test content"), 0644); err != nil {
+			t.Fatalf("failed to create file %s: %v", fullPath, err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		ref           string
+		recursive     bool
+		expectedCount int
+		expectError   bool
+	}{
+		{name: "single file", ref: filepath.Join(tmpDir, "flight1.igc"), expectedCount: 1},
+		{name: "non-IGC file", ref: filepath.Join(tmpDir, "notes.txt"), expectError: true},
+		{name: "missing file", ref: filepath.Join(tmpDir, "missing.igc"), expectError: true},
+		{name: "directory", ref: tmpDir, expectedCount: 1},
+		{name: "directory recursive", ref: tmpDir, recursive: true, expectedCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := FileSystem{}.List(tt.ref, tt.recursive)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(files) != tt.expectedCount {
+				t.Errorf("expected %d files, got %d: %v", tt.expectedCount, len(files), files)
+			}
+		})
+	}
+}
+
+func TestFileSystemOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flight.igc")
+	if err := os.WriteFile(path, []byte("AXXX"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	rc, err := FileSystem{}.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(content) != "AXXX" {
+		t.Errorf("expected content %q, got %q", "AXXX", string(content))
+	}
+
+	if _, err := (FileSystem{}).Open(filepath.Join(t.TempDir(), "missing.igc")); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+func TestFileSystemOpenGzip(t *testing.T) {
+	const content = "AXSDUB54EB\nHFDTE300723\n"
+	tmpDir := t.TempDir()
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(content))
+	writer.Close()
+
+	files := map[string][]byte{
+		"flight.igc":    []byte(content),
+		"flight.igc.gz": compressed.Bytes(),
+		"broken.igc.gz": []byte(content), // not actually compressed
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), data, 0644); err != nil {
+			t.Fatalf("failed to create file %s: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"flight.igc", "flight.igc.gz"} {
+		t.Run(name, func(t *testing.T) {
+			file, err := FileSystem{}.Open(filepath.Join(tmpDir, name))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer file.Close()
+
+			data, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			if string(data) != content {
+				t.Errorf("expected %q, got %q", content, string(data))
+			}
+		})
+	}
+
+	if _, err := (FileSystem{}).Open(filepath.Join(tmpDir, "broken.igc.gz")); err == nil {
+		t.Error("expected error for invalid gzip data")
+	}
+}
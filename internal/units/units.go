@@ -1,10 +1,16 @@
 package units
 
+import (
+	"fmt"
+	"math"
+)
+
 // Unit constants
 const (
 	// Altitude units
 	AltitudeMeters = "m"
 	AltitudeFeet   = "ft"
+	AltitudeFL     = "fl" // flight level, hundreds of feet
 
 	// Speed units
 	SpeedKmh   = "kmh"
@@ -16,9 +22,18 @@ const (
 	ClimbMs  = "ms"  // meters per second
 	ClimbFpm = "fpm" // feet per minute
 
+	// Distance units
+	DistanceKm            = "km"
+	DistanceMiles         = "mi" // statute miles
+	DistanceNauticalMiles = "nm"
+
 	// Time formats
 	TimeFormat24h  = "24h"
 	TimeFormatAMPM = "ampm"
+
+	// Coordinate formats
+	CoordFormatDecimal = "decimal" // decimal degrees, e.g. 45.814,6.246
+	CoordFormatDMS     = "dms"     // degrees, minutes and seconds, e.g. 45°48'50"N 6°14'46"E
 )
 
 // Unit conversion constants
@@ -27,6 +42,10 @@ const (
 	KmhToMph     = 0.621371
 	KmhToKnots   = 0.539957
 	MsToKmh      = 3.6 // meters per second to kilometers per hour
+
+	MetersPerKm           = 1000
+	MetersPerMile         = 1609.344
+	MetersPerNauticalMile = 1852
 )
 
 // Altitude converts altitude from meters to the specified unit
@@ -34,11 +53,45 @@ func Altitude(meters float64, unit string) float64 {
 	switch unit {
 	case AltitudeFeet:
 		return meters * MetersToFeet
+	case AltitudeFL:
+		// Rounded to whole levels; low and negative altitudes still yield a number
+		return math.Round(meters * MetersToFeet / 100)
 	default: // meters
 		return meters
 	}
 }
 
+// Height converts a height or an altitude difference, such as a climb gain or a thermal
+// radius, from meters to the specified altitude unit. Flight levels only describe
+// absolute altitudes, so heights are given in feet for them.
+func Height(meters float64, unit string) float64 {
+	return Altitude(meters, HeightUnit(unit))
+}
+
+// HeightUnit returns the unit used for heights with the altitude unit: feet for flight
+// levels, the altitude unit otherwise
+func HeightUnit(unit string) string {
+	if unit == AltitudeFL {
+		return AltitudeFeet
+	}
+	return unit
+}
+
+// FormatAltitude formats an absolute altitude given in meters in the unit, truncated to
+// a whole number with the symbol appended, e.g. 1500m, or as FL049 for flight levels
+func FormatAltitude(meters float64, unit string) string {
+	return FormatAltitudeValue(int(Altitude(meters, unit)), unit)
+}
+
+// FormatAltitudeValue formats an altitude already converted to the unit like
+// FormatAltitude
+func FormatAltitudeValue(value int, unit string) string {
+	if unit == AltitudeFL {
+		return fmt.Sprintf("FL%03d", value)
+	}
+	return fmt.Sprintf("%d%s", value, AltitudeSymbol(unit))
+}
+
 // Speed converts speed from km/h to the specified unit
 func Speed(kmh float64, unit string) float64 {
 	switch unit {
@@ -63,11 +116,42 @@ func Climb(ms float64, unit string) float64 {
 	}
 }
 
+// Distance converts a distance from meters to the specified unit
+func Distance(meters float64, unit string) float64 {
+	switch unit {
+	case DistanceMiles:
+		return meters / MetersPerMile
+	case DistanceNauticalMiles:
+		return meters / MetersPerNauticalMile
+	default: // km
+		return meters / MetersPerKm
+	}
+}
+
+// ClimbDecimals returns the number of decimals worth showing for a climb rate in the
+// unit: one for m/s, where 2.4 and 2.0 differ noticeably, none for ft/min
+func ClimbDecimals(unit string) int {
+	switch unit {
+	case ClimbFpm:
+		return 0
+	default: // ms
+		return 1
+	}
+}
+
+// Round rounds value to the given number of decimals
+func Round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
+
 // AltitudeSymbol returns the symbol for the altitude unit
 func AltitudeSymbol(unit string) string {
 	switch unit {
 	case AltitudeFeet:
 		return "ft"
+	case AltitudeFL:
+		return "FL"
 	default:
 		return "m"
 	}
@@ -97,10 +181,22 @@ func ClimbSymbol(unit string) string {
 	}
 }
 
+// DistanceSymbol returns the symbol for the distance unit
+func DistanceSymbol(unit string) string {
+	switch unit {
+	case DistanceMiles:
+		return "mi"
+	case DistanceNauticalMiles:
+		return "NM"
+	default:
+		return "km"
+	}
+}
+
 // ValidateAltitudeUnit checks if the given altitude unit is valid
 func ValidateAltitudeUnit(unit string) bool {
 	switch unit {
-	case AltitudeMeters, AltitudeFeet:
+	case AltitudeMeters, AltitudeFeet, AltitudeFL:
 		return true
 	default:
 		return false
@@ -127,6 +223,26 @@ func ValidateClimbUnit(unit string) bool {
 	}
 }
 
+// ValidateDistanceUnit checks if the given distance unit is valid
+func ValidateDistanceUnit(unit string) bool {
+	switch unit {
+	case DistanceKm, DistanceMiles, DistanceNauticalMiles:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateCoordFormat checks if the given coordinate format is valid
+func ValidateCoordFormat(format string) bool {
+	switch format {
+	case CoordFormatDecimal, CoordFormatDMS:
+		return true
+	default:
+		return false
+	}
+}
+
 // ValidateTimeFormat checks if the given time format is valid
 func ValidateTimeFormat(format string) bool {
 	switch format {
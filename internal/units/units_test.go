@@ -34,6 +34,27 @@ func TestAltitude(t *testing.T) {
 			expected:  0,
 			tolerance: 0.01,
 		},
+		{
+			name:      "meters to flight level",
+			meters:    3048, // 10,000 ft
+			unit:      "fl",
+			expected:  100,
+			tolerance: 0,
+		},
+		{
+			name:      "flight level rounds to nearest level",
+			meters:    1980, // 6,496 ft
+			unit:      "fl",
+			expected:  65,
+			tolerance: 0,
+		},
+		{
+			name:      "negative altitude to flight level",
+			meters:    -100, // -328 ft
+			unit:      "fl",
+			expected:  -3,
+			tolerance: 0,
+		},
 		{
 			name:      "negative altitude",
 			meters:    -100,
@@ -193,6 +214,11 @@ func TestAltitudeSymbol(t *testing.T) {
 			unit:     "ft",
 			expected: "ft",
 		},
+		{
+			name:     "flight level unit",
+			unit:     "fl",
+			expected: "FL",
+		},
 		{
 			name:     "unknown unit defaults to meters",
 			unit:     "unknown",
@@ -285,3 +311,118 @@ func TestClimbSymbol(t *testing.T) {
 		})
 	}
 }
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name      string
+		meters    float64
+		unit      string
+		expected  float64
+		tolerance float64
+	}{
+		{name: "meters to km", meters: 12500, unit: "km", expected: 12.5, tolerance: 0.001},
+		{name: "meters to statute miles", meters: 1609.344, unit: "mi", expected: 1, tolerance: 0.001},
+		{name: "meters to nautical miles", meters: 18520, unit: "nm", expected: 10, tolerance: 0.001},
+		{name: "zero distance", meters: 0, unit: "mi", expected: 0, tolerance: 0.001},
+		{name: "unknown unit defaults to km", meters: 3000, unit: "unknown", expected: 3, tolerance: 0.001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Distance(tt.meters, tt.unit)
+			if math.Abs(result-tt.expected) > tt.tolerance {
+				t.Errorf("expected %f, got %f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDistanceSymbol(t *testing.T) {
+	tests := []struct {
+		unit     string
+		expected string
+	}{
+		{unit: "km", expected: "km"},
+		{unit: "mi", expected: "mi"},
+		{unit: "nm", expected: "NM"},
+		{unit: "unknown", expected: "km"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			if result := DistanceSymbol(tt.unit); result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+			if valid := ValidateDistanceUnit(tt.unit); valid != (tt.unit != "unknown") {
+				t.Errorf("unexpected validity %v for %s", valid, tt.unit)
+			}
+		})
+	}
+}
+
+func TestClimbDecimals(t *testing.T) {
+	tests := []struct {
+		unit     string
+		value    float64
+		expected float64
+	}{
+		{unit: "ms", value: 2.44, expected: 2.4},
+		{unit: "ms", value: 2.46, expected: 2.5},
+		{unit: "fpm", value: 472.44, expected: 472},
+		{unit: "unknown", value: -1.25, expected: -1.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			result := Round(tt.value, ClimbDecimals(tt.unit))
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestHeight(t *testing.T) {
+	tests := []struct {
+		name     string
+		meters   float64
+		unit     string
+		expected float64
+	}{
+		{name: "meters", meters: 500, unit: "m", expected: 500},
+		{name: "feet", meters: 500, unit: "ft", expected: 1640.42},
+		{name: "flight level in feet", meters: 500, unit: "fl", expected: 1640.42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Height(tt.meters, tt.unit)
+			if math.Abs(result-tt.expected) > 0.01 {
+				t.Errorf("expected %.2f, got %.2f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatAltitude(t *testing.T) {
+	tests := []struct {
+		name     string
+		meters   float64
+		unit     string
+		expected string
+	}{
+		{name: "meters", meters: 1500.7, unit: "m", expected: "1500m"},
+		{name: "feet", meters: 1500, unit: "ft", expected: "4921ft"},
+		{name: "flight level", meters: 2100, unit: "fl", expected: "FL069"},
+		{name: "high flight level", meters: 3048, unit: "fl", expected: "FL100"},
+		{name: "ground level", meters: 0, unit: "fl", expected: "FL000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := FormatAltitude(tt.meters, tt.unit); result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"math"
+	"os"
 	"time"
 )
 
@@ -15,14 +19,98 @@ func FormatTime(t time.Time, format string) string {
 	}
 }
 
-// FormatDuration formats a duration as "XhYm"
+// FormatDuration formats a duration as "XhYm", truncating seconds. Negative durations
+// are prefixed with a minus sign, as in "-1h30m".
 func FormatDuration(d time.Duration) string {
+	sign, d := durationSign(d)
 	hours := int(d.Hours())
 	minutes := int(d.Minutes()) % 60
-	return fmt.Sprintf("%dh%dm", hours, minutes)
+	return fmt.Sprintf("%s%dh%dm", sign, hours, minutes)
 }
 
-// FormatCoordinates formats lat/lon as a string
-func FormatCoordinates(lat, lon float64) string {
-	return fmt.Sprintf("%.3f,%.3f", lat, lon)
+// FormatDurationLong formats a duration as "XhYmZs", truncating fractions of a second,
+// for short flights and precise analysis. Negative durations are prefixed with a minus
+// sign.
+func FormatDurationLong(d time.Duration) string {
+	sign, d := durationSign(d)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	return fmt.Sprintf("%s%dh%dm%ds", sign, hours, minutes, seconds)
+}
+
+// durationSign splits a duration into its sign prefix and absolute value
+func durationSign(d time.Duration) (string, time.Duration) {
+	if d < 0 {
+		return "-", -d
+	}
+	return "", d
+}
+
+// DefaultCoordPrecision is the default number of decimals of formatted coordinates,
+// about 110 m of latitude
+const DefaultCoordPrecision = 3
+
+// FormatCoordinates formats lat/lon as a string with the given number of decimals
+func FormatCoordinates(lat, lon float64, precision int) string {
+	precision = max(precision, 0)
+	return fmt.Sprintf("%.*f,%.*f", precision, lat, precision, lon)
+}
+
+// FormatCoordinatesDMS formats lat/lon in degrees, minutes and whole seconds with
+// hemisphere letters, e.g. 45°48'50"N 6°14'46"E
+func FormatCoordinatesDMS(lat, lon float64) string {
+	return formatDMS(lat, "N", "S") + " " + formatDMS(lon, "E", "W")
+}
+
+// formatDMS formats one coordinate in degrees, minutes and seconds, rounded to the second
+func formatDMS(value float64, positive, negative string) string {
+	hemisphere := positive
+	if value < 0 {
+		hemisphere = negative
+	}
+	seconds := int(math.Round(math.Abs(value) * 3600))
+	return fmt.Sprintf("%d°%02d'%02d\"%s", seconds/3600, seconds%3600/60, seconds%60, hemisphere)
+}
+
+// FormatPosition formats lat/lon according to the specified format, "dms" or decimal
+// degrees with precision decimals
+func FormatPosition(lat, lon float64, format string, precision int) string {
+	switch format {
+	case "dms":
+		return FormatCoordinatesDMS(lat, lon)
+	default: // decimal
+		return FormatCoordinates(lat, lon, precision)
+	}
+}
+
+// MarshalJSON marshals v as JSON, indenting each level with indent or producing
+// compact output when indent is empty
+func MarshalJSON(v interface{}, indent string) ([]byte, error) {
+	if indent == "" {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", indent)
+}
+
+// IndentJSON re-formats already marshaled JSON with indent per level, returning the
+// data unchanged when indent is empty
+func IndentJSON(data []byte, indent string) ([]byte, error) {
+	if indent == "" {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", indent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// IsTerminal reports whether the file is an interactive terminal rather than a pipe or file
+func IsTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
 }
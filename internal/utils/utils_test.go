@@ -120,6 +120,11 @@ func TestFormatDuration(t *testing.T) {
 			duration: 25*time.Hour + 30*time.Minute,
 			expected: "25h30m",
 		},
+		{
+			name:     "negative duration",
+			duration: -(time.Hour + 30*time.Minute),
+			expected: "-1h30m",
+		},
 	}
 
 	for _, tt := range tests {
@@ -132,6 +137,28 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestFormatDurationLong(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		expected string
+	}{
+		{name: "zero duration", duration: 0, expected: "0h0m0s"},
+		{name: "short flight", duration: 90 * time.Second, expected: "0h1m30s"},
+		{name: "hours minutes seconds", duration: 2*time.Hour + 47*time.Minute + 30*time.Second, expected: "2h47m30s"},
+		{name: "fractional seconds truncated", duration: 59*time.Second + 900*time.Millisecond, expected: "0h0m59s"},
+		{name: "negative duration", duration: -(time.Minute + 5*time.Second), expected: "-0h1m5s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := FormatDurationLong(tt.duration); result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestFormatCoordinates(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -179,10 +206,89 @@ func TestFormatCoordinates(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := FormatCoordinates(tt.lat, tt.lon)
+			result := FormatCoordinates(tt.lat, tt.lon, DefaultCoordPrecision)
 			if result != tt.expected {
 				t.Errorf("expected %s, got %s", tt.expected, result)
 			}
 		})
 	}
 }
+
+func TestFormatPosition(t *testing.T) {
+	tests := []struct {
+		name      string
+		lat       float64
+		lon       float64
+		format    string
+		precision int
+		expected  string
+	}{
+		{"decimal default precision", 45.8141592653, 6.2467890123, "decimal", 3, "45.814,6.247"},
+		{"decimal high precision", 45.8141592653, 6.2467890123, "decimal", 5, "45.81416,6.24679"},
+		{"decimal whole degrees", 45.8141592653, 6.2467890123, "decimal", 0, "46,6"},
+		{"negative precision", 45.8141592653, 6.2467890123, "decimal", -1, "46,6"},
+		{"dms northern eastern", 45.8139, 6.2461, "dms", 3, "45°48'50\"N 6°14'46\"E"},
+		{"dms southern western", -33.4489, -70.6693, "dms", 3, "33°26'56\"S 70°40'09\"W"},
+		{"dms seconds round up to minute", 45.99999, 6.5, "dms", 3, "46°00'00\"N 6°30'00\"E"},
+		{"dms zero", 0, 0, "dms", 3, "0°00'00\"N 0°00'00\"E"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := FormatPosition(tt.lat, tt.lon, tt.format, tt.precision); result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	value := map[string]int{"a": 1}
+
+	tests := []struct {
+		name     string
+		indent   string
+		expected string
+	}{
+		{name: "compact", indent: "", expected: `{"a":1}`},
+		{name: "two spaces", indent: "  ", expected: "{\n  \"a\": 1\n}"},
+		{name: "tab", indent: "\t", expected: "{\n\t\"a\": 1\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := MarshalJSON(value, tt.indent)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, string(result))
+			}
+		})
+	}
+}
+
+func TestIndentJSON(t *testing.T) {
+	data := []byte(`{"a":[1,2]}`)
+
+	compact, err := IndentJSON(data, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(compact) != `{"a":[1,2]}` {
+		t.Errorf("expected compact data unchanged, got %q", string(compact))
+	}
+
+	indented, err := IndentJSON(data, "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "{\n  \"a\": [\n    1,\n    2\n  ]\n}"
+	if string(indented) != expected {
+		t.Errorf("expected %q, got %q", expected, string(indented))
+	}
+
+	if _, err := IndentJSON([]byte("{"), "  "); err == nil {
+		t.Errorf("expected error for invalid JSON")
+	}
+}
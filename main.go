@@ -11,7 +11,7 @@ import (
 
 func main() {
 	// Initialize configuration
-	cfg := config.Load()
+	cfg := config.Load(config.FileFromArgs(os.Args[1:]))
 
 	// Initialize centralized flag configuration
 	flagConfig := flags.NewFlagConfig(cfg)